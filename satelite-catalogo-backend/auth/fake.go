@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"sync"
+)
+
+// Fake es un proveedor en memoria para pruebas, se instala con SetProvider. Usuarios va del email
+// (o nombre de usuario, se completa con AUTH_EMAIL_DOMAIN) a la contraseña
+type Fake struct {
+	mu       sync.Mutex
+	Usuarios map[string]string
+}
+
+func NewFake(usuarios map[string]string) *Fake {
+	f := &Fake{Usuarios: map[string]string{}}
+	for usuario, password := range usuarios {
+		f.Usuarios[EmailFor(usuario)] = password
+	}
+	return f
+}
+
+func (f *Fake) Name() string {
+	return "fake"
+}
+
+func (f *Fake) Authenticate(ctx context.Context, username, password string) (*Identity, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	email := EmailFor(username)
+	if esperada, ok := f.Usuarios[email]; !ok || esperada != password {
+		return nil, ErrCredencialesInvalidas
+	}
+	return &Identity{Username: UsernameFor(username), Email: email}, nil
+}
+
+// SetPassword guarda la contraseña en memoria, como el proveedor local
+func (f *Fake) SetPassword(ctx context.Context, email, password string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Usuarios[EmailFor(email)] = password
+	return nil
+}
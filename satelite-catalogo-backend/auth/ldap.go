@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LDAPProvider verifica las credenciales con un bind simple en un directorio LDAP. El DN del usuario
+// se arma con LDAP_BIND_DN reemplazando %s por el nombre de usuario escapado, ej:
+// uid=%s,ou=people,dc=usach,dc=cl. Si la entrada tiene LDAP_MAIL_ATTRIBUTE (por defecto mail) se usa
+// como email, si no el nombre de usuario con AUTH_EMAIL_DOMAIN
+type LDAPProvider struct {
+	URL           string // ldap://host:389 o ldaps://host:636
+	BindDN        string
+	MailAttribute string
+	StartTLS      bool
+	TLSConfig     *tls.Config
+}
+
+func NewLDAPProvider() (*LDAPProvider, error) {
+	p := &LDAPProvider{
+		URL:           os.Getenv("LDAP_URL"),
+		BindDN:        os.Getenv("LDAP_BIND_DN"),
+		MailAttribute: os.Getenv("LDAP_MAIL_ATTRIBUTE"),
+		StartTLS:      os.Getenv("LDAP_START_TLS") == "true",
+		TLSConfig:     &tls.Config{InsecureSkipVerify: os.Getenv("LDAP_INSECURE_SKIP_VERIFY") == "true"},
+	}
+	if p.URL == "" || !strings.Contains(p.BindDN, "%s") {
+		return nil, errors.New("el proveedor ldap requiere LDAP_URL y LDAP_BIND_DN con %s para el usuario")
+	}
+	if p.MailAttribute == "" {
+		p.MailAttribute = "mail"
+	}
+	return p, nil
+}
+
+func (p *LDAPProvider) Name() string {
+	return "ldap"
+}
+
+func (p *LDAPProvider) Authenticate(ctx context.Context, username, password string) (*Identity, error) {
+	username = UsernameFor(username)
+	// un bind simple con contraseña vacía es un bind anónimo que el servidor acepta
+	if username == "" || password == "" {
+		return nil, ErrCredencialesInvalidas
+	}
+
+	conn, err := ldap.DialURL(p.URL, ldap.DialWithTLSConfig(p.TLSConfig), ldap.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}))
+	if err != nil {
+		return nil, fmt.Errorf("error al conectar con LDAP: %w", err)
+	}
+	defer conn.Close()
+	timeout := 15 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	conn.SetTimeout(timeout)
+	if p.StartTLS {
+		if err := conn.StartTLS(p.TLSConfig); err != nil {
+			return nil, fmt.Errorf("error al iniciar TLS con LDAP: %w", err)
+		}
+	}
+
+	dn := fmt.Sprintf(p.BindDN, ldap.EscapeDN(username))
+	if err := conn.Bind(dn, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrCredencialesInvalidas
+		}
+		return nil, fmt.Errorf("error al autenticar con LDAP: %w", err)
+	}
+
+	identity := &Identity{Username: username, Email: EmailFor(username)}
+	search := ldap.NewSearchRequest(dn, ldap.ScopeBaseObject, ldap.NeverDerefAliases, 1, 0, false,
+		"(objectClass=*)", []string{p.MailAttribute}, nil)
+	if result, err := conn.Search(search); err == nil && len(result.Entries) > 0 {
+		if mail := result.Entries[0].GetAttributeValue(p.MailAttribute); mail != "" {
+			identity.Email = mail
+		}
+	}
+	return identity, nil
+}
@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"catalogo-backend/repositories"
+	"catalogo-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// LocalProvider verifica las contraseñas guardadas con bcrypt en users.password, para desarrollo y
+// para instalaciones sin un directorio externo
+type LocalProvider struct{}
+
+func NewLocalProvider() *LocalProvider {
+	return &LocalProvider{}
+}
+
+func (p *LocalProvider) Name() string {
+	return "local"
+}
+
+// hash para comparar cuando el usuario no existe o no tiene contraseña, así la respuesta tarda lo mismo
+var dummyPasswordHash = utils.GeneratePassword("catalogo-usuario-inexistente")
+
+func (p *LocalProvider) Authenticate(ctx context.Context, username, password string) (*Identity, error) {
+	email := EmailFor(username)
+	user, err := repositories.NewUserRepository().FindOne(bson.M{"email": email})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.Password == "" {
+		utils.ComparePasswords(dummyPasswordHash, password)
+		return nil, ErrCredencialesInvalidas
+	}
+	if err := utils.ComparePasswords(user.Password, password); err != nil {
+		return nil, ErrCredencialesInvalidas
+	}
+	return &Identity{Username: user.Username, Email: user.Email}, nil
+}
+
+// SetPassword guarda la contraseña del usuario con bcrypt
+func (p *LocalProvider) SetPassword(ctx context.Context, email, password string) error {
+	if password == "" {
+		return errors.New("la contraseña no puede estar vacía")
+	}
+	ok, err := repositories.NewUserRepository().SetPassword(ctx, EmailFor(email), utils.GeneratePassword(password))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("no se encontró el usuario")
+	}
+	return nil
+}
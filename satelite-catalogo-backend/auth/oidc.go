@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// ErrSSONoConfigurado es el inicio de sesión único sin configurar (OIDC_ISSUER o SAML_IDP_METADATA_URL)
+var ErrSSONoConfigurado = errors.New("el inicio de sesión único no está configurado")
+
+// OIDCRelyingParty inicia sesión con el flujo authorization code (con PKCE) de un IdP OpenID Connect.
+// Configuración: OIDC_ISSUER, OIDC_CLIENT_ID, OIDC_CLIENT_SECRET, OIDC_REDIRECT_URL (la URL pública de
+// /auth/oidc/callback), OIDC_SCOPES y los claims OIDC_EMAIL_CLAIM (email) y OIDC_RUT_CLAIM (rut)
+type OIDCRelyingParty struct {
+	config     oauth2.Config
+	verifier   *oidc.IDTokenVerifier
+	EmailClaim string
+	RutClaim   string
+}
+
+var (
+	oidcRP *OIDCRelyingParty
+	oidcMu sync.Mutex
+)
+
+// OIDC retorna el relying party configurado. El discovery del IdP se hace en el primer uso y se
+// reintenta en el siguiente si el IdP no respondió
+func OIDC(ctx context.Context) (*OIDCRelyingParty, error) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	if oidcRP != nil {
+		return oidcRP, nil
+	}
+	issuer := strings.TrimSpace(os.Getenv("OIDC_ISSUER"))
+	if issuer == "" {
+		return nil, ErrSSONoConfigurado
+	}
+	rp, err := NewOIDCRelyingParty(ctx, issuer, os.Getenv("OIDC_CLIENT_ID"), os.Getenv("OIDC_CLIENT_SECRET"), os.Getenv("OIDC_REDIRECT_URL"))
+	if err != nil {
+		return nil, err
+	}
+	oidcRP = rp
+	return oidcRP, nil
+}
+
+// NewOIDCRelyingParty hace el discovery del IdP en issuer y arma el cliente
+func NewOIDCRelyingParty(ctx context.Context, issuer, clientID, clientSecret, redirectURL string) (*OIDCRelyingParty, error) {
+	if clientID == "" || redirectURL == "" {
+		return nil, errors.New("OIDC requiere OIDC_CLIENT_ID y OIDC_REDIRECT_URL")
+	}
+	provider, err := oidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la configuración del IdP OIDC: %w", err)
+	}
+	return &OIDCRelyingParty{
+		config: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			RedirectURL:  redirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       strings.Fields(envOrDefault("OIDC_SCOPES", "openid email profile")),
+		},
+		verifier:   provider.Verifier(&oidc.Config{ClientID: clientID}),
+		EmailClaim: envOrDefault("OIDC_EMAIL_CLAIM", "email"),
+		RutClaim:   envOrDefault("OIDC_RUT_CLAIM", "rut"),
+	}, nil
+}
+
+// AuthURL retorna la URL del IdP a la que se redirige el navegador y el valor de la cookie con el
+// estado, que se debe entregar a Callback
+func (rp *OIDCRelyingParty) AuthURL() (string, string, error) {
+	state := ssoState{Verifier: oauth2.GenerateVerifier()}
+	var err error
+	if state.State, err = randomString(); err != nil {
+		return "", "", err
+	}
+	if state.Nonce, err = randomString(); err != nil {
+		return "", "", err
+	}
+	cookie, err := state.seal()
+	if err != nil {
+		return "", "", err
+	}
+	url := rp.config.AuthCodeURL(state.State, oidc.Nonce(state.Nonce), oauth2.S256ChallengeOption(state.Verifier))
+	return url, cookie, nil
+}
+
+// Callback canjea el código de la respuesta del IdP, verifica el ID token y retorna la identidad
+// con los claims de email y rut
+func (rp *OIDCRelyingParty) Callback(ctx context.Context, code, state, cookie string) (*Identity, error) {
+	s, err := openState(cookie, state)
+	if err != nil {
+		return nil, err
+	}
+	token, err := rp.config.Exchange(ctx, code, oauth2.VerifierOption(s.Verifier))
+	if err != nil {
+		return nil, fmt.Errorf("error al canjear el código con el IdP: %w", err)
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("el IdP no entregó el id_token")
+	}
+	idToken, err := rp.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("id_token inválido: %w", err)
+	}
+	if !hmacEqual(idToken.Nonce, s.Nonce) {
+		return nil, ErrEstadoSSOInvalido
+	}
+
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+	// un email que el IdP marca como no verificado no identifica al usuario
+	if verificado, ok := claims["email_verified"].(bool); ok && !verificado && rp.EmailClaim == "email" {
+		return nil, ErrCredencialesInvalidas
+	}
+	identity := &Identity{
+		Username: claimString(claims["preferred_username"]),
+		Email:    strings.ToLower(claimString(claims[rp.EmailClaim])),
+		Rut:      claimString(claims[rp.RutClaim]),
+	}
+	if identity.Email == "" && identity.Rut == "" {
+		return nil, fmt.Errorf("el IdP no entregó el claim %s ni %s", rp.EmailClaim, rp.RutClaim)
+	}
+	return identity, nil
+}
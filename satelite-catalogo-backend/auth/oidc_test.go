@@ -0,0 +1,291 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+const oidcClientID = "catalogo"
+
+// mockIssuer es un IdP OpenID Connect con discovery, JWKS y token endpoint. Los códigos se emiten con
+// autorizar, como si el usuario hubiera iniciado sesión en el IdP
+type mockIssuer struct {
+	*httptest.Server
+	key     *rsa.PrivateKey
+	mu      sync.Mutex
+	codigos map[string]codigoEmitido
+}
+
+// codigoEmitido es lo que el IdP recuerda de la autorización para canjear el código
+type codigoEmitido struct {
+	challenge string
+	claims    map[string]interface{}
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIssuer{key: key, codigos: map[string]codigoEmitido{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                m.URL,
+			"authorization_endpoint":                m.URL + "/authorize",
+			"token_endpoint":                        m.URL + "/token",
+			"jwks_uri":                              m.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// token canjea el código si el code_verifier corresponde al code_challenge S256 de la autorización
+func (m *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m.mu.Lock()
+	emitido, ok := m.codigos[r.Form.Get("code")]
+	delete(m.codigos, r.Form.Get("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != emitido.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     m.sign(emitido.claims),
+	})
+}
+
+// sign firma el id_token con RS256
+func (m *mockIssuer) sign(claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "test", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, sum[:])
+	if err != nil {
+		panic(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// autorizar emite un código para la URL de autorización, con los claims por defecto de ana y los
+// cambios indicados (nil quita el claim)
+func (m *mockIssuer) autorizar(t *testing.T, authURL string, cambios map[string]interface{}) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Fatalf("la autorización no usa PKCE S256: %s", authURL)
+	}
+	claims := map[string]interface{}{
+		"iss":                m.URL,
+		"aud":                q.Get("client_id"),
+		"sub":                "ana",
+		"iat":                time.Now().Unix(),
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"nonce":              q.Get("nonce"),
+		"email":              "Ana@usach.cl",
+		"email_verified":     true,
+		"preferred_username": "ana",
+		"rut":                "11111111-1",
+	}
+	for k, v := range cambios {
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+	}
+	code := randomCode(t)
+	m.mu.Lock()
+	m.codigos[code] = codigoEmitido{challenge: q.Get("code_challenge"), claims: claims}
+	m.mu.Unlock()
+	return code
+}
+
+func randomCode(t *testing.T) string {
+	t.Helper()
+	code, err := randomString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func newTestRelyingParty(t *testing.T, issuer *mockIssuer) *OIDCRelyingParty {
+	t.Helper()
+	rp, err := NewOIDCRelyingParty(context.Background(), issuer.URL, oidcClientID, "secreto", "https://api.example/auth/oidc/callback")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rp
+}
+
+// inicio es una redirección al IdP con su cookie de estado
+type inicio struct {
+	url, state, cookie string
+}
+
+func iniciar(t *testing.T, rp *OIDCRelyingParty) inicio {
+	t.Helper()
+	authURL, cookie, err := rp.AuthURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(authURL)
+	return inicio{url: authURL, state: u.Query().Get("state"), cookie: cookie}
+}
+
+func TestOIDCCallback(t *testing.T) {
+	issuer := newMockIssuer(t)
+	rp := newTestRelyingParty(t, issuer)
+	login := iniciar(t, rp)
+
+	identity, err := rp.Callback(context.Background(), issuer.autorizar(t, login.url, nil), login.state, login.cookie)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Email != "ana@usach.cl" || identity.Rut != "11111111-1" || identity.Username != "ana" {
+		t.Errorf("identidad %+v", identity)
+	}
+}
+
+func TestOIDCCallbackRechazado(t *testing.T) {
+	issuer := newMockIssuer(t)
+	rp := newTestRelyingParty(t, issuer)
+
+	casos := []struct {
+		nombre string
+		// callback arma el código, el state y la cookie que recibe Callback
+		callback func(t *testing.T) (code, state, cookie string)
+		esperado error
+	}{
+		{"state distinto al de la cookie", func(t *testing.T) (string, string, string) {
+			login := iniciar(t, rp)
+			return issuer.autorizar(t, login.url, nil), "otro-state", login.cookie
+		}, ErrEstadoSSOInvalido},
+		{"cookie de otro inicio de sesión", func(t *testing.T) (string, string, string) {
+			login, otro := iniciar(t, rp), iniciar(t, rp)
+			return issuer.autorizar(t, login.url, nil), login.state, otro.cookie
+		}, ErrEstadoSSOInvalido},
+		{"cookie adulterada", func(t *testing.T) (string, string, string) {
+			login := iniciar(t, rp)
+			return issuer.autorizar(t, login.url, nil), login.state, "x" + login.cookie
+		}, ErrEstadoSSOInvalido},
+		{"nonce distinto", func(t *testing.T) (string, string, string) {
+			login := iniciar(t, rp)
+			return issuer.autorizar(t, login.url, map[string]interface{}{"nonce": "otro-nonce"}), login.state, login.cookie
+		}, ErrEstadoSSOInvalido},
+		{"id_token sin nonce", func(t *testing.T) (string, string, string) {
+			login := iniciar(t, rp)
+			return issuer.autorizar(t, login.url, map[string]interface{}{"nonce": nil}), login.state, login.cookie
+		}, ErrEstadoSSOInvalido},
+		{"email no verificado", func(t *testing.T) (string, string, string) {
+			login := iniciar(t, rp)
+			return issuer.autorizar(t, login.url, map[string]interface{}{"email_verified": false}), login.state, login.cookie
+		}, ErrCredencialesInvalidas},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			code, state, cookie := c.callback(t)
+			identity, err := rp.Callback(context.Background(), code, state, cookie)
+			if !errors.Is(err, c.esperado) {
+				t.Errorf("error %v, se esperaba %v", err, c.esperado)
+			}
+			if identity != nil {
+				t.Errorf("se aceptó la identidad %+v", identity)
+			}
+		})
+	}
+}
+
+// el IdP rechaza el canje o el id_token no es válido para este cliente
+func TestOIDCCallbackTokenInvalido(t *testing.T) {
+	issuer := newMockIssuer(t)
+	rp := newTestRelyingParty(t, issuer)
+	otroIssuer := newMockIssuer(t)
+
+	casos := []struct {
+		nombre string
+		code   func(t *testing.T, login inicio) string
+	}{
+		{"code_verifier de otro inicio de sesión", func(t *testing.T, login inicio) string {
+			// el código se emitió para el code_challenge de otra redirección
+			return issuer.autorizar(t, iniciar(t, rp).url, nil)
+		}},
+		{"código ya canjeado", func(t *testing.T, login inicio) string {
+			code := issuer.autorizar(t, login.url, nil)
+			if _, err := rp.Callback(context.Background(), code, login.state, login.cookie); err != nil {
+				t.Fatal(err)
+			}
+			return code
+		}},
+		{"otra audiencia", func(t *testing.T, login inicio) string {
+			return issuer.autorizar(t, login.url, map[string]interface{}{"aud": "otro-cliente"})
+		}},
+		{"otro issuer", func(t *testing.T, login inicio) string {
+			return issuer.autorizar(t, login.url, map[string]interface{}{"iss": otroIssuer.URL})
+		}},
+		{"expirado", func(t *testing.T, login inicio) string {
+			return issuer.autorizar(t, login.url, map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})
+		}},
+		{"firmado con otra llave", func(t *testing.T, login inicio) string {
+			anterior := issuer.key
+			issuer.key = otroIssuer.key
+			t.Cleanup(func() { issuer.key = anterior })
+			return issuer.autorizar(t, login.url, nil)
+		}},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			login := iniciar(t, rp)
+			identity, err := rp.Callback(context.Background(), c.code(t, login), login.state, login.cookie)
+			if err == nil {
+				t.Errorf("se aceptó la identidad %+v", identity)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Package auth verifica las credenciales del login contra el proveedor de identidad configurado
+// (AUTH_PROVIDER): la API de autenticación USACH, contraseñas locales con bcrypt o un directorio LDAP.
+// También implementa el inicio de sesión único con un IdP institucional por OpenID Connect o SAML
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrCredencialesInvalidas = errors.New("credenciales inválidas")
+	ErrProveedorDesconocido  = errors.New("AUTH_PROVIDER desconocido, debe ser usach, local o ldap")
+)
+
+// Identity es el usuario verificado por el proveedor, se busca en users por Email. Rut solo lo
+// entregan los IdP de inicio de sesión único que lo tienen
+type Identity struct {
+	Username string
+	Email    string
+	Rut      string
+}
+
+// Provider verifica las credenciales de un usuario
+type Provider interface {
+	Name() string
+	// Authenticate retorna ErrCredencialesInvalidas si el usuario o la contraseña no son válidos
+	Authenticate(ctx context.Context, username, password string) (*Identity, error)
+}
+
+// PasswordStore lo implementan los proveedores que guardan las contraseñas en la base de datos, al crear
+// un usuario se guarda su contraseña en vez de verificarla contra el proveedor
+type PasswordStore interface {
+	SetPassword(ctx context.Context, email, password string) error
+}
+
+var (
+	current     Provider
+	currentErr  error
+	currentOnce sync.Once
+)
+
+// Current retorna el proveedor configurado en AUTH_PROVIDER (por defecto usach)
+func Current() (Provider, error) {
+	currentOnce.Do(func() {
+		if current == nil {
+			current, currentErr = FromConfig(os.Getenv("AUTH_PROVIDER"))
+		}
+	})
+	return current, currentErr
+}
+
+// SetProvider reemplaza el proveedor configurado, para pruebas con un Fake
+func SetProvider(p Provider) {
+	currentOnce.Do(func() {})
+	current, currentErr = p, nil
+}
+
+// FromConfig crea el proveedor por su nombre con la configuración de las variables de entorno
+func FromConfig(name string) (Provider, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "usach":
+		return NewUSACHProvider(), nil
+	case "local":
+		return NewLocalProvider(), nil
+	case "ldap":
+		return NewLDAPProvider()
+	}
+	return nil, fmt.Errorf("%w: %s", ErrProveedorDesconocido, name)
+}
+
+// EmailDomain es el dominio que se agrega a los usuarios que inician sesión sin email
+// (AUTH_EMAIL_DOMAIN, por defecto usach.cl)
+func EmailDomain() string {
+	if domain := strings.TrimSpace(os.Getenv("AUTH_EMAIL_DOMAIN")); domain != "" {
+		return strings.TrimPrefix(domain, "@")
+	}
+	return "usach.cl"
+}
+
+// EmailFor retorna el email del usuario, agregando EmailDomain si se indicó solo el nombre de usuario
+func EmailFor(username string) string {
+	username = strings.TrimSpace(username)
+	if strings.Contains(username, "@") {
+		return username
+	}
+	return username + "@" + EmailDomain()
+}
+
+// UsernameFor quita EmailDomain del usuario, los proveedores externos esperan solo el nombre de usuario
+func UsernameFor(username string) string {
+	return strings.TrimSuffix(strings.TrimSpace(username), "@"+EmailDomain())
+}
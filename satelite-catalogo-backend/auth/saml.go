@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+)
+
+// SAMLServiceProvider inicia sesión como service provider SAML 2.0 con un IdP institucional, con el
+// binding HTTP-Redirect para la solicitud y HTTP-POST para la respuesta. Configuración:
+// SAML_IDP_METADATA_URL, SAML_ROOT_URL (la URL pública del backend), SAML_ENTITY_ID (por defecto la
+// URL de /auth/saml/metadata), SAML_CERT_FILE y SAML_KEY_FILE (certificado y llave RSA del SP) y los
+// atributos SAML_EMAIL_ATTRIBUTE (mail) y SAML_RUT_ATTRIBUTE (rut)
+type SAMLServiceProvider struct {
+	sp             saml.ServiceProvider
+	EmailAttribute string
+	RutAttribute   string
+}
+
+var (
+	samlSP *SAMLServiceProvider
+	samlMu sync.Mutex
+)
+
+// SAML retorna el service provider configurado. La metadata del IdP se descarga en el primer uso y
+// se reintenta en el siguiente si el IdP no respondió
+func SAML(ctx context.Context) (*SAMLServiceProvider, error) {
+	samlMu.Lock()
+	defer samlMu.Unlock()
+	if samlSP != nil {
+		return samlSP, nil
+	}
+	metadataURL := strings.TrimSpace(os.Getenv("SAML_IDP_METADATA_URL"))
+	if metadataURL == "" {
+		return nil, ErrSSONoConfigurado
+	}
+	idpURL, err := url.Parse(metadataURL)
+	if err != nil {
+		return nil, fmt.Errorf("SAML_IDP_METADATA_URL inválida: %w", err)
+	}
+	idpMetadata, err := samlsp.FetchMetadata(ctx, http.DefaultClient, *idpURL)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener la metadata del IdP SAML: %w", err)
+	}
+	keyPair, err := tls.LoadX509KeyPair(os.Getenv("SAML_CERT_FILE"), os.Getenv("SAML_KEY_FILE"))
+	if err != nil {
+		return nil, fmt.Errorf("error al cargar SAML_CERT_FILE y SAML_KEY_FILE: %w", err)
+	}
+	sp, err := NewSAMLServiceProvider(os.Getenv("SAML_ROOT_URL"), os.Getenv("SAML_ENTITY_ID"), idpMetadata, keyPair)
+	if err != nil {
+		return nil, err
+	}
+	samlSP = sp
+	return samlSP, nil
+}
+
+// NewSAMLServiceProvider arma el service provider publicado en rootURL con la metadata del IdP
+func NewSAMLServiceProvider(rootURL, entityID string, idpMetadata *saml.EntityDescriptor, keyPair tls.Certificate) (*SAMLServiceProvider, error) {
+	root, err := url.Parse(strings.TrimSuffix(strings.TrimSpace(rootURL), "/"))
+	if err != nil || root.Host == "" {
+		return nil, errors.New("SAML requiere SAML_ROOT_URL con la URL pública del backend")
+	}
+	key, ok := keyPair.PrivateKey.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("la llave de SAML_KEY_FILE debe ser RSA")
+	}
+	cert, err := x509.ParseCertificate(keyPair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	return &SAMLServiceProvider{
+		sp: saml.ServiceProvider{
+			EntityID:    entityID,
+			Key:         key,
+			Certificate: cert,
+			MetadataURL: *root.JoinPath("auth", "saml", "metadata"),
+			AcsURL:      *root.JoinPath("auth", "saml", "acs"),
+			IDPMetadata: idpMetadata,
+		},
+		EmailAttribute: envOrDefault("SAML_EMAIL_ATTRIBUTE", "mail"),
+		RutAttribute:   envOrDefault("SAML_RUT_ATTRIBUTE", "rut"),
+	}, nil
+}
+
+// Metadata retorna la metadata del SP para registrarlo en el IdP
+func (s *SAMLServiceProvider) Metadata() ([]byte, error) {
+	return xml.MarshalIndent(s.sp.Metadata(), "", "  ")
+}
+
+// AuthURL retorna la URL del IdP con la solicitud de autenticación y el valor de la cookie con el
+// estado, que se debe entregar a ParseResponse
+func (s *SAMLServiceProvider) AuthURL() (string, string, error) {
+	req, err := s.sp.MakeAuthenticationRequest(s.sp.GetSSOBindingLocation(saml.HTTPRedirectBinding), saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return "", "", err
+	}
+	state := ssoState{RequestID: req.ID}
+	if state.State, err = randomString(); err != nil {
+		return "", "", err
+	}
+	redirect, err := req.Redirect(state.State, &s.sp)
+	if err != nil {
+		return "", "", err
+	}
+	cookie, err := state.seal()
+	if err != nil {
+		return "", "", err
+	}
+	return redirect.String(), cookie, nil
+}
+
+// ParseResponse verifica la respuesta del IdP recibida en el ACS, que debe responder a la solicitud
+// guardada en la cookie, y retorna la identidad con los atributos de email y rut
+func (s *SAMLServiceProvider) ParseResponse(r *http.Request, cookie string) (*Identity, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	state, err := openState(cookie, r.Form.Get("RelayState"))
+	if err != nil {
+		return nil, err
+	}
+	assertion, err := s.sp.ParseResponse(r, []string{state.RequestID})
+	if err != nil {
+		var invalid *saml.InvalidResponseError
+		if errors.As(err, &invalid) {
+			return nil, fmt.Errorf("respuesta SAML inválida: %w", invalid.PrivateErr)
+		}
+		return nil, err
+	}
+
+	identity := &Identity{}
+	for _, statement := range assertion.AttributeStatements {
+		for _, attr := range statement.Attributes {
+			if len(attr.Values) == 0 {
+				continue
+			}
+			value := strings.TrimSpace(attr.Values[0].Value)
+			switch {
+			case attr.Name == s.EmailAttribute || attr.FriendlyName == s.EmailAttribute:
+				identity.Email = strings.ToLower(value)
+			case attr.Name == s.RutAttribute || attr.FriendlyName == s.RutAttribute:
+				identity.Rut = value
+			case attr.Name == "uid" || attr.FriendlyName == "uid":
+				identity.Username = value
+			}
+		}
+	}
+	// sin el atributo de email se usa el NameID si es un email
+	if identity.Email == "" && assertion.Subject != nil && assertion.Subject.NameID != nil && strings.Contains(assertion.Subject.NameID.Value, "@") {
+		identity.Email = strings.ToLower(assertion.Subject.NameID.Value)
+	}
+	if identity.Email == "" && identity.Rut == "" {
+		return nil, fmt.Errorf("el IdP no entregó el atributo %s ni %s", s.EmailAttribute, s.RutAttribute)
+	}
+	return identity, nil
+}
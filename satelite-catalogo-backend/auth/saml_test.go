@@ -0,0 +1,225 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crewjam/saml"
+)
+
+const samlACS = "https://api.example/auth/saml/acs"
+
+// certificado autofirmado para el IdP y el SP de prueba
+func selfSigned(t *testing.T, nombre string) (*rsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: nombre},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, cert
+}
+
+// spRegistrado entrega al IdP la metadata del único SP registrado
+type spRegistrado struct{ metadata *saml.EntityDescriptor }
+
+func (s spRegistrado) GetServiceProvider(r *http.Request, serviceProviderID string) (*saml.EntityDescriptor, error) {
+	return s.metadata, nil
+}
+
+func newMockIdP(t *testing.T) *saml.IdentityProvider {
+	t.Helper()
+	key, cert := selfSigned(t, "idp")
+	base, _ := url.Parse("https://idp.example")
+	return &saml.IdentityProvider{
+		Key:         key,
+		Certificate: cert,
+		MetadataURL: *base.JoinPath("metadata"),
+		SSOURL:      *base.JoinPath("sso"),
+	}
+}
+
+// newTestSP registra el SP en el IdP, que en las pruebas responde sin pasar por su login
+func newTestSP(t *testing.T, idp *saml.IdentityProvider) *SAMLServiceProvider {
+	t.Helper()
+	key, cert := selfSigned(t, "sp")
+	sp, err := NewSAMLServiceProvider("https://api.example/", "", idp.Metadata(), tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp.ServiceProviderProvider = spRegistrado{sp.sp.Metadata()}
+	return sp
+}
+
+// anaSAML es la sesión de ana en el IdP, con los atributos de email y rut
+func anaSAML() *saml.Session {
+	return &saml.Session{
+		ID:         "sesion",
+		NameID:     "ana",
+		CreateTime: time.Now(),
+		ExpireTime: time.Now().Add(time.Hour),
+		CustomAttributes: []saml.Attribute{
+			{Name: "mail", Values: []saml.AttributeValue{{Type: "xs:string", Value: "Ana@usach.cl"}}},
+			{Name: "rut", Values: []saml.AttributeValue{{Type: "xs:string", Value: "11111111-1"}}},
+		},
+	}
+}
+
+// responder arma la respuesta firmada del IdP a la solicitud de autenticación de authURL, con el
+// SAMLResponse y RelayState que el navegador envía al ACS
+func responder(t *testing.T, idp *saml.IdentityProvider, authURL string, session *saml.Session) url.Values {
+	t.Helper()
+	req, err := saml.NewIdpAuthnRequest(idp, httptest.NewRequest(http.MethodGet, authURL, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (saml.DefaultAssertionMaker{}).MakeAssertion(req, session); err != nil {
+		t.Fatal(err)
+	}
+	form, err := req.PostBinding()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.URL != samlACS {
+		t.Fatalf("el IdP responde a %s, se esperaba %s", form.URL, samlACS)
+	}
+	return url.Values{"SAMLResponse": {form.SAMLResponse}, "RelayState": {form.RelayState}}
+}
+
+func acs(form url.Values) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, samlACS, strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func iniciarSAML(t *testing.T, sp *SAMLServiceProvider) (string, string) {
+	t.Helper()
+	authURL, cookie, err := sp.AuthURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authURL, cookie
+}
+
+func TestSAMLParseResponse(t *testing.T) {
+	idp := newMockIdP(t)
+	sp := newTestSP(t, idp)
+	authURL, cookie := iniciarSAML(t, sp)
+
+	identity, err := sp.ParseResponse(acs(responder(t, idp, authURL, anaSAML())), cookie)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Email != "ana@usach.cl" || identity.Rut != "11111111-1" {
+		t.Errorf("identidad %+v", identity)
+	}
+}
+
+// sin el atributo de email se usa el NameID si es un email
+func TestSAMLEmailDelNameID(t *testing.T) {
+	idp := newMockIdP(t)
+	sp := newTestSP(t, idp)
+	authURL, cookie := iniciarSAML(t, sp)
+	session := anaSAML()
+	session.NameID = "Ana@usach.cl"
+	session.CustomAttributes = nil
+
+	identity, err := sp.ParseResponse(acs(responder(t, idp, authURL, session)), cookie)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Email != "ana@usach.cl" {
+		t.Errorf("email %q, se esperaba el del NameID", identity.Email)
+	}
+}
+
+func TestSAMLParseResponseRechazada(t *testing.T) {
+	idp := newMockIdP(t)
+	sp := newTestSP(t, idp)
+
+	casos := []struct {
+		nombre string
+		// respuesta arma el formulario que llega al ACS y la cookie del navegador
+		respuesta func(t *testing.T) (url.Values, string)
+		esperado  error
+	}{
+		{"RelayState distinto al de la cookie", func(t *testing.T) (url.Values, string) {
+			authURL, cookie := iniciarSAML(t, sp)
+			form := responder(t, idp, authURL, anaSAML())
+			form.Set("RelayState", "otro-state")
+			return form, cookie
+		}, ErrEstadoSSOInvalido},
+		{"cookie de otro inicio de sesión", func(t *testing.T) (url.Values, string) {
+			authURL, _ := iniciarSAML(t, sp)
+			_, otra := iniciarSAML(t, sp)
+			return responder(t, idp, authURL, anaSAML()), otra
+		}, ErrEstadoSSOInvalido},
+		{"respuesta a otra solicitud", func(t *testing.T) (url.Values, string) {
+			// el RelayState corresponde a la cookie pero la respuesta es para otra solicitud
+			authURL, _ := iniciarSAML(t, sp)
+			otraURL, otra := iniciarSAML(t, sp)
+			form := responder(t, idp, authURL, anaSAML())
+			u, _ := url.Parse(otraURL)
+			form.Set("RelayState", u.Query().Get("RelayState"))
+			return form, otra
+		}, nil},
+		{"firmada por otro IdP", func(t *testing.T) (url.Values, string) {
+			authURL, cookie := iniciarSAML(t, sp)
+			impostor := newMockIdP(t)
+			impostor.ServiceProviderProvider = idp.ServiceProviderProvider
+			return responder(t, impostor, authURL, anaSAML()), cookie
+		}, nil},
+		{"respuesta adulterada", func(t *testing.T) (url.Values, string) {
+			authURL, cookie := iniciarSAML(t, sp)
+			form := responder(t, idp, authURL, anaSAML())
+			// un atributo agregado a la respuesta firmada solo lo detecta la firma
+			xml, _ := base64.StdEncoding.DecodeString(form.Get("SAMLResponse"))
+			adulterada := strings.Replace(string(xml), "<samlp:Response ", `<samlp:Response Consent="urn:oasis:names:tc:SAML:2.0:consent:obtained" `, 1)
+			if adulterada == string(xml) {
+				t.Fatalf("respuesta inesperada: %s", xml)
+			}
+			form.Set("SAMLResponse", base64.StdEncoding.EncodeToString([]byte(adulterada)))
+			return form, cookie
+		}, nil},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			form, cookie := c.respuesta(t)
+			identity, err := sp.ParseResponse(acs(form), cookie)
+			if err == nil || (c.esperado != nil && !errors.Is(err, c.esperado)) {
+				t.Errorf("error %v, se esperaba %v", err, c.esperado)
+			}
+			if identity != nil {
+				t.Errorf("se aceptó la identidad %+v", identity)
+			}
+		})
+	}
+}
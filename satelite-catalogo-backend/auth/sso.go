@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrEstadoSSOInvalido es el estado del inicio de sesión único que no corresponde al navegador que lo
+// inició o que expiró, ej: una respuesta del IdP repetida o falsificada
+var ErrEstadoSSOInvalido = errors.New("estado del inicio de sesión inválido o expirado")
+
+// tiempo que tiene el usuario para iniciar sesión en el IdP
+const ssoStateTTL = 10 * time.Minute
+
+// ssoState es lo que el backend necesita recordar entre la redirección al IdP y su respuesta. Viaja
+// firmado en una cookie, así no se guarda estado en el servidor
+type ssoState struct {
+	State     string `json:"s"`
+	Nonce     string `json:"n,omitempty"`
+	Verifier  string `json:"v,omitempty"`
+	RequestID string `json:"r,omitempty"`
+	Expira    int64  `json:"e"`
+}
+
+func stateKey() []byte {
+	if key, ok := os.LookupEnv("JWT_KEY"); ok {
+		return []byte(key)
+	}
+	return []byte("string_largo_unico_por_proyecto")
+}
+
+func randomString() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hmacEqual compara en tiempo constante
+func hmacEqual(a, b string) bool {
+	return hmac.Equal([]byte(a), []byte(b))
+}
+
+func signState(payload string) string {
+	mac := hmac.New(sha256.New, stateKey())
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// seal retorna el valor de la cookie con el estado firmado
+func (s *ssoState) seal() (string, error) {
+	s.Expira = time.Now().Add(ssoStateTTL).Unix()
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(raw)
+	return payload + "." + signState(payload), nil
+}
+
+// openState verifica la firma y la vigencia de la cookie y que corresponda al state de la respuesta
+func openState(cookie, state string) (*ssoState, error) {
+	payload, firma, ok := strings.Cut(cookie, ".")
+	if !ok || !hmac.Equal([]byte(firma), []byte(signState(payload))) {
+		return nil, ErrEstadoSSOInvalido
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, ErrEstadoSSOInvalido
+	}
+	var s ssoState
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, ErrEstadoSSOInvalido
+	}
+	if time.Now().Unix() > s.Expira || s.State == "" || !hmacEqual(s.State, state) {
+		return nil, ErrEstadoSSOInvalido
+	}
+	return &s, nil
+}
+
+// AutoProvision indica si el inicio de sesión único crea los usuarios que no están registrados
+// (SSO_AUTO_PROVISION=true)
+func AutoProvision() bool {
+	return os.Getenv("SSO_AUTO_PROVISION") == "true"
+}
+
+// FrontendURL es la URL del frontend a la que se redirige con el token después del inicio de sesión
+// único (SSO_FRONTEND_URL), si no se indica la respuesta es el mismo JSON de /auth/login
+func FrontendURL() string {
+	return strings.TrimSpace(os.Getenv("SSO_FRONTEND_URL"))
+}
+
+func envOrDefault(name, def string) string {
+	if v := strings.TrimSpace(os.Getenv(name)); v != "" {
+		return v
+	}
+	return def
+}
+
+// claimString retorna el valor de texto del claim o atributo, o el primero si es una lista
+func claimString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimSpace(t)
+	case []interface{}:
+		if len(t) > 0 {
+			return claimString(t[0])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"catalogo-backend/models"
+	"catalogo-backend/utils"
+)
+
+// USACHProvider verifica las credenciales con la API de autenticación USACH (API_AUTH_URL), que recibe
+// la contraseña en SHA-1 y se autentica con API_AUTH_USER y API_AUTH_PASS
+type USACHProvider struct {
+	URL    string
+	User   string
+	Pass   string
+	Client *http.Client
+}
+
+func NewUSACHProvider() *USACHProvider {
+	return &USACHProvider{
+		URL:    os.Getenv("API_AUTH_URL"),
+		User:   os.Getenv("API_AUTH_USER"),
+		Pass:   os.Getenv("API_AUTH_PASS"),
+		Client: &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (p *USACHProvider) Name() string {
+	return "usach"
+}
+
+func (p *USACHProvider) Authenticate(ctx context.Context, username, password string) (*Identity, error) {
+	username = UsernameFor(username)
+	response, err := p.requestLogin(ctx, username, password)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	//Verificar la respuesta de la API de autenticacion
+	if response.StatusCode != http.StatusOK {
+		var errorMessage map[string]string
+		if err := json.Unmarshal(responseBody, &errorMessage); err != nil {
+			return nil, errors.New("error al obtener el error")
+		}
+		if errorMessage["message"] == "" {
+			return nil, ErrCredencialesInvalidas
+		}
+		return nil, errors.New(errorMessage["message"])
+	}
+
+	var responseLogin models.ResponseLogin
+	if err := json.Unmarshal(responseBody, &responseLogin); err != nil {
+		return nil, ErrCredencialesInvalidas
+	}
+	return &Identity{Username: username, Email: EmailFor(username)}, nil
+}
+
+func (p *USACHProvider) requestLogin(ctx context.Context, username, password string) (*http.Response, error) {
+	body := models.Login{
+		User:     username,
+		Password: utils.HashPassword(password),
+	}
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	//Se agregan los headers a la solicitud
+	request.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(p.User+":"+p.Pass)))
+	request.Header.Set("Content-Type", "application/json")
+
+	return p.Client.Do(request)
+}
@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"catalogo-backend/models"
+	"catalogo-backend/utils"
+)
+
+// apiUSACH simula la API de autenticación, acepta solo al usuario ana con la contraseña secreta
+func apiUSACH(t *testing.T) *USACHProvider {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "api" || pass != "api-pass" {
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"message": "cliente no autorizado"})
+			return
+		}
+		var login models.Login
+		if err := json.NewDecoder(r.Body).Decode(&login); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if login.User != "ana" || login.Password != utils.HashPassword("secreta") {
+			// la API responde el rechazo sin mensaje
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"message": ""})
+			return
+		}
+		json.NewEncoder(w).Encode(models.ResponseLogin{Token: "token-usach"})
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("AUTH_EMAIL_DOMAIN", "usach.cl")
+	return &USACHProvider{URL: server.URL, User: "api", Pass: "api-pass", Client: server.Client()}
+}
+
+func TestUSACHAutentica(t *testing.T) {
+	p := apiUSACH(t)
+	identity, err := p.Authenticate(context.Background(), "ana@usach.cl", "secreta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Username != "ana" || identity.Email != "ana@usach.cl" {
+		t.Errorf("identidad %+v, se esperaba ana", identity)
+	}
+}
+
+// la contraseña gest-password ya no autentica a ningún usuario cuando la API rechaza el login
+func TestUSACHSinContraseñaMaestra(t *testing.T) {
+	p := apiUSACH(t)
+	for _, user := range []string{"ana", "otro"} {
+		identity, err := p.Authenticate(context.Background(), user, "gest-password")
+		if !errors.Is(err, ErrCredencialesInvalidas) {
+			t.Errorf("%s: error %v, se esperaba ErrCredencialesInvalidas", user, err)
+		}
+		if identity != nil {
+			t.Errorf("%s: se autenticó con la contraseña maestra", user)
+		}
+	}
+}
+
+func TestUSACHRechazaContraseñaIncorrecta(t *testing.T) {
+	p := apiUSACH(t)
+	if _, err := p.Authenticate(context.Background(), "ana", "otra"); !errors.Is(err, ErrCredencialesInvalidas) {
+		t.Errorf("error %v, se esperaba ErrCredencialesInvalidas", err)
+	}
+}
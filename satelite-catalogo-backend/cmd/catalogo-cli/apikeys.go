@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"catalogo-backend/auth"
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+)
+
+func createAPIKey(args []string) error {
+	fs := newFlagSet("create-api-key")
+	email := fs.String("email", "", "email del usuario en cuyo nombre actúa la key")
+	nombre := fs.String("nombre", "", "nombre de la integración o cuenta de servicio")
+	roles := fs.String("roles", string(models.USER), "roles de la key separados por coma, deben ser roles del usuario")
+	dias := fs.Int("dias", 90, "días de vigencia de la key")
+	fs.Parse(args)
+
+	if *email == "" || *nombre == "" || *dias < 1 {
+		fs.Usage()
+		return fmt.Errorf("debe indicar -email, -nombre y -dias positivo")
+	}
+	user, err := services.GetUserByEmailService(auth.EmailFor(*email))
+	if err != nil {
+		return err
+	}
+
+	key := &models.APIKey{
+		Nombre:    *nombre,
+		Usuario:   user.ID,
+		ExpiresAt: time.Now().AddDate(0, 0, *dias),
+	}
+	for _, rol := range strings.Split(*roles, ",") {
+		if rol = strings.TrimSpace(rol); rol != "" {
+			key.Roles = append(key.Roles, models.Role(rol))
+		}
+	}
+	created, err := services.CreateAPIKeyService(context.Background(), key)
+	if err != nil {
+		return err
+	}
+	// la key no se vuelve a mostrar, solo queda su hash
+	return printJSON(created)
+}
+
+func revokeAPIKey(args []string) error {
+	fs := newFlagSet("revoke-api-key")
+	id := fs.String("id", "", "ID de la API key")
+	fs.Parse(args)
+
+	if *id == "" {
+		fs.Usage()
+		return fmt.Errorf("debe indicar -id")
+	}
+	key, err := services.RevokeAPIKeyService(context.Background(), *id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("API key %s (%s) revocada\n", key.Nombre, key.Prefijo)
+	return nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+	"catalogo-backend/utils"
+)
+
+func importCentrosCosto(args []string) error {
+	return importCCFile("import-ccs", args, services.ImportCentrosCostoService)
+}
+
+func importMemberships(args []string) error {
+	return importCCFile("import-memberships", args, services.ImportMembershipsService)
+}
+
+func importCCFile(name string, args []string, importar func(context.Context, [][]string, bool) (*models.ImportReport, error)) error {
+	fs := newFlagSet(name)
+	file := fs.String("file", "", "ruta del archivo CSV o XLSX")
+	dryRun := fs.Bool("dry-run", false, "valida el archivo sin escribir en la base de datos")
+	fs.Parse(args)
+
+	if *file == "" {
+		fs.Usage()
+		return fmt.Errorf("debe indicar -file")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rows, err := utils.ReadSpreadsheet(*file, f)
+	if err != nil {
+		return err
+	}
+	report, err := importar(context.Background(), rows, *dryRun)
+	if err != nil {
+		return err
+	}
+	return printJSON(report)
+}
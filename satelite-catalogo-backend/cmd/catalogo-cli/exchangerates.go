@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"catalogo-backend/services"
+	"catalogo-backend/utils"
+)
+
+func importRates(args []string) error {
+	fs := newFlagSet("import-rates")
+	file := fs.String("file", "", "ruta del archivo CSV o XLSX con columnas moneda, fecha, valor y fuente")
+	dryRun := fs.Bool("dry-run", false, "valida el archivo sin escribir en la base de datos")
+	fs.Parse(args)
+
+	if *file == "" {
+		fs.Usage()
+		return fmt.Errorf("debe indicar -file")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rows, err := utils.ReadSpreadsheet(*file, f)
+	if err != nil {
+		return err
+	}
+	report, err := services.ImportExchangeRatesService(rows, *dryRun)
+	if err != nil {
+		return err
+	}
+	return printJSON(report)
+}
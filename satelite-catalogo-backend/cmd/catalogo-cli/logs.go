@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+
+	"catalogo-backend/services"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func backfillLogDiffs(args []string) error {
+	fs := newFlagSet("backfill-log-diffs")
+	dropSnapshots := fs.Bool("drop-snapshots", false, "elimina previous_state y new_state de los logs actualizados")
+	dryRun := fs.Bool("dry-run", false, "cuenta los logs a actualizar sin escribir en la base de datos")
+	fs.Parse(args)
+
+	report, err := services.BackfillLogChangesService(*dropSnapshots, *dryRun)
+	if err != nil {
+		return err
+	}
+	return printJSON(report)
+}
+
+func verifyLogs(args []string) error {
+	fs := newFlagSet("verify-logs")
+	request := fs.String("request", "", "ID de la solicitud, si no se indica se verifican todas")
+	fs.Parse(args)
+
+	var reports []*services.ChainReport
+	if *request != "" {
+		requestID, err := primitive.ObjectIDFromHex(*request)
+		if err != nil {
+			return fmt.Errorf("ID de solicitud inválido: %s", *request)
+		}
+		report, err := services.VerifyLogChainService(requestID)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+	} else {
+		var err error
+		if reports, err = services.VerifyAllLogChainsService(); err != nil {
+			return err
+		}
+	}
+
+	if err := printJSON(reports); err != nil {
+		return err
+	}
+	invalidas := 0
+	for _, r := range reports {
+		if !r.Valida {
+			invalidas++
+		}
+	}
+	if invalidas > 0 {
+		return fmt.Errorf("%d de %d solicitudes tienen la cadena de logs alterada", invalidas, len(reports))
+	}
+	return nil
+}
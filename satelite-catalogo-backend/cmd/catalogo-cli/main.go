@@ -0,0 +1,122 @@
+// Package main implementa la CLI de administración del catálogo, comparte
+// servicios y configuración (.env) con la API.
+//
+// Uso:
+//
+//	go run ./cmd/catalogo-cli <comando> [opciones]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"catalogo-backend/database"
+	"catalogo-backend/utils"
+)
+
+type command struct {
+	description string
+	run         func(args []string) error
+}
+
+// comandos disponibles, para agregar uno nuevo registrarlo aca
+var commands = map[string]command{
+	"import-products": {
+		description: "Importa productos desde una planilla CSV/XLSX de convenio marco",
+		run:         importProducts,
+	},
+	"backfill-log-diffs": {
+		description: "Calcula los cambios campo a campo de los logs guardados con estados completos",
+		run:         backfillLogDiffs,
+	},
+	"import-rates": {
+		description: "Importa tipos de cambio diarios (UF, USD, EUR) desde una planilla CSV/XLSX",
+		run:         importRates,
+	},
+	"verify-logs": {
+		description: "Verifica la cadena de hashes de los logs de una o todas las solicitudes",
+		run:         verifyLogs,
+	},
+	"purge-deleted": {
+		description: "Borra definitivamente los registros eliminados hace más de los días de retención",
+		run:         purgeDeleted,
+	},
+	"set-password": {
+		description: "Guarda la contraseña de un usuario para el proveedor de autenticación local",
+		run:         setPassword,
+	},
+	"create-api-key": {
+		description: "Crea una API key con roles y vigencia para un usuario o cuenta de servicio",
+		run:         createAPIKey,
+	},
+	"revoke-api-key": {
+		description: "Revoca una API key por su ID",
+		run:         revokeAPIKey,
+	},
+	"import-ccs": {
+		description: "Importa centros de costo (numero, nombre, jefe_email, padre) desde una planilla CSV/XLSX",
+		run:         importCentrosCosto,
+	},
+	"import-memberships": {
+		description: "Agrega usuarios a centros de costo (email, cc, rol) desde una planilla CSV/XLSX",
+		run:         importMemberships,
+	},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	//Cargar variables de entorno e inicializar conexión Mongo
+	utils.LoadEnv()
+	database.InitMongo()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := database.Client.Disconnect(ctx); err != nil {
+			log.Println("Error al desconectar MongoDB:", err)
+		}
+	}()
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		log.Println("Error:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Uso: catalogo-cli <comando> [opciones]")
+	fmt.Fprintln(os.Stderr, "\nComandos:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", name, commands[name].description)
+	}
+}
+
+// printJSON imprime el resultado de un comando en stdout
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ExitOnError)
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"catalogo-backend/services"
+)
+
+func purgeDeleted(args []string) error {
+	fs := newFlagSet("purge-deleted")
+	days := fs.Int("days", services.SoftDeleteRetentionDays(), "días de retención, se purgan los eliminados hace más tiempo")
+	dryRun := fs.Bool("dry-run", false, "informa lo que se purgaría sin borrar nada")
+	fs.Parse(args)
+
+	report, err := services.PurgeDeletedService(*days, *dryRun)
+	if err != nil {
+		return err
+	}
+	return printJSON(report)
+}
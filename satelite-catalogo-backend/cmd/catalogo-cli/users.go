@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"catalogo-backend/auth"
+)
+
+func setPassword(args []string) error {
+	fs := newFlagSet("set-password")
+	email := fs.String("email", "", "email del usuario, o solo el nombre de usuario con AUTH_EMAIL_DOMAIN")
+	password := fs.String("password", "", "nueva contraseña, si no se indica se lee de la entrada estándar")
+	fs.Parse(args)
+
+	if *email == "" {
+		fs.Usage()
+		return fmt.Errorf("debe indicar -email")
+	}
+	if *password == "" {
+		linea, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && linea == "" {
+			return fmt.Errorf("debe indicar la contraseña: %w", err)
+		}
+		*password = strings.TrimRight(linea, "\r\n")
+	}
+
+	// la contraseña se guarda para el proveedor local aunque AUTH_PROVIDER sea otro
+	if err := auth.NewLocalProvider().SetPassword(context.Background(), *email, *password); err != nil {
+		return err
+	}
+	fmt.Println("Contraseña actualizada para", auth.EmailFor(*email))
+	return nil
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"catalogo-backend/middleware"
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKey godoc
+// @Summary      Create API key
+// @Description  Issues an API key that acts as the user with the given roles, which must be roles the user has. Without expires_at the key expires in 90 days. The key is returned only in this response, send it in the X-API-Key header. Not available when authenticated with an API key
+// @Tags         api-keys
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      models.APIKey  true  "API key (nombre, usuario, roles, expires_at)"
+// @Success      201      {object} models.CreatedAPIKey
+// @Failure      400      {object} map[string]interface{}
+// @Failure      403      {object} map[string]interface{}
+// @Failure      422      {object} map[string]interface{}
+// @Router       /api-key/ [post]
+func CreateAPIKey(ctx *gin.Context) {
+	var key models.APIKey
+	if err := ctx.ShouldBindJSON(&key); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := services.CreateAPIKeyService(middleware.RequestContext(ctx), &key)
+	if respondValidationError(ctx, err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, created)
+}
+
+// GetAPIKeys godoc
+// @Summary      List API keys
+// @Description  Returns the API keys, newest first, without the keys themselves
+// @Tags         api-keys
+// @Produce      json
+// @Param        usuario   query  string  false  "User the keys act as"
+// @Param        vigentes  query  bool    false  "Only keys not expired nor revoked"
+// @Param        page      query  int     false  "Page number"
+// @Param        pageSize  query  int     false  "Page size"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} map[string]interface{}
+// @Router       /api-key/ [get]
+func GetAPIKeys(ctx *gin.Context) {
+	usuario, err := parseOptionalID(ctx.Query("usuario"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+	page, pageSize := logPagination(ctx)
+
+	keys, total, err := services.GetAPIKeysService(usuario, ctx.Query("vigentes") == "true", page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       keys,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke API key
+// @Description  Revokes the API key, it is rejected from the next request on
+// @Tags         api-keys
+// @Produce      json
+// @Param        id   path      string  true  "API key ID"
+// @Success      200  {object} models.APIKey
+// @Failure      400  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Router       /api-key/{id} [delete]
+func RevokeAPIKey(ctx *gin.Context) {
+	key, err := services.RevokeAPIKeyService(middleware.RequestContext(ctx), ctx.Param("id"))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrAPIKeyNoEncontrada) {
+			status = http.StatusNotFound
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, key)
+}
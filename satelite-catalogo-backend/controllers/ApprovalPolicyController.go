@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateApprovalPolicy godoc
+// @Summary      Create approval policy
+// @Description  Creates the approval chain of a centro de costo, or the global one when cc is omitted. Each step applies when the solicitud amount exceeds monto_minimo
+// @Tags         approval-policies
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      models.ApprovalPolicy  true  "Approval policy"
+// @Success      201      {object} models.ApprovalPolicy
+// @Failure      400      {object} map[string]interface{}
+// @Failure      409      {object} map[string]interface{}
+// @Failure      422      {object} map[string]interface{}
+// @Router       /approval-policy/ [post]
+func CreateApprovalPolicy(ctx *gin.Context) {
+	var policy models.ApprovalPolicy
+	if err := ctx.ShouldBindJSON(&policy); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := services.CreateApprovalPolicyService(&policy)
+	if respondValidationError(ctx, err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(approvalPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, policy)
+}
+
+// UpdateApprovalPolicy godoc
+// @Summary      Update approval policy
+// @Description  Replaces the name and steps of an approval policy. Solicitudes already submitted keep their chain
+// @Tags         approval-policies
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                 true  "Policy ID"
+// @Param        payload  body      models.ApprovalPolicy  true  "Approval policy"
+// @Success      200      {object} models.ApprovalPolicy
+// @Failure      400      {object} map[string]interface{}
+// @Failure      404      {object} map[string]interface{}
+// @Failure      422      {object} map[string]interface{}
+// @Router       /approval-policy/{id} [put]
+func UpdateApprovalPolicy(ctx *gin.Context) {
+	var cambios models.ApprovalPolicy
+	if err := ctx.ShouldBindJSON(&cambios); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := services.UpdateApprovalPolicyService(ctx.Param("id"), cambios)
+	if respondValidationError(ctx, err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(approvalPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
+
+// GetApprovalPolicy godoc
+// @Summary      Get approval policy
+// @Tags         approval-policies
+// @Produce      json
+// @Param        id   path      string  true  "Policy ID"
+// @Success      200  {object} models.ApprovalPolicy
+// @Failure      404  {object} map[string]interface{}
+// @Router       /approval-policy/{id} [get]
+func GetApprovalPolicy(ctx *gin.Context) {
+	policy, err := services.GetApprovalPolicyService(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(approvalPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, policy)
+}
+
+// GetApprovalPolicies godoc
+// @Summary      List approval policies
+// @Tags         approval-policies
+// @Produce      json
+// @Param        page      query  int  false  "Page number"
+// @Param        pageSize  query  int  false  "Page size"
+// @Success      200  {object} map[string]interface{}
+// @Failure      500  {object} map[string]interface{}
+// @Router       /approval-policy/ [get]
+func GetApprovalPolicies(ctx *gin.Context) {
+	page, pageSize := logPagination(ctx)
+
+	policies, total, err := services.GetApprovalPoliciesService(page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       policies,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// DeleteApprovalPolicy godoc
+// @Summary      Delete approval policy
+// @Description  Deletes an approval policy. The centro de costo falls back to the global policy
+// @Tags         approval-policies
+// @Param        id   path      string  true  "Policy ID"
+// @Success      204  {string} string "No Content"
+// @Failure      404  {object} map[string]interface{}
+// @Router       /approval-policy/{id} [delete]
+func DeleteApprovalPolicy(ctx *gin.Context) {
+	if err := services.DeleteApprovalPolicyService(ctx.Param("id")); err != nil {
+		ctx.JSON(approvalPolicyErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func approvalPolicyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPoliticaNoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPoliticaDuplicada):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
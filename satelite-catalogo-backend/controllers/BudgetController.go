@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"catalogo-backend/middleware"
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+	"catalogo-backend/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CreateBudget godoc
+// @Summary      Create budget
+// @Description  Creates the budget of a centro de costo for a period. Committed and consumed amounts start at zero
+// @Tags         budgets
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      models.Budget  true  "Budget"
+// @Success      201      {object} models.Budget
+// @Failure      400      {object} map[string]interface{}
+// @Failure      409      {object} map[string]interface{}
+// @Failure      422      {object} map[string]interface{}
+// @Router       /budget/ [post]
+func CreateBudget(ctx *gin.Context) {
+	var budget models.Budget
+	if err := ctx.ShouldBindJSON(&budget); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := services.CreateBudgetService(&budget)
+	if respondValidationError(ctx, err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(budgetErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, budget)
+}
+
+// UpdateBudget godoc
+// @Summary      Update budget
+// @Description  Updates period, amount and policy of a budget. Centro de costo, currency and committed/consumed amounts are not editable
+// @Tags         budgets
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string         true  "Budget ID"
+// @Param        payload  body      models.Budget  true  "Budget changes"
+// @Success      200      {object} models.Budget
+// @Failure      400      {object} map[string]interface{}
+// @Failure      404      {object} map[string]interface{}
+// @Failure      409      {object} map[string]interface{}
+// @Failure      422      {object} map[string]interface{}
+// @Router       /budget/{id} [put]
+func UpdateBudget(ctx *gin.Context) {
+	var cambios models.Budget
+	if err := ctx.ShouldBindJSON(&cambios); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	budget, err := services.UpdateBudgetService(ctx.Param("id"), cambios)
+	if respondValidationError(ctx, err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(budgetErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, budget)
+}
+
+// GetBudget godoc
+// @Summary      Get budget
+// @Description  Returns a budget with its remaining balance
+// @Tags         budgets
+// @Produce      json
+// @Param        id   path      string  true  "Budget ID"
+// @Success      200  {object} models.BudgetBalance
+// @Failure      403  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Router       /budget/{id} [get]
+func GetBudget(ctx *gin.Context) {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	budget, err := services.GetBudgetService(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(budgetErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	if !principal.CanSeeCC(budget.CC) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "No tiene acceso al centro de costo"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, models.NewBudgetBalance(budget))
+}
+
+// GetBudgets godoc
+// @Summary      List budgets
+// @Description  Returns the budgets of the centros de costo visible to the user, newest period first
+// @Tags         budgets
+// @Produce      json
+// @Param        cc        query  string  false  "Centro de costo ID"
+// @Param        page      query  int     false  "Page number"
+// @Param        pageSize  query  int     false  "Page size"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} map[string]interface{}
+// @Router       /budget/ [get]
+func GetBudgets(ctx *gin.Context) {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+	page, pageSize := logPagination(ctx)
+
+	filter := bson.M{}
+	if !principal.IsAdmin() {
+		filter["cc"] = bson.M{"$in": principal.VisibleCCs()}
+	}
+	if cc := ctx.Query("cc"); cc != "" {
+		ccID, err := primitive.ObjectIDFromHex(cc)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID de centro de costo inválido"})
+			return
+		}
+		if !principal.CanSeeCC(ccID) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "No tiene acceso al centro de costo"})
+			return
+		}
+		filter["cc"] = ccID
+	}
+
+	budgets, total, err := services.GetBudgetsService(filter, page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       budgets,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+// GetBudgetBalance godoc
+// @Summary      Centro de costo budget balance
+// @Description  Returns the budget of the centro de costo in force at the given date (today by default) with its remaining balance
+// @Tags         budgets
+// @Produce      json
+// @Param        cc     path   string  true   "Centro de costo ID"
+// @Param        fecha  query  string  false  "Date (YYYY-MM-DD)"
+// @Success      200  {object} models.BudgetBalance
+// @Failure      400  {object} map[string]interface{}
+// @Failure      403  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Router       /budget/cc/{cc}/balance [get]
+func GetBudgetBalance(ctx *gin.Context) {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+	ccID, err := primitive.ObjectIDFromHex(ctx.Param("cc"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID de centro de costo inválido"})
+		return
+	}
+	if !principal.CanSeeCC(ccID) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "No tiene acceso al centro de costo"})
+		return
+	}
+	fecha := time.Now()
+	if raw := ctx.Query("fecha"); raw != "" {
+		if fecha, err = utils.ParseDate(raw); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	balance, err := services.GetBudgetBalanceService(ccID, fecha)
+	if err != nil {
+		ctx.JSON(budgetErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, balance)
+}
+
+func budgetErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrPresupuestoNoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPresupuestoSuperpuesto):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -1,11 +1,14 @@
 package controllers
 
 import (
+	"catalogo-backend/middleware"
 	"catalogo-backend/models"
 	"catalogo-backend/services"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // CreateCentroCosto godoc
@@ -24,8 +27,14 @@ func CreateCentroCosto(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Error al procesar los datos del centro de costo"})
 		return
 	}
+	// la eliminación solo se marca mediante DELETE /cc/:id
+	cc.SoftDelete = models.SoftDelete{}
 
 	id, err := services.NewCentroCostoService().CreateCC(&cc)
+	if isPadreError(err) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear el centro de costo"})
 		return
@@ -89,8 +98,19 @@ func UpdateCentroCosto(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Error al procesar los datos de actualización"})
 		return
 	}
+	if _, ok := updateData["jefe"]; ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "El jefe solo puede cambiarse mediante /cc/:id/jefe"})
+		return
+	}
+	delete(updateData, "_id")
+	delete(updateData, "deleted_at")
+	delete(updateData, "deleted_by")
 
 	err := services.NewCentroCostoService().UpdateCC(id, updateData)
+	if isPadreError(err) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al actualizar el centro de costo"})
 		return
@@ -101,12 +121,14 @@ func UpdateCentroCosto(ctx *gin.Context) {
 
 // DeleteCentroCosto godoc
 // @Summary      Delete centro de costo
-// @Description  Deletes a Centro de Costo by ID
+// @Description  Soft deletes a Centro de Costo by ID. It can be restored until the purge removes it. Fails with 409 while the Centro de Costo has open solicitudes
 // @Tags         cc
 // @Produce      json
 // @Param        id   path      string  true  "Centro de Costo ID"
 // @Success      200  {object} map[string]string
 // @Failure      400  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Failure      409  {object} map[string]interface{}
 // @Router       /cc/{id} [delete]
 func DeleteCentroCosto(ctx *gin.Context) {
 	id := ctx.Param("id")
@@ -115,7 +137,16 @@ func DeleteCentroCosto(ctx *gin.Context) {
 		return
 	}
 
-	err := services.NewCentroCostoService().DeleteCC(id)
+	err := services.NewCentroCostoService().DeleteCC(middleware.RequestContext(ctx), id)
+	var dependientes *services.CCDependentsError
+	if errors.As(err, &dependientes) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error(), "dependientes": dependientes.Dependientes})
+		return
+	}
+	if errors.Is(err, services.ErrCentroCostoNoEncontrado) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al eliminar el centro de costo"})
 		return
@@ -124,6 +155,25 @@ func DeleteCentroCosto(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "Centro de costo eliminado correctamente"})
 }
 
+// RestoreCentroCosto godoc
+// @Summary      Restore centro de costo
+// @Description  Restores a soft deleted Centro de Costo
+// @Tags         cc
+// @Produce      json
+// @Param        id   path      string  true  "Centro de Costo ID"
+// @Success      200  {object} map[string]string
+// @Failure      404  {object} map[string]interface{}
+// @Router       /cc/{id}/restore [post]
+func RestoreCentroCosto(ctx *gin.Context) {
+	err := services.NewCentroCostoService().RestoreCC(middleware.RequestContext(ctx), ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(restoreErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Centro de costo restaurado correctamente"})
+}
+
 // GetAllCentroCostos godoc
 // @Summary      List centros de costo
 // @Description  Returns all Centros de Costo
@@ -141,3 +191,187 @@ func GetAllCentroCostos(ctx *gin.Context) {
 
 	ctx.JSON(http.StatusOK, ccList)
 }
+
+// GetCentroCostoDependents godoc
+// @Summary      Centro de costo dependents
+// @Description  Counts the open solicitudes and the users of a Centro de Costo
+// @Tags         cc
+// @Produce      json
+// @Param        id   path      string  true  "Centro de Costo ID"
+// @Success      200  {object} services.CCDependents
+// @Failure      404  {object} map[string]interface{}
+// @Router       /cc/{id}/dependents [get]
+func GetCentroCostoDependents(ctx *gin.Context) {
+	dependientes, err := services.NewCentroCostoService().GetDependents(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(ccErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, dependientes)
+}
+
+// ReassignCentroCostoRequest es el cuerpo de POST /cc/:id/reassign
+type ReassignCentroCostoRequest struct {
+	Destino string `json:"destino" binding:"required"`
+}
+
+// ReassignCentroCosto godoc
+// @Summary      Reassign centro de costo
+// @Description  Moves the users and the open solicitudes of a Centro de Costo to another one in a single transaction and reports what was moved. Pending jefe steps of the source jefe are reassigned to the destination jefe and the budget committed by approved solicitudes moves to the destination's active budget
+// @Tags         cc
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string                                  true  "Centro de Costo ID"
+// @Param        payload  body  controllers.ReassignCentroCostoRequest  true  "Destination Centro de Costo"
+// @Success      200  {object} services.CCReassignReport
+// @Failure      400  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Failure      422  {object} map[string]interface{}
+// @Router       /cc/{id}/reassign [post]
+func ReassignCentroCosto(ctx *gin.Context) {
+	var req ReassignCentroCostoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Debe indicar el centro de costo de destino"})
+		return
+	}
+
+	report, err := services.NewCentroCostoService().ReassignCC(middleware.RequestContext(ctx), ctx.Param("id"), req.Destino)
+	if err != nil {
+		ctx.JSON(ccErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// ReassignJefeRequest es el cuerpo de PUT /cc/:id/jefe
+type ReassignJefeRequest struct {
+	Jefe string `json:"jefe" binding:"required"`
+}
+
+// ReassignJefeCentroCosto godoc
+// @Summary      Change centro de costo jefe
+// @Description  Sets a new jefe for the Centro de Costo, moves the Centro de Costo from the previous jefe's cc list to the new one's and hands the pending jefe approval steps over to the new jefe, logging it in each solicitud
+// @Tags         cc
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string                           true  "Centro de Costo ID"
+// @Param        payload  body  controllers.ReassignJefeRequest  true  "New jefe user ID"
+// @Success      200  {object} services.JefeHandoverReport
+// @Failure      400  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Failure      409  {object} map[string]interface{}
+// @Router       /cc/{id}/jefe [put]
+func ReassignJefeCentroCosto(ctx *gin.Context) {
+	var req ReassignJefeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Debe indicar el nuevo jefe"})
+		return
+	}
+
+	report, err := services.NewCentroCostoService().ReassignJefe(middleware.RequestContext(ctx), ctx.Param("id"), req.Jefe)
+	if err != nil {
+		ctx.JSON(ccErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+func ccErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrCentroCostoNoEncontrado), errors.Is(err, services.ErrUsuarioNoEncontrado),
+		errors.Is(err, services.ErrMembresiaNoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrMismoCentroCosto), errors.Is(err, services.ErrMismoJefe), errors.Is(err, primitive.ErrInvalidHex),
+		errors.Is(err, services.ErrRolMembresiaInvalido):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrJefeModificado), errors.Is(err, services.ErrMembresiaJefe):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrPresupuestoExcedido):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+func isPadreError(err error) bool {
+	return errors.Is(err, services.ErrPadreNoEncontrado) || errors.Is(err, services.ErrPadreCiclico)
+}
+
+// canSeeSubtree indica si el usuario puede ver el centro de costo con todos sus descendientes
+func canSeeSubtree(ctx *gin.Context, id string) bool {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return false
+	}
+	ccID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID de centro de costo inválido"})
+		return false
+	}
+	if !principal.IsAdmin() && !principal.LeadsCC(ccID) && !principal.SupervisesCC(ccID) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Solo el jefe del centro de costo o de uno superior puede ver sus descendientes"})
+		return false
+	}
+	return true
+}
+
+// GetCentroCostoSubtree godoc
+// @Summary      Centro de costo subtree
+// @Description  Returns the Centro de Costo with all its descendants. Available to admins and to the jefes of the Centro de Costo or of one of its ancestors
+// @Tags         cc
+// @Produce      json
+// @Param        id   path      string  true  "Centro de Costo ID"
+// @Success      200  {object} models.CCNode
+// @Failure      403  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Router       /cc/{id}/subtree [get]
+func GetCentroCostoSubtree(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if !canSeeSubtree(ctx, id) {
+		return
+	}
+
+	node, err := services.NewCentroCostoService().GetSubtree(id)
+	if err != nil {
+		ctx.JSON(ccErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, node)
+}
+
+// GetCentroCostoSpend godoc
+// @Summary      Centro de costo spend roll-up
+// @Description  Returns the spend of the solicitudes of the Centro de Costo and of each descendant, in the reporting currency, with each node's own spend and the total rolled up from its descendants
+// @Tags         cc
+// @Produce      json
+// @Param        id     path   string  true   "Centro de Costo ID"
+// @Param        desde  query  string  false  "From fecha_solicitud (2006-01-02 or RFC3339)"
+// @Param        hasta  query  string  false  "To fecha_solicitud, inclusive (2006-01-02 or RFC3339)"
+// @Success      200  {object} models.CCSpendNode
+// @Failure      400  {object} map[string]interface{}
+// @Failure      403  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Router       /cc/{id}/spend [get]
+func GetCentroCostoSpend(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if !canSeeSubtree(ctx, id) {
+		return
+	}
+	desde, hasta, err := parseDateRange(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	node, err := services.NewCentroCostoService().GetSpendTree(id, desde, hasta)
+	if err != nil {
+		ctx.JSON(ccErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, node)
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"catalogo-backend/middleware"
+	"catalogo-backend/services"
+	"catalogo-backend/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportCentrosCosto godoc
+// @Summary      Import centros de costo
+// @Description  Imports a CSV/XLSX with numero, nombre, jefe_email and padre (parent numero), upserting by numero. Jefes are resolved by email and a jefe change hands over the pending approvals
+// @Tags         cc
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        archivo  formData  file  true   "CSV or XLSX file"
+// @Param        dryRun   query     bool  false  "Validate without writing"
+// @Success      200  {object} models.ImportReport
+// @Failure      400  {object} map[string]interface{}
+// @Router       /cc/import [post]
+func ImportCentrosCosto(ctx *gin.Context) {
+	rows, dryRun, ok := readImportUpload(ctx)
+	if !ok {
+		return
+	}
+
+	report, err := services.ImportCentrosCostoService(middleware.RequestContext(ctx), rows, dryRun)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// ImportCentroCostoMemberships godoc
+// @Summary      Import centro de costo memberships
+// @Description  Imports a CSV/XLSX with email, cc (centro de costo numero) and rol (solicitante or observador), adding each user to the centro de costo or changing the role
+// @Tags         cc
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        archivo  formData  file  true   "CSV or XLSX file"
+// @Param        dryRun   query     bool  false  "Validate without writing"
+// @Success      200  {object} models.ImportReport
+// @Failure      400  {object} map[string]interface{}
+// @Router       /cc/import-memberships [post]
+func ImportCentroCostoMemberships(ctx *gin.Context) {
+	rows, dryRun, ok := readImportUpload(ctx)
+	if !ok {
+		return
+	}
+
+	report, err := services.ImportMembershipsService(middleware.RequestContext(ctx), rows, dryRun)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// readImportUpload lee la planilla del campo 'archivo' y el parámetro dryRun, responde 400 si no puede
+func readImportUpload(ctx *gin.Context) ([][]string, bool, bool) {
+	fileHeader, err := ctx.FormFile("archivo")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Debe adjuntar el archivo en el campo 'archivo'"})
+		return nil, false, false
+	}
+	dryRun, _ := strconv.ParseBool(ctx.DefaultQuery("dryRun", "false"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+		return nil, false, false
+	}
+	defer file.Close()
+
+	rows, err := utils.ReadSpreadsheet(fileHeader.Filename, file)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false, false
+	}
+	return rows, dryRun, true
+}
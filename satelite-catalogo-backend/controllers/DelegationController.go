@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"catalogo-backend/middleware"
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateDelegation godoc
+// @Summary      Create delegation
+// @Description  Delegates the approval authority of the user to another user for a date range, optionally limited to some centros de costo. Only admins can set a different delegante
+// @Tags         delegations
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      models.Delegation  true  "Delegation"
+// @Success      201      {object} models.Delegation
+// @Failure      400      {object} map[string]interface{}
+// @Failure      403      {object} map[string]interface{}
+// @Failure      422      {object} map[string]interface{}
+// @Router       /delegation/ [post]
+func CreateDelegation(ctx *gin.Context) {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var delegation models.Delegation
+	if err := ctx.ShouldBindJSON(&delegation); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := services.CreateDelegationService(&delegation, principal)
+	if respondValidationError(ctx, err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(delegationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, delegation)
+}
+
+// GetDelegations godoc
+// @Summary      List delegations
+// @Description  Returns the delegations given or received by the user, all of them for admins
+// @Tags         delegations
+// @Produce      json
+// @Param        vigentes  query  bool  false  "Only delegations active now"
+// @Param        page      query  int   false  "Page number"
+// @Param        pageSize  query  int   false  "Page size"
+// @Success      200  {object} map[string]interface{}
+// @Failure      500  {object} map[string]interface{}
+// @Router       /delegation/ [get]
+func GetDelegations(ctx *gin.Context) {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+	page, pageSize := logPagination(ctx)
+	vigentes := ctx.Query("vigentes") == "true"
+
+	delegations, total, err := services.GetDelegationsService(principal, vigentes, page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       delegations,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// RevokeDelegation godoc
+// @Summary      Revoke delegation
+// @Description  Ends a delegation before its end date. Only the delegante or an admin can revoke it
+// @Tags         delegations
+// @Produce      json
+// @Param        id   path      string  true  "Delegation ID"
+// @Success      200  {object} models.Delegation
+// @Failure      400  {object} map[string]interface{}
+// @Failure      403  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Router       /delegation/{id} [delete]
+func RevokeDelegation(ctx *gin.Context) {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	delegation, err := services.RevokeDelegationService(ctx.Param("id"), principal)
+	if err != nil {
+		ctx.JSON(delegationErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, delegation)
+}
+
+func delegationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrDelegacionNoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrDelegacionNoPermitida):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+	"catalogo-backend/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SaveExchangeRates godoc
+// @Summary      Save exchange rates
+// @Description  Creates or replaces daily exchange rates (CLP per unit), one per currency and date
+// @Tags         exchange-rates
+// @Accept       json
+// @Produce      json
+// @Param        payload  body      []models.ExchangeRate  true  "Exchange rates"
+// @Param        dryRun   query     bool                   false "Validate without writing"
+// @Success      200      {object} models.ImportReport
+// @Failure      400      {object} map[string]interface{}
+// @Router       /exchange-rate/ [post]
+func SaveExchangeRates(ctx *gin.Context) {
+	var rates []models.ExchangeRate
+	if err := ctx.ShouldBindJSON(&rates); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dryRun, _ := strconv.ParseBool(ctx.DefaultQuery("dryRun", "false"))
+
+	report, err := services.SaveExchangeRatesService(rates, dryRun)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// ImportExchangeRates godoc
+// @Summary      Import exchange rates
+// @Description  Imports a CSV/XLSX file with the columns moneda, fecha, valor and fuente (optional)
+// @Tags         exchange-rates
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        archivo  formData  file  true   "CSV or XLSX file"
+// @Param        dryRun   query     bool  false  "Validate without writing"
+// @Success      200  {object} models.ImportReport
+// @Failure      400  {object} map[string]interface{}
+// @Router       /exchange-rate/import [post]
+func ImportExchangeRates(ctx *gin.Context) {
+	fileHeader, err := ctx.FormFile("archivo")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Debe adjuntar el archivo en el campo 'archivo'"})
+		return
+	}
+	dryRun, _ := strconv.ParseBool(ctx.DefaultQuery("dryRun", "false"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+		return
+	}
+	defer file.Close()
+
+	rows, err := utils.ReadSpreadsheet(fileHeader.Filename, file)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := services.ImportExchangeRatesService(rows, dryRun)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// GetExchangeRates godoc
+// @Summary      List exchange rates
+// @Description  Returns the loaded exchange rates, newest first
+// @Tags         exchange-rates
+// @Produce      json
+// @Param        moneda    query  string  false  "Currency (UF, USD, EUR)"
+// @Param        page      query  int     false  "Page number"
+// @Param        pageSize  query  int     false  "Page size"
+// @Success      200  {object} map[string]interface{}
+// @Failure      500  {object} map[string]interface{}
+// @Router       /exchange-rate/ [get]
+func GetExchangeRates(ctx *gin.Context) {
+	page, pageSize := logPagination(ctx)
+
+	rates, total, err := services.GetExchangeRatesService(ctx.Query("moneda"), page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       rates,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
@@ -2,10 +2,11 @@ package controllers
 
 import (
 	"net/http"
-	"os"
 	"path/filepath"
 	"strings"
 
+	"catalogo-backend/utils"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -21,15 +22,17 @@ import (
 func ServeArchivo(ctx *gin.Context) {
 	fileParam := ctx.Param("filepath")
 	cleanPath := filepath.Clean(fileParam)
-	uploadRoot := os.Getenv("UPLOAD_DIR")
-	if uploadRoot == "" {
-		uploadRoot = "./uploads"
-	}
+	uploadRoot := utils.UploadRoot()
 	fullPath := filepath.Join(uploadRoot, cleanPath)
 
 	if !strings.HasPrefix(fullPath, filepath.Clean(uploadRoot)) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid path"})
 		return
 	}
+	// los archivos en staging aún no pertenecen a una solicitud confirmada
+	if strings.HasPrefix(filepath.ToSlash(cleanPath), "/"+utils.StagingDirName) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		return
+	}
 	ctx.File(fullPath)
 }
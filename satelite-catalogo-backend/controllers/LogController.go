@@ -0,0 +1,211 @@
+package controllers
+
+import (
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+	"catalogo-backend/utils"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetSolicitudHistory godoc
+// @Summary      Solicitud history
+// @Description  Returns the timeline of a solicitud, oldest event first. State snapshots are only included with snapshots=true
+// @Tags         solicitudes
+// @Produce      json
+// @Param        id         path   string  true   "Solicitud ID"
+// @Param        eventType  query  string  false  "Event types, comma separated"  Enums(create, update, transition, line_decision, delete, restore, handover)
+// @Param        userId     query  string  false  "User who performed the event"
+// @Param        desde      query  string  false  "From date (2006-01-02 or RFC3339)"
+// @Param        hasta      query  string  false  "To date, inclusive (2006-01-02 or RFC3339)"
+// @Param        snapshots  query  bool    false  "Include previous and new state"
+// @Param        page       query  int     false  "Page number"
+// @Param        pageSize   query  int     false  "Page size"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} map[string]interface{}
+// @Failure      403  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Router       /solicitud/{id}/history [get]
+func GetSolicitudHistory(ctx *gin.Context) {
+	solicitud, _, ok := getSolicitudConAcceso(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
+	query, err := parseLogQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	page, pageSize := logPagination(ctx)
+
+	logs, total, err := services.GetSolicitudHistoryService(solicitud.ID, query, page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       logs,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// SearchAuditLogs godoc
+// @Summary      Search audit logs
+// @Description  Searches the logs of every solicitud by solicitud, user, centro de costo, event type and date range, newest event first
+// @Tags         audit
+// @Produce      json
+// @Param        requestId      query  string  false  "Solicitud ID"
+// @Param        userId         query  string  false  "User who performed the event"
+// @Param        cc             query  string  false  "Centro de costo ID"
+// @Param        correlationId  query  string  false  "Correlation ID of the call (X-Request-ID)"
+// @Param        eventType      query  string  false  "Event types, comma separated"  Enums(create, update, transition, line_decision, delete, restore, handover)
+// @Param        desde          query  string  false  "From date (2006-01-02 or RFC3339)"
+// @Param        hasta          query  string  false  "To date, inclusive (2006-01-02 or RFC3339)"
+// @Param        snapshots      query  bool    false  "Include previous and new state"
+// @Param        page           query  int     false  "Page number"
+// @Param        pageSize       query  int     false  "Page size"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} map[string]interface{}
+// @Router       /audit/logs [get]
+func SearchAuditLogs(ctx *gin.Context) {
+	query, err := parseLogQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if query.RequestID, err = parseOptionalID(ctx.Query("requestId")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID de solicitud inválido"})
+		return
+	}
+	if query.CC, err = parseOptionalID(ctx.Query("cc")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID de centro de costo inválido"})
+		return
+	}
+	query.CorrelationID = ctx.Query("correlationId")
+	page, pageSize := logPagination(ctx)
+
+	logs, total, err := services.SearchLogsService(query, page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       logs,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// parseLogQuery lee los filtros comunes de la línea de tiempo y de la auditoría
+func parseLogQuery(ctx *gin.Context) (services.LogQuery, error) {
+	var query services.LogQuery
+	var err error
+
+	if query.UserID, err = parseOptionalID(ctx.Query("userId")); err != nil {
+		return query, fmt.Errorf("ID de usuario inválido")
+	}
+	for _, raw := range strings.Split(ctx.Query("eventType"), ",") {
+		eventType := models.LogEventType(strings.TrimSpace(raw))
+		if eventType == "" {
+			continue
+		}
+		if !models.IsLogEventType(eventType) {
+			return query, fmt.Errorf("tipo de evento inválido: %s", eventType)
+		}
+		query.EventTypes = append(query.EventTypes, eventType)
+	}
+	if query.Desde, query.Hasta, err = parseDateRange(ctx); err != nil {
+		return query, err
+	}
+	query.Snapshots = ctx.Query("snapshots") == "true"
+	return query, nil
+}
+
+// parseDateRange lee desde y hasta; hasta es exclusivo y una fecha sin hora incluye todo el día
+func parseDateRange(ctx *gin.Context) (desde, hasta time.Time, err error) {
+	if raw := ctx.Query("desde"); raw != "" {
+		if desde, _, err = parseLogDate(raw); err != nil {
+			return desde, hasta, err
+		}
+	}
+	if raw := ctx.Query("hasta"); raw != "" {
+		var soloFecha bool
+		if hasta, soloFecha, err = parseLogDate(raw); err != nil {
+			return desde, hasta, err
+		}
+		if soloFecha {
+			hasta = hasta.AddDate(0, 0, 1)
+		}
+	}
+	return desde, hasta, nil
+}
+
+// parseLogDate acepta una fecha RFC3339 o solo la fecha, en cuyo caso retorna soloFecha
+func parseLogDate(raw string) (fecha time.Time, soloFecha bool, err error) {
+	if fecha, err = time.Parse(time.RFC3339, raw); err == nil {
+		return fecha, false, nil
+	}
+	fecha, err = utils.ParseDate(raw)
+	return fecha, true, err
+}
+
+func parseOptionalID(raw string) (primitive.ObjectID, error) {
+	if raw == "" {
+		return primitive.NilObjectID, nil
+	}
+	return primitive.ObjectIDFromHex(raw)
+}
+
+// logPagination lee page y pageSize de la query, con pageSize entre 1 y 100 como en los demás listados
+func logPagination(ctx *gin.Context) (int, int) {
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	pageSize, err := strconv.Atoi(ctx.DefaultQuery("pageSize", "50"))
+	if err != nil || pageSize < 1 {
+		pageSize = 50
+	}
+	if pageSize > 100 {
+		pageSize = 100
+	}
+	return page, pageSize
+}
+
+// VerifyLogChain godoc
+// @Summary      Verify solicitud log chain
+// @Description  Recomputes the hash chain of the logs of a solicitud and reports edited, missing or unchained entries
+// @Tags         audit
+// @Produce      json
+// @Param        id   path      string  true  "Solicitud ID"
+// @Success      200  {object} services.ChainReport
+// @Failure      400  {object} map[string]interface{}
+// @Router       /audit/solicitud/{id}/verify [get]
+func VerifyLogChain(ctx *gin.Context) {
+	requestID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID de solicitud inválido"})
+		return
+	}
+
+	report, err := services.VerifyLogChainService(requestID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"catalogo-backend/middleware"
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SetMembershipRequest es el rol del usuario en el centro de costo
+type SetMembershipRequest struct {
+	Rol models.MembershipRole `json:"rol" binding:"required" enums:"solicitante,jefe,observador"`
+}
+
+// GetUserMemberships godoc
+// @Summary      List user centros de costo
+// @Description  Returns the Centros de Costo of the user with the role in each one
+// @Tags         users
+// @Produce      json
+// @Param        id   path  string  true  "User ID"
+// @Success      200  {array}  models.Membership
+// @Failure      404  {object} map[string]interface{}
+// @Router       /user/{id}/cc [get]
+func GetUserMemberships(ctx *gin.Context) {
+	memberships, err := services.GetUserMembershipsService(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(ccErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, memberships)
+}
+
+// SetUserMembership godoc
+// @Summary      Add user to centro de costo
+// @Description  Adds the Centro de Costo to the user or changes the user's role in it. The jefe role changes the jefe of the Centro de Costo like PUT /cc/{id}/jefe. The change is recorded in the membership audit
+// @Tags         users
+// @Accept       json
+// @Produce      json
+// @Param        id       path  string                            true  "User ID"
+// @Param        cc       path  string                            true  "Centro de Costo ID"
+// @Param        payload  body  controllers.SetMembershipRequest  true  "Role"
+// @Success      200  {object} models.Membership
+// @Failure      400  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Failure      409  {object} map[string]interface{}
+// @Router       /user/{id}/cc/{cc} [put]
+func SetUserMembership(ctx *gin.Context) {
+	var req SetMembershipRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Debe indicar el rol"})
+		return
+	}
+
+	membership, err := services.SetMembershipService(middleware.RequestContext(ctx), ctx.Param("id"), ctx.Param("cc"), req.Rol)
+	if err != nil {
+		ctx.JSON(ccErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, membership)
+}
+
+// RemoveUserMembership godoc
+// @Summary      Remove user from centro de costo
+// @Description  Removes the Centro de Costo from the user, recording it in the membership audit. The jefe cannot be removed until the Centro de Costo has another jefe
+// @Tags         users
+// @Produce      json
+// @Param        id   path  string  true  "User ID"
+// @Param        cc   path  string  true  "Centro de Costo ID"
+// @Success      200  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Failure      409  {object} map[string]interface{}
+// @Router       /user/{id}/cc/{cc} [delete]
+func RemoveUserMembership(ctx *gin.Context) {
+	if err := services.RemoveMembershipService(middleware.RequestContext(ctx), ctx.Param("id"), ctx.Param("cc")); err != nil {
+		ctx.JSON(ccErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "Membresía eliminada"})
+}
+
+// GetCentroCostoMembers godoc
+// @Summary      List centro de costo members
+// @Description  Returns the users of the Centro de Costo with their role, ordered by username. Available to admins and to the jefes of the Centro de Costo or of one of its ancestors
+// @Tags         cc
+// @Produce      json
+// @Param        id        path   string  true   "Centro de Costo ID"
+// @Param        page      query  int     false  "Page number"
+// @Param        pageSize  query  int     false  "Page size"
+// @Success      200  {object} map[string]interface{}
+// @Failure      403  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Router       /cc/{id}/members [get]
+func GetCentroCostoMembers(ctx *gin.Context) {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+	ccID, err := primitive.ObjectIDFromHex(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID de centro de costo inválido"})
+		return
+	}
+	if !principal.IsAdmin() && !principal.LeadsCC(ccID) && !principal.SupervisesCC(ccID) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Solo el jefe del centro de costo o de uno superior puede ver sus miembros"})
+		return
+	}
+	page, pageSize := logPagination(ctx)
+
+	members, total, err := services.GetCCMembersService(ccID.Hex(), page, pageSize)
+	if err != nil {
+		ctx.JSON(ccErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       members,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// SearchMembershipLogs godoc
+// @Summary      Search membership audit
+// @Description  Searches the changes of user memberships in centros de costo by user, centro de costo and date range, newest first
+// @Tags         audit
+// @Produce      json
+// @Param        userId    query  string  false  "User whose membership changed"
+// @Param        cc        query  string  false  "Centro de costo ID"
+// @Param        desde     query  string  false  "From date (2006-01-02 or RFC3339)"
+// @Param        hasta     query  string  false  "To date, inclusive (2006-01-02 or RFC3339)"
+// @Param        page      query  int     false  "Page number"
+// @Param        pageSize  query  int     false  "Page size"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} map[string]interface{}
+// @Router       /audit/memberships [get]
+func SearchMembershipLogs(ctx *gin.Context) {
+	var query services.MembershipLogQuery
+	var err error
+	if query.Usuario, err = parseOptionalID(ctx.Query("userId")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID de usuario inválido"})
+		return
+	}
+	if query.CC, err = parseOptionalID(ctx.Query("cc")); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "ID de centro de costo inválido"})
+		return
+	}
+	if query.Desde, query.Hasta, err = parseDateRange(ctx); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Fecha inválida"})
+		return
+	}
+	page, pageSize := logPagination(ctx)
+
+	logs, total, err := services.SearchMembershipLogsService(query, page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       logs,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
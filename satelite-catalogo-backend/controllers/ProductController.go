@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"catalogo-backend/middleware"
 	"catalogo-backend/models"
 	"catalogo-backend/services"
+	"catalogo-backend/utils"
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -26,9 +29,11 @@ func CreateProduct(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// la eliminación solo se marca mediante DELETE /product/:id
+	product.SoftDelete = models.SoftDelete{}
 
 	if err := services.CreateProduct(product); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(productErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -93,9 +98,10 @@ func UpdateProduct(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	product.SoftDelete = models.SoftDelete{}
 
 	if err := services.UpdateProduct(id, product); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(productErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 	ctx.JSON(http.StatusOK, product)
@@ -103,24 +109,43 @@ func UpdateProduct(ctx *gin.Context) {
 
 // DeleteProduct godoc
 // @Summary      Delete product
-// @Description  Deletes a product by ID
+// @Description  Soft deletes a product by ID. It can be restored until the purge removes it
 // @Tags         products
 // @Produce      json
 // @Param        id   path      string  true  "Product ID"
 // @Success      204  {string} string "No Content"
+// @Failure      404  {object} map[string]interface{}
 // @Failure      500  {object} map[string]interface{}
 // @Router       /product/{id} [delete]
 
 func DeleteProduct(ctx *gin.Context) {
 	id := ctx.Param("id")
-	if err := services.DeleteProduct(id); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := services.DeleteProduct(middleware.RequestContext(ctx), id); err != nil {
+		ctx.JSON(productErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	ctx.Status(http.StatusNoContent)
 }
 
+// RestoreProduct godoc
+// @Summary      Restore product
+// @Description  Restores a soft deleted product
+// @Tags         products
+// @Produce      json
+// @Param        id   path      string  true  "Product ID"
+// @Success      200  {object} map[string]string
+// @Failure      404  {object} map[string]interface{}
+// @Router       /product/{id}/restore [post]
+func RestoreProduct(ctx *gin.Context) {
+	if err := services.RestoreProduct(ctx.Param("id")); err != nil {
+		ctx.JSON(restoreErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Producto restaurado correctamente"})
+}
+
 // GetAllPaginated godoc
 // Obtener productos paginados
 // Obtiene una lista paginada de productos con filtros opcionales
@@ -257,3 +282,84 @@ func GetProductsFiltradasPaginated(ctx *gin.Context) {
 		"totalPages": int((total + int64(pageSize) - 1) / int64(pageSize)),
 	})
 }
+
+// ImportProducts godoc
+// @Summary      Import products
+// @Description  Imports a convenio marco CSV/XLSX export, upserting by id_convenio, id_product and region
+// @Tags         products
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        archivo  formData  file  true   "CSV or XLSX file"
+// @Param        dryRun   query     bool  false  "Validate without writing"
+// @Success      200  {object} models.ImportReport
+// @Failure      400  {object} map[string]interface{}
+// @Router       /product/import [post]
+func ImportProducts(ctx *gin.Context) {
+	fileHeader, err := ctx.FormFile("archivo")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Debe adjuntar el archivo en el campo 'archivo'"})
+		return
+	}
+	dryRun, _ := strconv.ParseBool(ctx.DefaultQuery("dryRun", "false"))
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No se pudo leer el archivo"})
+		return
+	}
+	defer file.Close()
+
+	rows, err := utils.ReadSpreadsheet(fileHeader.Filename, file)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := services.ImportProductsService(rows, dryRun)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// GetProductPriceHistory godoc
+// @Summary      Product price history
+// @Description  Returns the chronological price timeline of a product
+// @Tags         products
+// @Produce      json
+// @Param        id        path   string  true   "Product ID"
+// @Param        page      query  int     false  "Page number"
+// @Param        pageSize  query  int     false  "Page size"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} map[string]interface{}
+// @Router       /product/{id}/price-history [get]
+func GetProductPriceHistory(ctx *gin.Context) {
+	id := ctx.Param("id")
+	page, pageSize := logPagination(ctx)
+
+	history, total, err := services.GetProductPriceHistory(id, page, pageSize)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       history,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": int((total + int64(pageSize) - 1) / int64(pageSize)),
+	})
+}
+
+func productErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrMonedaNoSoportada):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrProductoNoEncontrado):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
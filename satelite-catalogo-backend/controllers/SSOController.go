@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"catalogo-backend/auth"
+	"catalogo-backend/middleware"
+	"catalogo-backend/services"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cookies con el estado firmado del inicio de sesión único, duran lo que tarda el login en el IdP
+const (
+	oidcStateCookie = "catalogo_oidc_state"
+	samlStateCookie = "catalogo_saml_state"
+	ssoCookieMaxAge = 10 * 60
+)
+
+func setSSOCookie(ctx *gin.Context, name, value string, maxAge int) {
+	secure := middleware.IsHTTPS(ctx)
+	// la respuesta SAML llega en un POST desde el dominio del IdP, el navegador solo envía la cookie
+	// con SameSite=None, que requiere https
+	sameSite := http.SameSiteLaxMode
+	if name == samlStateCookie {
+		sameSite = http.SameSiteDefaultMode
+		if secure {
+			sameSite = http.SameSiteNoneMode
+		}
+	}
+	ctx.SetSameSite(sameSite)
+	ctx.SetCookie(name, value, maxAge, "/auth", "", secure, true)
+}
+
+func ssoErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, auth.ErrSSONoConfigurado):
+		return http.StatusNotFound
+	case errors.Is(err, auth.ErrEstadoSSOInvalido), errors.Is(err, auth.ErrCredencialesInvalidas):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrUsuarioSSONoRegistrado):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// completeSSOLogin resuelve el usuario de la identidad del IdP y responde con el token jwt
+func completeSSOLogin(ctx *gin.Context, identity *auth.Identity) {
+	user, err := services.ResolveSSOUserService(identity)
+	if err != nil {
+		status := ssoErrorStatus(err)
+		if status == http.StatusBadGateway {
+			status = http.StatusInternalServerError
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	middleware.SSOLoginResponse(ctx, user)
+}
+
+// OIDCLogin godoc
+// @Summary      Start OpenID Connect login
+// @Description  Redirects the browser to the institutional OpenID Connect IdP. The IdP returns to /auth/oidc/callback
+// @Tags         auth
+// @Success      302
+// @Failure      404  {object} map[string]interface{}
+// @Failure      502  {object} map[string]interface{}
+// @Router       /auth/oidc/login [get]
+func OIDCLogin(ctx *gin.Context) {
+	rp, err := auth.OIDC(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(ssoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	url, cookie, err := rp.AuthURL()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	setSSOCookie(ctx, oidcStateCookie, cookie, ssoCookieMaxAge)
+	ctx.Redirect(http.StatusFound, url)
+}
+
+// OIDCCallback godoc
+// @Summary      Complete OpenID Connect login
+// @Description  Redirect URI of the OpenID Connect IdP. Verifies the ID token, finds the user by the email claim or else by the RUT claim (creating it when SSO_AUTO_PROVISION is enabled) and returns the same token as /auth/login, or redirects to SSO_FRONTEND_URL with the token in the URL fragment
+// @Tags         auth
+// @Produce      json
+// @Param        code   query  string  true  "Authorization code"
+// @Param        state  query  string  true  "State"
+// @Success      200  {object} map[string]interface{}
+// @Failure      401  {object} map[string]interface{}
+// @Failure      403  {object} map[string]interface{}
+// @Router       /auth/oidc/callback [get]
+func OIDCCallback(ctx *gin.Context) {
+	cookie, _ := ctx.Cookie(oidcStateCookie)
+	setSSOCookie(ctx, oidcStateCookie, "", -1)
+	if idpError := ctx.Query("error"); idpError != "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "El IdP rechazó el inicio de sesión: " + idpError})
+		return
+	}
+
+	rp, err := auth.OIDC(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(ssoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	identity, err := rp.Callback(ctx.Request.Context(), ctx.Query("code"), ctx.Query("state"), cookie)
+	if err != nil {
+		log.Println("Error en el inicio de sesión OIDC:", err)
+		ctx.JSON(ssoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	completeSSOLogin(ctx, identity)
+}
+
+// SAMLMetadata godoc
+// @Summary      SAML service provider metadata
+// @Description  Returns the SAML metadata to register the backend as service provider in the IdP
+// @Tags         auth
+// @Produce      xml
+// @Success      200  {string} string
+// @Failure      404  {object} map[string]interface{}
+// @Router       /auth/saml/metadata [get]
+func SAMLMetadata(ctx *gin.Context) {
+	sp, err := auth.SAML(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(ssoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	metadata, err := sp.Metadata()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+// SAMLLogin godoc
+// @Summary      Start SAML login
+// @Description  Redirects the browser to the SAML IdP with an authentication request. The IdP posts the response to /auth/saml/acs
+// @Tags         auth
+// @Success      302
+// @Failure      404  {object} map[string]interface{}
+// @Failure      502  {object} map[string]interface{}
+// @Router       /auth/saml/login [get]
+func SAMLLogin(ctx *gin.Context) {
+	sp, err := auth.SAML(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(ssoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	url, cookie, err := sp.AuthURL()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	setSSOCookie(ctx, samlStateCookie, cookie, ssoCookieMaxAge)
+	ctx.Redirect(http.StatusFound, url)
+}
+
+// SAMLACS godoc
+// @Summary      SAML assertion consumer service
+// @Description  Receives the SAML response of the IdP, verifies it, finds the user by the email attribute or else by the RUT attribute (creating it when SSO_AUTO_PROVISION is enabled) and returns the same token as /auth/login, or redirects to SSO_FRONTEND_URL with the token in the URL fragment
+// @Tags         auth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        SAMLResponse  formData  string  true  "SAML response"
+// @Param        RelayState    formData  string  true  "Relay state"
+// @Success      200  {object} map[string]interface{}
+// @Failure      401  {object} map[string]interface{}
+// @Failure      403  {object} map[string]interface{}
+// @Router       /auth/saml/acs [post]
+func SAMLACS(ctx *gin.Context) {
+	cookie, _ := ctx.Cookie(samlStateCookie)
+	setSSOCookie(ctx, samlStateCookie, "", -1)
+
+	sp, err := auth.SAML(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(ssoErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	identity, err := sp.ParseResponse(ctx.Request, cookie)
+	if err != nil {
+		log.Println("Error en el inicio de sesión SAML:", err)
+		status := ssoErrorStatus(err)
+		if status == http.StatusBadGateway {
+			// el detalle de una respuesta SAML inválida queda solo en el log
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Respuesta SAML inválida"})
+			return
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	completeSSOLogin(ctx, identity)
+}
@@ -1,13 +1,17 @@
 package controllers
 
 import (
+	"catalogo-backend/middleware"
 	"catalogo-backend/models"
 	"catalogo-backend/services"
+	"catalogo-backend/transitions"
 	"catalogo-backend/utils"
 	"encoding/json"
-	"fmt"
+	"errors"
+	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -25,8 +29,14 @@ import (
 // @Param        archivos   formData  file    false "Attached files"
 // @Success      201  {object} map[string]interface{}
 // @Failure      400  {object} map[string]interface{}
+// @Failure      422  {object} map[string]interface{}
 // @Router       /solicitud/ [post]
 func CreateSolicitud(ctx *gin.Context) {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
 	// se envia como formData ya que recibe los archivos como multipart/form-data
 	jsonStr := ctx.PostForm("solicitud")
 	var solicitud models.Solicitud
@@ -35,35 +45,64 @@ func CreateSolicitud(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Solicitud inválida"})
 		return
 	}
+	// solo se pueden crear solicitudes para centros de costo del usuario
+	if !principal.CanRequestForCC(solicitud.CC) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "No tiene acceso al centro de costo de la solicitud"})
+		return
+	}
 	// le damos un ID único a la solicitud
 	solicitud.ID = primitive.NewObjectID()
+	// el solicitante es siempre el usuario autenticado, el aprobador lo registran las transiciones
+	solicitud.Solicitante = principal.UserID
+	solicitud.Aprobador = primitive.NilObjectID
+	// toda solicitud nace en estado inicial, los cambios posteriores pasan por las transiciones
+	solicitud.State = string(transitions.Inicial)
+	solicitud.Presupuesto = nil
+	// la cadena de aprobación se arma al enviar la solicitud
+	solicitud.Aprobaciones = nil
+	solicitud.PasoActual = 0
+	solicitud.AprobadorActual = primitive.NilObjectID
+	solicitud.RolActual = ""
+	solicitud.SoftDelete = models.SoftDelete{}
+	// las decisiones por línea solo se registran mediante /solicitud/:id/lines
+	for i := range solicitud.Lines {
+		solicitud.Lines[i].ResetDecision()
+	}
 
 	form, _ := ctx.MultipartForm()
-	files := form.File["archivos"]
+	var files []*multipart.FileHeader
+	if form != nil {
+		files = form.File["archivos"]
+	}
 
+	// los archivos quedan en staging hasta que se confirme la solicitud
+	var archivos *utils.ArchivosPendientes
 	if len(files) > 0 {
-		// la carpeta es generada con el ID de la solicitud en Hexadecimal como string
-		rutas, err := utils.GuardarArchivos(files, solicitud.ID.Hex())
+		// la carpeta definitiva es generada con el ID de la solicitud en Hexadecimal como string
+		var err error
+		archivos, err = utils.StageArchivos(files, solicitud.ID.Hex())
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al guardar archivos"})
 			return
 		}
-		solicitud.Documents = rutas
+		defer archivos.Descartar()
+		solicitud.Documents = archivos.Rutas
 	}
-	// se crea la solicitud en la base de datos
-	result, err := services.CreateSolicitudService(&solicitud)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error al crear la solicitud": err.Error()})
+	// se crea la solicitud y su log en la base de datos
+	resultado, err := services.CreateSolicitudService(middleware.RequestContext(ctx), &solicitud, archivos)
+	if respondValidationError(ctx, err) {
+		return
+	}
+	if errors.Is(err, services.ErrArchivosNoPromovidos) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "id": solicitud.ID})
 		return
 	}
-	// se crea el log de la solicitud
-	resultado, err := services.CreateLogFromSolicitud(&solicitud)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear log de solicitud: " + err.Error()})
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error al crear la solicitud": err.Error()})
 		return
 	}
 	ctx.JSON(http.StatusCreated, gin.H{
-		"id":         result.ID,
+		"id":         solicitud.ID,
 		"documentos": solicitud.Documents,
 		"log":        resultado,
 	})
@@ -79,19 +118,63 @@ func CreateSolicitud(ctx *gin.Context) {
 // @Failure      404  {object} map[string]interface{}
 // @Router       /solicitud/{id} [get]
 func GetSolicitud(ctx *gin.Context) {
-	id := ctx.Param("id")
+	solicitud, _, ok := getSolicitudConAcceso(ctx, ctx.Param("id"))
+	if !ok {
+		return
+	}
 
+	ctx.JSON(http.StatusOK, solicitud)
+}
+
+// respondValidationError responde 422 con los errores por campo si err es un error de validación
+func respondValidationError(ctx *gin.Context, err error) bool {
+	var verr *services.ValidationError
+	if !errors.As(err, &verr) {
+		return false
+	}
+	ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":  "La solicitud tiene errores de validación",
+		"fields": verr.Errors,
+	})
+	return true
+}
+
+// getSolicitudConAcceso obtiene la solicitud verificando que el usuario autenticado tenga acceso,
+// en caso contrario responde el error y retorna false
+func getSolicitudConAcceso(ctx *gin.Context, id string) (*models.Solicitud, *models.Principal, bool) {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return nil, nil, false
+	}
 	solicitud, err := services.GetSolicitudByIDService(id)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+		return nil, nil, false
 	}
 	if solicitud == nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "Solicitud no encontrada"})
-		return
+		return nil, nil, false
+	}
+	if !principal.CanAccessSolicitud(solicitud) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": services.ErrSinAcceso.Error()})
+		return nil, nil, false
 	}
+	return solicitud, principal, true
+}
 
-	ctx.JSON(http.StatusOK, solicitud)
+// getSolicitudParaModificar obtiene la solicitud verificando que el usuario autenticado pueda
+// modificarla o eliminarla, en caso contrario responde el error y retorna false
+func getSolicitudParaModificar(ctx *gin.Context, id string) (*models.Solicitud, *models.Principal, bool) {
+	solicitud, principal, ok := getSolicitudConAcceso(ctx, id)
+	if !ok {
+		return nil, nil, false
+	}
+	if !principal.CanModifySolicitud(solicitud) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": services.ErrSinPermisoModificar.Error()})
+		return nil, nil, false
+	}
+	return solicitud, principal, true
 }
 
 func GetAllSolicitudes(ctx *gin.Context) {
@@ -104,9 +187,22 @@ func GetAllSolicitudes(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, solicitudes)
 }
 
+// camposEditablesSolicitud son los campos de primer nivel que se aceptan en PUT /solicitud/:id.
+// Los importes y tipos de cambio se recalculan en el servidor a partir de las líneas
+var camposEditablesSolicitud = map[string]bool{
+	"cc":               true,
+	"lines":            true,
+	"description":      true,
+	"documents":        true,
+	"fecha_solicitud":  true,
+	"fecha_contable":   true,
+	"moneda":           true,
+	"nombre_solicitud": true,
+}
+
 // UpdateSolicitud godoc
 // @Summary      Update solicitud
-// @Description  Updates an existing solicitud
+// @Description  Updates the editable fields of a solicitud (cc, lines, description, documents, fecha_solicitud, fecha_contable, moneda, nombre_solicitud). Keys with dots or starting with $ are rejected. Once submitted, lines, cc, moneda and fecha_solicitud can no longer be changed
 // @Tags         solicitudes
 // @Accept       json
 // @Produce      json
@@ -114,6 +210,8 @@ func GetAllSolicitudes(ctx *gin.Context) {
 // @Param        payload body      object  true  "Update data"
 // @Success      200  {object} map[string]string
 // @Failure      400  {object} map[string]interface{}
+// @Failure      409  {object} map[string]interface{}
+// @Failure      422  {object} map[string]interface{}
 // @Router       /solicitud/{id} [put]
 func UpdateSolicitud(ctx *gin.Context) {
 	id := ctx.Param("id")
@@ -123,25 +221,51 @@ func UpdateSolicitud(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos para actualización"})
 		return
 	}
-	// antes de actualizar, obtenemos la solicitud actual para crear el log
-	solicitudPrevia, err := services.GetSolicitudByIDService(id)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener solicitud para obtener estado previo (logs): " + err.Error()})
+	if _, ok := update["state"]; ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "El estado solo puede cambiarse mediante /solicitud/:id/transitions"})
 		return
 	}
-	if err := services.UpdateSolicitudService(id, update); err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error al actualizar la solicitud": err.Error()})
+	for campo := range update {
+		// una clave con punto o con $ llegaría tal cual al $set y modificaría campos anidados
+		if strings.Contains(campo, ".") || strings.HasPrefix(campo, "$") {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Campo inválido para actualización: " + campo})
+			return
+		}
+		// el resto de los campos los administran el servidor, las transiciones y la eliminación
+		if !camposEditablesSolicitud[campo] {
+			delete(update, campo)
+		}
+	}
+	// antes de actualizar, obtenemos la solicitud actual para crear el log
+	solicitudPrevia, principal, ok := getSolicitudParaModificar(ctx, id)
+	if !ok {
 		return
 	}
-	// se crea el log de la solicitud
-	solicitudPosterior, err := services.GetSolicitudByIDService(id)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener solicitud posterior a la actualización (logs): " + err.Error()})
+	// si se cambia el centro de costo, el nuevo también debe ser visible para el usuario
+	if ccRaw, ok := update["cc"]; ok {
+		ccStr, _ := ccRaw.(string)
+		ccID, err := primitive.ObjectIDFromHex(ccStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Centro de costo inválido"})
+			return
+		}
+		if !principal.CanRequestForCC(ccID) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "No tiene acceso al centro de costo indicado"})
+			return
+		}
+		update["cc"] = ccID
 	}
-	// se crea el log de actualización
-	_, err = services.CreateLogFromUpdate(solicitudPosterior, solicitudPrevia)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al crear log de actualización: " + err.Error()})
+	// la actualización y su log se guardan en una transacción. Una vez enviada a aprobación, las líneas
+	// solo cambian mediante las decisiones por línea
+	if _, err := services.UpdateSolicitudService(middleware.RequestContext(ctx), solicitudPrevia, update); err != nil {
+		if respondValidationError(ctx, err) {
+			return
+		}
+		if errors.Is(err, services.ErrCamposBloqueados) || errors.Is(err, services.ErrEstadoModificado) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusBadRequest, gin.H{"error al actualizar la solicitud": err.Error()})
 		return
 	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "Solicitud actualizada correctamente"})
@@ -149,17 +273,26 @@ func UpdateSolicitud(ctx *gin.Context) {
 
 // DeleteSolicitud godoc
 // @Summary      Delete solicitud
-// @Description  Deletes a solicitud by ID
+// @Description  Soft deletes a solicitud by ID and records it in its log, releasing the budget committed by an approved solicitud. It can be restored until the purge removes it
 // @Tags         solicitudes
 // @Produce      json
 // @Param        id   path      string  true  "Solicitud ID"
 // @Success      200  {object} map[string]string
 // @Failure      400  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
 // @Router       /solicitud/{id} [delete]
 func DeleteSolicitud(ctx *gin.Context) {
 	id := ctx.Param("id")
 
-	if err := services.DeleteSolicitudService(id); err != nil {
+	if _, _, ok := getSolicitudParaModificar(ctx, id); !ok {
+		return
+	}
+	err := services.DeleteSolicitudService(middleware.RequestContext(ctx), id)
+	if errors.Is(err, services.ErrSolicitudNoEncontrada) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
@@ -167,6 +300,26 @@ func DeleteSolicitud(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, gin.H{"message": "Solicitud eliminada"})
 }
 
+// RestoreSolicitud godoc
+// @Summary      Restore solicitud
+// @Description  Restores a soft deleted solicitud and records it in its log. An approved solicitud commits its budget again
+// @Tags         solicitudes
+// @Produce      json
+// @Param        id   path      string  true  "Solicitud ID"
+// @Success      200  {object} map[string]string
+// @Failure      400  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Failure      422  {object} map[string]interface{}
+// @Router       /solicitud/{id}/restore [post]
+func RestoreSolicitud(ctx *gin.Context) {
+	if err := services.RestoreSolicitudService(middleware.RequestContext(ctx), ctx.Param("id")); err != nil {
+		ctx.JSON(restoreErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Solicitud restaurada"})
+}
+
 // GetSolicitudesFiltradasPaginated godoc
 // @Summary      List solicitudes filtered paginated
 // @Description  Returns solicitudes using filters and pagination
@@ -183,29 +336,24 @@ func DeleteSolicitud(ctx *gin.Context) {
 // @Failure      500  {object} map[string]interface{}
 // @Router       /solicitud/filtradas [get]
 func GetSolicitudesFiltradasPaginated(ctx *gin.Context) {
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
 	pageStr := ctx.DefaultQuery("page", "1")
 	pageSizeStr := ctx.DefaultQuery("pageSize", "50")
 	state := ctx.Query("state")
 	idStr := ctx.Query("id")
 	fechaInicioStr := ctx.Query("fechaInicio")
 	fechaFinStr := ctx.Query("fechaFin")
-	// Obtener los centros de costo enviados desde el frontend
+	// Obtener los centros de costo enviados desde el frontend, solo se usan para acotar
+	// la búsqueda, la visibilidad la define el principal en el servidor
 	ccIDs := ctx.QueryArray("ccs[]")
 	if len(ccIDs) == 0 {
 		ccIDs = ctx.QueryArray("ccs") // fallback
-		// si no llegan centros de costo, no se muestra nada
-		if len(ccIDs) == 0 {
-			ctx.JSON(http.StatusOK, gin.H{
-				"data":       []models.Solicitud{},
-				"total":      0,
-				"page":       1,
-				"pageSize":   50,
-				"totalPages": 0,
-			})
-			return
-		}
 	}
-	fmt.Println("Centros de Costo recibidos:", ccIDs)
+	utils.Debug("Centros de Costo recibidos:", ccIDs)
 
 	// Paginación segura
 	page, err := strconv.Atoi(pageStr)
@@ -271,7 +419,7 @@ func GetSolicitudesFiltradasPaginated(ctx *gin.Context) {
 	}
 
 	// Llamada al servicio
-	solicitudes, total, err := services.GetSolicitudesFilteredPaginatedService(page, pageSize, filter)
+	solicitudes, total, err := services.GetSolicitudesFilteredPaginatedService(page, pageSize, services.ScopeSolicitudFilter(principal, filter))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -309,10 +457,16 @@ func GetSolicitudesPaginated(ctx *gin.Context) {
 		pageSize = 50
 	}
 
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
 	// filtrar por estado, si se proporciona
 	filter := bson.M{}
 
-	solicitudes, total, err := services.GetSolicitudesPaginatedService(page, pageSize, filter)
+	solicitudes, total, err := services.GetSolicitudesPaginatedService(page, pageSize, services.ScopeSolicitudFilter(principal, filter))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -369,10 +523,9 @@ func GetSolicitudesByCCAndStatePaginated(ctx *gin.Context) {
 
 // GetSolicitudesAprobarPaginated godoc
 // @Summary      List solicitudes to approve
-// @Description  Returns solicitudes for approval filtered by supervisor
+// @Description  Returns solicitudes whose pending approval step belongs to the caller
 // @Tags         solicitudes
 // @Produce      json
-// @Param        userId    query string true  "User ID"
 // @Param        page      query int    false "Page"
 // @Param        pageSize  query int    false "Page size"
 // @Param        state     query string false "State"
@@ -384,14 +537,10 @@ func GetSolicitudesByCCAndStatePaginated(ctx *gin.Context) {
 // @Failure      400 {object} map[string]interface{}
 // @Router       /solicitud/aprobar [get]
 func GetSolicitudesAprobarPaginated(ctx *gin.Context) {
-	userIDStr := ctx.Query("userId")
-	if userIDStr == "" {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Falta el parámetro userId"})
-		return
-	}
-	userID, err := primitive.ObjectIDFromHex(userIDStr)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Formato de userId inválido"})
+	// el aprobador se obtiene del token, el parámetro userId del frontend se ignora
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
 		return
 	}
 
@@ -415,25 +564,9 @@ func GetSolicitudesAprobarPaginated(ctx *gin.Context) {
 		pageSize = 100
 	}
 
-	ccIDs, err := services.NewCentroCostoService().GetCCIDsByJefe(userID)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Error al obtener centros de costo"})
-		return
-	}
-	if len(ccIDs) == 0 {
-		ctx.JSON(http.StatusOK, gin.H{
-			"data":       []models.Solicitud{},
-			"total":      0,
-			"page":       page,
-			"pageSize":   pageSize,
-			"totalPages": 0,
-		})
-		return
-	}
-
-	filter := bson.M{
-		"cc": bson.M{"$in": ccIDs},
-	}
+	// solo las solicitudes cuyo paso de aprobación pendiente le corresponde al usuario,
+	// los administradores ven las solicitudes de todos los centros de costo
+	filter := bson.M{}
 
 	if state != "" {
 		filter["state"] = state
@@ -447,25 +580,7 @@ func GetSolicitudesAprobarPaginated(ctx *gin.Context) {
 
 	if ccStr != "" {
 		if ccID, err := primitive.ObjectIDFromHex(ccStr); err == nil {
-			found := false
-			for _, id := range ccIDs {
-				if id == ccID {
-					found = true
-					break
-				}
-			}
-			if found {
-				filter["cc"] = ccID
-			} else {
-				ctx.JSON(http.StatusOK, gin.H{
-					"data":       []models.Solicitud{},
-					"total":      0,
-					"page":       page,
-					"pageSize":   pageSize,
-					"totalPages": 0,
-				})
-				return
-			}
+			filter["cc"] = ccID
 		}
 	}
 	// Si ambas fechas están presentes, intentamos parsear y agregar al filtro
@@ -485,6 +600,7 @@ func GetSolicitudesAprobarPaginated(ctx *gin.Context) {
 			utils.Debug("Filtro por fechas aplicado:", filter["fecha_solicitud"])
 		}
 	}
+	filter = bson.M{"$and": []bson.M{filter, services.PendingApprovalFilter(principal)}}
 	solicitudes, total, err := services.GetSolicitudesFilteredPaginatedService(page, pageSize, filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
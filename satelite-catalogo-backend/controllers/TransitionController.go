@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"catalogo-backend/middleware"
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+	"catalogo-backend/transitions"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TransitionRequest represents the optional payload of a state transition.
+type TransitionRequest struct {
+	Comentario string `json:"comentario"`
+}
+
+// TransitionSolicitud godoc
+// @Summary      Apply state transition
+// @Description  Moves a solicitud through the state machine (submit, approve, reject, close)
+// @Tags         solicitudes
+// @Accept       json
+// @Produce      json
+// @Param        id          path      string             true   "Solicitud ID"
+// @Param        transition  path      string             true   "Transition name"  Enums(submit, approve, reject, close)
+// @Param        payload     body      TransitionRequest  false  "Comentario"
+// @Success      200  {object} models.Solicitud
+// @Failure      400  {object} map[string]interface{}
+// @Failure      403  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Failure      409  {object} map[string]interface{}
+// @Failure      422  {object} map[string]interface{}
+// @Router       /solicitud/{id}/transitions/{transition} [post]
+func TransitionSolicitud(ctx *gin.Context) {
+	id := ctx.Param("id")
+	name := transitions.Name(ctx.Param("transition"))
+
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var req TransitionRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos para la transición"})
+			return
+		}
+	}
+
+	solicitud, err := services.ApplyTransitionService(middleware.RequestContext(ctx), id, name, principal, req.Comentario)
+	if err != nil {
+		ctx.JSON(transitionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, solicitud)
+}
+
+// GetTransitionsSolicitud godoc
+// @Summary      List available transitions
+// @Description  Returns the transitions the current user may apply to a solicitud
+// @Tags         solicitudes
+// @Produce      json
+// @Param        id   path      string  true  "Solicitud ID"
+// @Success      200  {object} map[string]interface{}
+// @Failure      404  {object} map[string]interface{}
+// @Router       /solicitud/{id}/transitions [get]
+func GetTransitionsSolicitud(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	names, err := services.GetAvailableTransitionsService(id, principal)
+	if err != nil {
+		ctx.JSON(transitionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"transitions": names})
+}
+
+// ApproveLineSolicitud godoc
+// @Summary      Approve solicitud line
+// @Description  Approves a single line of a solicitud and derives the solicitud state
+// @Tags         solicitudes
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string             true   "Solicitud ID"
+// @Param        numero   path      int                true   "Numero de linea"
+// @Param        payload  body      TransitionRequest  false  "Comentario"
+// @Success      200  {object} models.Solicitud
+// @Failure      403  {object} map[string]interface{}
+// @Failure      409  {object} map[string]interface{}
+// @Failure      422  {object} map[string]interface{}
+// @Router       /solicitud/{id}/lines/{numero}/approve [post]
+func ApproveLineSolicitud(ctx *gin.Context) {
+	decideLine(ctx, models.LineStateAprobada)
+}
+
+// RejectLineSolicitud godoc
+// @Summary      Reject solicitud line
+// @Description  Rejects a single line of a solicitud, a reason is required
+// @Tags         solicitudes
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string             true  "Solicitud ID"
+// @Param        numero   path      int                true  "Numero de linea"
+// @Param        payload  body      TransitionRequest  true  "Motivo del rechazo"
+// @Success      200  {object} models.Solicitud
+// @Failure      403  {object} map[string]interface{}
+// @Failure      409  {object} map[string]interface{}
+// @Failure      422  {object} map[string]interface{}
+// @Router       /solicitud/{id}/lines/{numero}/reject [post]
+func RejectLineSolicitud(ctx *gin.Context) {
+	decideLine(ctx, models.LineStateRechazada)
+}
+
+func decideLine(ctx *gin.Context, decision models.LineState) {
+	id := ctx.Param("id")
+	numeroLinea, err := strconv.Atoi(ctx.Param("numero"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Número de línea inválido"})
+		return
+	}
+
+	principal, ok := middleware.GetPrincipal(ctx)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Usuario no autenticado"})
+		return
+	}
+
+	var req TransitionRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Datos inválidos para la decisión"})
+			return
+		}
+	}
+
+	solicitud, err := services.DecideLineService(middleware.RequestContext(ctx), id, numeroLinea, decision, principal, req.Comentario)
+	if err != nil {
+		ctx.JSON(transitionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, solicitud)
+}
+
+// transitionErrorStatus traduce los errores de la máquina de estados a códigos HTTP
+func transitionErrorStatus(err error) int {
+	var guardErr *transitions.GuardError
+	switch {
+	case errors.Is(err, services.ErrSolicitudNoEncontrada), errors.Is(err, transitions.ErrTransicionDesconocida):
+		return http.StatusNotFound
+	case errors.Is(err, transitions.ErrNoAutorizado), errors.Is(err, services.ErrSinAcceso):
+		return http.StatusForbidden
+	case errors.Is(err, transitions.ErrTransicionInvalida), errors.Is(err, services.ErrEstadoModificado):
+		return http.StatusConflict
+	case errors.As(err, &guardErr), errors.Is(err, services.ErrPresupuestoExcedido), errors.Is(err, services.ErrTipoCambioNoDisponible):
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusBadRequest
+	}
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetDeleted godoc
+// @Summary      List deleted records
+// @Description  Returns the soft deleted solicitudes, products or centros de costo, most recently deleted first
+// @Tags         trash
+// @Produce      json
+// @Param        tipo      query  string  true   "Record type"  Enums(solicitud, product, cc)
+// @Param        page      query  int     false  "Page number"
+// @Param        pageSize  query  int     false  "Page size"
+// @Success      200  {object} map[string]interface{}
+// @Failure      400  {object} map[string]interface{}
+// @Router       /trash/ [get]
+func GetDeleted(ctx *gin.Context) {
+	page, pageSize := logPagination(ctx)
+
+	data, total, err := services.ListDeletedService(models.SoftDeleteKind(ctx.Query("tipo")), page, pageSize)
+	if errors.Is(err, services.ErrTipoEliminadoInvalido) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":       data,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
+	})
+}
+
+// PurgeDeleted godoc
+// @Summary      Purge deleted records
+// @Description  Permanently removes the records deleted before the retention window, with their files and price history
+// @Tags         trash
+// @Produce      json
+// @Param        days    query  int   false  "Retention days, defaults to SOFT_DELETE_RETENTION_DAYS"
+// @Param        dryRun  query  bool  false  "Only report what would be removed"
+// @Success      200  {object} services.PurgeReport
+// @Failure      400  {object} map[string]interface{}
+// @Router       /trash/purge [post]
+func PurgeDeleted(ctx *gin.Context) {
+	dias := services.SoftDeleteRetentionDays()
+	if raw := ctx.Query("days"); raw != "" {
+		var err error
+		if dias, err = strconv.Atoi(raw); err != nil || dias < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Días de retención inválidos"})
+			return
+		}
+	}
+
+	report, err := services.PurgeDeletedService(dias, ctx.Query("dryRun") == "true")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+func restoreErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNoEliminado), errors.Is(err, services.ErrSolicitudNoEncontrada):
+		return http.StatusNotFound
+	case errors.Is(err, primitive.ErrInvalidHex):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrPresupuestoExcedido):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
@@ -5,7 +5,7 @@ import (
 	"net/http"
 	"time"
 
-	"catalogo-backend/middleware"
+	"catalogo-backend/auth"
 	"catalogo-backend/models"
 	"catalogo-backend/services"
 
@@ -27,13 +27,14 @@ type CreateUserRequest struct {
 
 // CreateUser godoc
 // @Summary      Register new user
-// @Description  Creates a new user after validating credentials
+// @Description  Creates a new user. With an external AUTH_PROVIDER (usach, ldap) the credentials are verified against it; with the local provider the password is stored
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Param        payload  body      CreateUserRequest  true  "User info"
 // @Success      201      {object} models.User
 // @Failure      400      {object} map[string]interface{}
+// @Failure      401      {object} map[string]interface{}
 // @Router       /user/ [post]
 func CreateUser(ctx *gin.Context) {
 	var req CreateUserRequest
@@ -42,22 +43,30 @@ func CreateUser(ctx *gin.Context) {
 		return
 	}
 
-	// Verificar con login externo (USACH)
-	response, err := middleware.RequestLogin(req.Username, req.Password)
-
-	if response == nil || response.StatusCode != http.StatusOK {
-		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Credenciales inválidas"})
+	provider, err := auth.Current()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Error al verificar el usuario"})
+	// los proveedores que guardan contraseñas la reciben después de crear el usuario, el resto verifica
+	// que el usuario exista en el directorio externo
+	store, guardaPassword := provider.(auth.PasswordStore)
+	email := auth.EmailFor(req.Username)
+	if !guardaPassword {
+		identity, err := provider.Authenticate(ctx.Request.Context(), req.Username, req.Password)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Credenciales inválidas"})
+			return
+		}
+		email = identity.Email
+	} else if req.Password == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Debe indicar la contraseña"})
 		return
 	}
 
 	newUser := &models.User{
-		Username:  req.Username,
-		Email:     req.Username + "@usach.cl",
+		Username:  auth.UsernameFor(req.Username),
+		Email:     email,
 		Rut:       req.Rut,
 		Role:      []models.Role{models.USER},
 		CC:        []primitive.ObjectID{},
@@ -69,6 +78,12 @@ func CreateUser(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if guardaPassword {
+		if err := store.SetPassword(ctx.Request.Context(), createdUser.Email, req.Password); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
 
 	ctx.JSON(http.StatusCreated, createdUser)
 }
@@ -127,6 +142,7 @@ func GetAllUsers(ctx *gin.Context) {
 
 // UpdateUser godoc
 // @Summary      Update user
+// @Description  Updates the user. cc and cc_observador are ignored, memberships are managed with /user/{id}/cc/{cc}
 // @Tags         users
 // @Accept       json
 // @Produce      json
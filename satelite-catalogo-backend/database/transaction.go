@@ -0,0 +1,30 @@
+package database
+
+import (
+	"context"
+	"os"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TransactionsEnabled indica si las operaciones de varias colecciones se ejecutan en una transacción.
+// Las transacciones requieren un replica set; con MONGO_TRANSACTIONS=false se ejecutan sin transacción,
+// por ejemplo contra un MongoDB standalone de desarrollo
+func TransactionsEnabled() bool {
+	return os.Getenv("MONGO_TRANSACTIONS") != "false"
+}
+
+// RunTransaction ejecuta fn en una transacción multi-documento. Los repositorios deben usar el ctx
+// recibido por fn para participar de ella. fn puede ejecutarse más de una vez si la transacción se
+// reintenta por un error transitorio, por lo que no debe tener efectos fuera de la base de datos
+func RunTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !TransactionsEnabled() {
+		return fn(ctx)
+	}
+	return Client.UseSession(ctx, func(sc mongo.SessionContext) error {
+		_, err := sc.WithTransaction(sc, func(tx mongo.SessionContext) (interface{}, error) {
+			return nil, fn(tx)
+		})
+		return err
+	})
+}
@@ -0,0 +1,214 @@
+// Package diff calcula los cambios campo a campo entre dos estados de una solicitud para
+// registrarlos en el log en vez de los estados completos.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// arrayKeys son los arreglos cuyos elementos se comparan por una clave y no por posición, así
+// eliminar una línea no aparece como cambios en todas las siguientes
+var arrayKeys = map[string]string{
+	"lines":        "numero_linea",
+	"aprobaciones": "orden",
+}
+
+// Solicitudes retorna los campos que cambiaron de previa a nueva, ordenados por campo.
+// Los nombres de los campos son los de la base de datos; previa nil se trata como vacía
+func Solicitudes(previa, nueva *models.Solicitud) ([]models.FieldChange, error) {
+	a, err := toDoc(previa)
+	if err != nil {
+		return nil, err
+	}
+	b, err := toDoc(nueva)
+	if err != nil {
+		return nil, err
+	}
+	cambios := []models.FieldChange{}
+	compareDocs("", a, b, &cambios)
+	return cambios, nil
+}
+
+func toDoc(s *models.Solicitud) (bson.M, error) {
+	if s == nil {
+		return bson.M{}, nil
+	}
+	raw, err := bson.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	doc := bson.M{}
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	// el último log registrado cambia con cada log, no es un cambio de la solicitud
+	delete(doc, "log_secuencia")
+	delete(doc, "log_hash")
+	return doc, nil
+}
+
+func compareDocs(prefix string, a, b bson.M, cambios *[]models.FieldChange) {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		compareValues(path, k, Normalize(a[k]), Normalize(b[k]), cambios)
+	}
+}
+
+func compareValues(path, key string, a, b interface{}, cambios *[]models.FieldChange) {
+	docA, okA := a.(bson.M)
+	docB, okB := b.(bson.M)
+	if okA && okB {
+		compareDocs(path, docA, docB, cambios)
+		return
+	}
+
+	arrA, okA := a.(primitive.A)
+	arrB, okB := b.(primitive.A)
+	if idKey, keyed := arrayKeys[key]; keyed && (okA || a == nil) && (okB || b == nil) {
+		compareKeyed(path, idKey, arrA, arrB, cambios)
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*cambios = append(*cambios, models.FieldChange{Campo: path, Anterior: a, Nuevo: b})
+	}
+}
+
+// compareKeyed compara los elementos de dos arreglos de documentos con el mismo valor de idKey
+func compareKeyed(path, idKey string, a, b primitive.A, cambios *[]models.FieldChange) {
+	byID := func(arr primitive.A) (map[string]bson.M, []string) {
+		m := map[string]bson.M{}
+		orden := []string{}
+		for i, elem := range arr {
+			doc, ok := elem.(bson.M)
+			if !ok {
+				continue
+			}
+			id := fmt.Sprint(doc[idKey])
+			if doc[idKey] == nil {
+				id = "#" + strconv.Itoa(i)
+			}
+			if _, dup := m[id]; !dup {
+				orden = append(orden, id)
+			}
+			m[id] = doc
+		}
+		return m, orden
+	}
+	mapA, ordenA := byID(a)
+	mapB, ordenB := byID(b)
+
+	ids := ordenA
+	for _, id := range ordenB {
+		if _, ok := mapA[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+
+	for _, id := range ids {
+		elemPath := path + "[" + id + "]"
+		docA, okA := mapA[id]
+		docB, okB := mapB[id]
+		switch {
+		case okA && okB:
+			compareDocs(elemPath, docA, docB, cambios)
+		case okA:
+			*cambios = append(*cambios, models.FieldChange{Campo: elemPath, Anterior: docA})
+		default:
+			*cambios = append(*cambios, models.FieldChange{Campo: elemPath, Nuevo: docB})
+		}
+	}
+}
+
+// Normalize convierte los documentos leídos de la base de datos (primitive.D) en bson.M, para
+// compararlos y para que se muestren como objetos en JSON
+func Normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case primitive.D:
+		m := bson.M{}
+		for _, e := range t {
+			m[e.Key] = Normalize(e.Value)
+		}
+		return m
+	case bson.M:
+		m := bson.M{}
+		for k, e := range t {
+			m[k] = Normalize(e)
+		}
+		return m
+	case map[string]interface{}:
+		return Normalize(bson.M(t))
+	case primitive.A:
+		arr := make(primitive.A, len(t))
+		for i, e := range t {
+			arr[i] = Normalize(e)
+		}
+		return arr
+	case []interface{}:
+		return Normalize(primitive.A(t))
+	}
+	return v
+}
+
+// Describe retorna el cambio en texto legible, ej: "lines[2].cantidad: 3 → 5"
+func Describe(c models.FieldChange) string {
+	switch {
+	case c.Anterior == nil && c.Nuevo != nil:
+		return fmt.Sprintf("%s: agregado %s", c.Campo, formatValue(c.Nuevo))
+	case c.Nuevo == nil && c.Anterior != nil:
+		return fmt.Sprintf("%s: eliminado %s", c.Campo, formatValue(c.Anterior))
+	}
+	return fmt.Sprintf("%s: %s → %s", c.Campo, formatValue(c.Anterior), formatValue(c.Nuevo))
+}
+
+func formatValue(v interface{}) string {
+	switch t := Normalize(v).(type) {
+	case nil:
+		return "(vacío)"
+	case string:
+		if t == "" {
+			return "(vacío)"
+		}
+		return strconv.Quote(t)
+	case primitive.ObjectID:
+		return t.Hex()
+	case primitive.DateTime:
+		return t.Time().UTC().Format(time.RFC3339)
+	case time.Time:
+		return t.UTC().Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bson.M, primitive.A:
+		raw, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprint(t)
+		}
+		return string(raw)
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -15,64 +15,52 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/archivos/{filepath}": {
+        "/api-key/": {
             "get": {
-                "description": "Serves files from the uploads directory in a safe manner",
+                "description": "Returns the API keys, newest first, without the keys themselves",
                 "produces": [
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "files"
+                    "api-keys"
                 ],
-                "summary": "Serve uploaded file",
+                "summary": "List API keys",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "File path",
-                        "name": "filepath",
-                        "in": "path",
-                        "required": true
+                        "description": "User the keys act as",
+                        "name": "usuario",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only keys not expired nor revoked",
+                        "name": "vigentes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
-                        "schema": {
-                            "type": "file"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    }
-                }
-            }
-        },
-        "/cc/": {
-            "get": {
-                "description": "Returns all Centros de Costo",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "cc"
-                ],
-                "summary": "List centros de costo",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.CC"
-                            }
-                        }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -81,7 +69,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Creates a new Centro de Costo",
+                "description": "Issues an API key that acts as the user with the given roles, which must be roles the user has. Without expires_at the key expires in 90 days. The key is returned only in this response, send it in the X-API-Key header. Not available when authenticated with an API key",
                 "consumes": [
                     "application/json"
                 ],
@@ -89,17 +77,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "cc"
+                    "api-keys"
                 ],
-                "summary": "Create centro de costo",
+                "summary": "Create API key",
                 "parameters": [
                     {
-                        "description": "Centro de Costo info",
+                        "description": "API key (nombre, usuario, roles, expires_at)",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.CC"
+                            "$ref": "#/definitions/models.APIKey"
                         }
                     }
                 ],
@@ -107,10 +95,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.CreatedAPIKey"
                         }
                     },
                     "400": {
@@ -119,27 +104,38 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/cc/{id}": {
-            "get": {
-                "description": "Returns a Centro de Costo by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api-key/{id}": {
+            "delete": {
+                "description": "Revokes the API key, it is rejected from the next request on",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cc"
+                    "api-keys"
                 ],
-                "summary": "Get centro de costo by ID",
+                "summary": "Revoke API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Centro de Costo ID",
+                        "description": "API key ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -149,7 +145,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.CC"
+                            "$ref": "#/definitions/models.APIKey"
                         }
                     },
                     "400": {
@@ -167,36 +163,29 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Updates a Centro de Costo by ID",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/approval-policy/": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cc"
+                    "approval-policies"
                 ],
-                "summary": "Update centro de costo",
+                "summary": "List approval policies",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Centro de Costo ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
                     },
                     {
-                        "description": "Update data",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -204,13 +193,11 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -218,32 +205,34 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "description": "Deletes a Centro de Costo by ID",
+            "post": {
+                "description": "Creates the approval chain of a centro de costo, or the global one when cc is omitted. Each step applies when the solicitud amount exceeds monto_minimo",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "cc"
+                    "approval-policies"
                 ],
-                "summary": "Delete centro de costo",
+                "summary": "Create approval policy",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Centro de Costo ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Approval policy",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ApprovalPolicy"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.ApprovalPolicy"
                         }
                     },
                     "400": {
@@ -252,32 +241,51 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/product/": {
+        "/approval-policy/{id}": {
             "get": {
-                "description": "Returns all products",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "approval-policies"
+                ],
+                "summary": "Get approval policy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Policy ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "List products",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Product"
-                            }
+                            "$ref": "#/definitions/models.ApprovalPolicy"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -285,8 +293,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
-                "description": "Creates a new product with the provided information",
+            "put": {
+                "description": "Replaces the name and steps of an approval policy. Solicitudes already submitted keep their chain",
                 "consumes": [
                     "application/json"
                 ],
@@ -294,25 +302,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "products"
+                    "approval-policies"
                 ],
-                "summary": "Create product",
+                "summary": "Update approval policy",
                 "parameters": [
                     {
-                        "description": "Product info",
+                        "type": "string",
+                        "description": "Policy ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Approval policy",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/models.ApprovalPolicy"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Product"
+                            "$ref": "#/definitions/models.ApprovalPolicy"
                         }
                     },
                     "400": {
@@ -321,44 +336,47 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
-            }
-        },
-        "/product/paginated": {
-            "get": {
-                "description": "Returns paginated products",
-                "produces": [
-                    "application/json"
-                ],
+            },
+            "delete": {
+                "description": "Deletes an approval policy. The centro de costo falls back to the global policy",
                 "tags": [
-                    "products"
+                    "approval-policies"
                 ],
-                "summary": "List products paginated",
+                "summary": "Delete approval policy",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Page number",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Page size",
-                        "name": "pageSize",
-                        "in": "query"
+                        "type": "string",
+                        "description": "Policy ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "string"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -367,80 +385,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/solicitud/": {
+        "/archivos/{filepath}": {
             "get": {
-                "description": "Returns solicitudes paginated",
+                "description": "Serves files from the uploads directory in a safe manner",
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
-                    "solicitudes"
-                ],
-                "summary": "List solicitudes paginated",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Page",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Page size",
-                        "name": "pageSize",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    }
-                }
-            },
-            "post": {
-                "description": "Creates a new solicitud with optional files",
-                "consumes": [
-                    "multipart/form-data"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "solicitudes"
+                    "files"
                 ],
-                "summary": "Create solicitud",
+                "summary": "Serve uploaded file",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Solicitud JSON",
-                        "name": "solicitud",
-                        "in": "formData",
+                        "description": "File path",
+                        "name": "filepath",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "Attached files",
-                        "name": "archivos",
-                        "in": "formData"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "file"
                         }
                     },
                     "400": {
@@ -453,64 +421,84 @@ const docTemplate = `{
                 }
             }
         },
-        "/solicitud/aprobar": {
+        "/audit/logs": {
             "get": {
-                "description": "Returns solicitudes for approval filtered by supervisor",
+                "description": "Searches the logs of every solicitud by solicitud, user, centro de costo, event type and date range, newest event first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "solicitudes"
+                    "audit"
                 ],
-                "summary": "List solicitudes to approve",
+                "summary": "Search audit logs",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User ID",
-                        "name": "userId",
-                        "in": "query",
-                        "required": true
+                        "description": "Solicitud ID",
+                        "name": "requestId",
+                        "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Page",
-                        "name": "page",
+                        "type": "string",
+                        "description": "User who performed the event",
+                        "name": "userId",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Page size",
-                        "name": "pageSize",
+                        "type": "string",
+                        "description": "Centro de costo ID",
+                        "name": "cc",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "State",
-                        "name": "state",
+                        "description": "Correlation ID of the call (X-Request-ID)",
+                        "name": "correlationId",
                         "in": "query"
                     },
                     {
+                        "enum": [
+                            "create",
+                            "update",
+                            "transition",
+                            "line_decision",
+                            "delete",
+                            "restore",
+                            "handover"
+                        ],
                         "type": "string",
-                        "description": "Solicitud ID",
-                        "name": "id",
+                        "description": "Event types, comma separated",
+                        "name": "eventType",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Centro de costo",
-                        "name": "cc",
+                        "description": "From date (2006-01-02 or RFC3339)",
+                        "name": "desde",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Fecha inicio",
-                        "name": "fechaInicio",
+                        "description": "To date, inclusive (2006-01-02 or RFC3339)",
+                        "name": "hasta",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Fecha fin",
-                        "name": "fechaFin",
+                        "type": "boolean",
+                        "description": "Include previous and new state",
+                        "name": "snapshots",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
                         "in": "query"
                     }
                 ],
@@ -532,61 +520,51 @@ const docTemplate = `{
                 }
             }
         },
-        "/solicitud/filtradas": {
+        "/audit/memberships": {
             "get": {
-                "description": "Returns solicitudes using filters and pagination",
+                "description": "Searches the changes of user memberships in centros de costo by user, centro de costo and date range, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "solicitudes"
+                    "audit"
                 ],
-                "summary": "List solicitudes filtered paginated",
+                "summary": "Search membership audit",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Page",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Page size",
-                        "name": "pageSize",
+                        "type": "string",
+                        "description": "User whose membership changed",
+                        "name": "userId",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "State",
-                        "name": "state",
+                        "description": "Centro de costo ID",
+                        "name": "cc",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Solicitud ID",
-                        "name": "id",
+                        "description": "From date (2006-01-02 or RFC3339)",
+                        "name": "desde",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Fecha inicio",
-                        "name": "fechaInicio",
+                        "description": "To date, inclusive (2006-01-02 or RFC3339)",
+                        "name": "hasta",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Fecha fin",
-                        "name": "fechaFin",
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
                         "in": "query"
                     },
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Centros de costo",
-                        "name": "ccs",
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
                         "in": "query"
                     }
                 ],
@@ -598,8 +576,8 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -608,16 +586,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/solicitud/{id}": {
+        "/audit/solicitud/{id}/verify": {
             "get": {
-                "description": "Returns a solicitud by its ID",
+                "description": "Recomputes the hash chain of the logs of a solicitud and reports edited, missing or unchained entries",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "solicitudes"
+                    "audit"
                 ],
-                "summary": "Get solicitud by ID",
+                "summary": "Verify solicitud log chain",
                 "parameters": [
                     {
                         "type": "string",
@@ -631,46 +609,43 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Solicitud"
+                            "$ref": "#/definitions/services.ChainReport"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Updates an existing solicitud",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/auth/oidc/callback": {
+            "get": {
+                "description": "Redirect URI of the OpenID Connect IdP. Verifies the ID token, finds the user by the email claim or else by the RUT claim (creating it when SSO_AUTO_PROVISION is enabled) and returns the same token as /auth/login, or redirects to SSO_FRONTEND_URL with the token in the URL fragment",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "solicitudes"
+                    "auth"
                 ],
-                "summary": "Update solicitud",
+                "summary": "Complete OpenID Connect login",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Solicitud ID",
-                        "name": "id",
-                        "in": "path",
+                        "description": "Authorization code",
+                        "name": "code",
+                        "in": "query",
                         "required": true
                     },
                     {
-                        "description": "Update data",
-                        "name": "payload",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "object"
-                        }
+                        "type": "string",
+                        "description": "State",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -678,50 +653,46 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Deletes a solicitud by ID",
-                "produces": [
-                    "application/json"
-                ],
+            }
+        },
+        "/auth/oidc/login": {
+            "get": {
+                "description": "Redirects the browser to the institutional OpenID Connect IdP. The IdP returns to /auth/oidc/callback",
                 "tags": [
-                    "solicitudes"
-                ],
-                "summary": "Delete solicitud",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Solicitud ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "auth"
                 ],
+                "summary": "Start OpenID Connect login",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "302": {
+                        "description": "Found"
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "502": {
+                        "description": "Bad Gateway",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -730,27 +701,155 @@ const docTemplate = `{
                 }
             }
         },
-        "/user/": {
-            "get": {
+        "/auth/saml/acs": {
+            "post": {
+                "description": "Receives the SAML response of the IdP, verifies it, finds the user by the email attribute or else by the RUT attribute (creating it when SSO_AUTO_PROVISION is enabled) and returns the same token as /auth/login, or redirects to SSO_FRONTEND_URL with the token in the URL fragment",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "auth"
+                ],
+                "summary": "SAML assertion consumer service",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "SAML response",
+                        "name": "SAMLResponse",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Relay state",
+                        "name": "RelayState",
+                        "in": "formData",
+                        "required": true
+                    }
                 ],
-                "summary": "List users",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.User"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/saml/login": {
+            "get": {
+                "description": "Redirects the browser to the SAML IdP with an authentication request. The IdP posts the response to /auth/saml/acs",
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Start SAML login",
+                "responses": {
+                    "302": {
+                        "description": "Found"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "502": {
+                        "description": "Bad Gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/saml/metadata": {
+            "get": {
+                "description": "Returns the SAML metadata to register the backend as service provider in the IdP",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "SAML service provider metadata",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/budget/": {
+            "get": {
+                "description": "Returns the budgets of the centros de costo visible to the user, newest period first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "budgets"
+                ],
+                "summary": "List budgets",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Centro de costo ID",
+                        "name": "cc",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -759,7 +858,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "Creates a new user after validating credentials",
+                "description": "Creates the budget of a centro de costo for a period. Committed and consumed amounts start at zero",
                 "consumes": [
                     "application/json"
                 ],
@@ -767,17 +866,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "budgets"
                 ],
-                "summary": "Register new user",
+                "summary": "Create budget",
                 "parameters": [
                     {
-                        "description": "User info",
+                        "description": "Budget",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/controllers.CreateUserRequest"
+                            "$ref": "#/definitions/models.Budget"
                         }
                     }
                 ],
@@ -785,7 +884,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.Budget"
                         }
                     },
                     "400": {
@@ -794,44 +893,54 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/user/by-cc": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/budget/cc/{cc}/balance": {
+            "get": {
+                "description": "Returns the budget of the centro de costo in force at the given date (today by default) with its remaining balance",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "budgets"
                 ],
-                "summary": "Get users by centros de costo",
+                "summary": "Centro de costo budget balance",
                 "parameters": [
                     {
-                        "description": "CC IDs",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
-                        }
+                        "type": "string",
+                        "description": "Centro de costo ID",
+                        "name": "cc",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Date (YYYY-MM-DD)",
+                        "name": "fecha",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.User"
-                            }
+                            "$ref": "#/definitions/models.BudgetBalance"
                         }
                     },
                     "400": {
@@ -840,24 +949,39 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
             }
         },
-        "/user/email/{email}": {
+        "/budget/{id}": {
             "get": {
+                "description": "Returns a budget with its remaining balance",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "budgets"
                 ],
-                "summary": "Get user by email",
+                "summary": "Get budget",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User email",
-                        "name": "email",
+                        "description": "Budget ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -866,21 +990,27 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.BudgetBalance"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/user/{email}": {
+            },
             "put": {
+                "description": "Updates period, amount and policy of a budget. Centro de costo, currency and committed/consumed amounts are not editable",
                 "consumes": [
                     "application/json"
                 ],
@@ -888,24 +1018,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "budgets"
                 ],
-                "summary": "Update user",
+                "summary": "Update budget",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User email",
-                        "name": "email",
+                        "description": "Budget ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "User info",
+                        "description": "Budget changes",
                         "name": "payload",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.Budget"
                         }
                     }
                 ],
@@ -913,7 +1043,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.Budget"
                         }
                     },
                     "400": {
@@ -922,66 +1052,91 @@ const docTemplate = `{
                             "type": "object",
                             "additionalProperties": true
                         }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/cc/": {
+            "get": {
+                "description": "Returns all Centros de Costo",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
-                ],
-                "summary": "Delete user",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "User email",
-                        "name": "email",
-                        "in": "path",
-                        "required": true
-                    }
+                    "cc"
                 ],
+                "summary": "List centros de costo",
                 "responses": {
                     "200": {
-                        "description": "deleted",
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CC"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     }
                 }
-            }
-        },
-        "/user/{id}": {
-            "get": {
+            },
+            "post": {
+                "description": "Creates a new Centro de Costo",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "cc"
                 ],
-                "summary": "Get user by ID",
+                "summary": "Create centro de costo",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Centro de Costo info",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CC"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
@@ -993,43 +1148,3066 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
+        },
+        "/cc/import": {
+            "post": {
+                "description": "Imports a CSV/XLSX with numero, nombre, jefe_email and padre (parent numero), upserting by numero. Jefes are resolved by email and a jefe change hands over the pending approvals",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cc"
+                ],
+                "summary": "Import centros de costo",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV or XLSX file",
+                        "name": "archivo",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate without writing",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/cc/import-memberships": {
+            "post": {
+                "description": "Imports a CSV/XLSX with email, cc (centro de costo numero) and rol (solicitante or observador), adding each user to the centro de costo or changing the role",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cc"
+                ],
+                "summary": "Import centro de costo memberships",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV or XLSX file",
+                        "name": "archivo",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate without writing",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/cc/{id}": {
+            "get": {
+                "description": "Returns a Centro de Costo by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cc"
+                ],
+                "summary": "Get centro de costo by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Centro de Costo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CC"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates a Centro de Costo by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cc"
+                ],
+                "summary": "Update centro de costo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Centro de Costo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update data",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Soft deletes a Centro de Costo by ID. It can be restored until the purge removes it. Fails with 409 while the Centro de Costo has open solicitudes",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cc"
+                ],
+                "summary": "Delete centro de costo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Centro de Costo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/cc/{id}/dependents": {
+            "get": {
+                "description": "Counts the open solicitudes and the users of a Centro de Costo",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cc"
+                ],
+                "summary": "Centro de costo dependents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Centro de Costo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.CCDependents"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/cc/{id}/jefe": {
+            "put": {
+                "description": "Sets a new jefe for the Centro de Costo, moves the Centro de Costo from the previous jefe's cc list to the new one's and hands the pending jefe approval steps over to the new jefe, logging it in each solicitud",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cc"
+                ],
+                "summary": "Change centro de costo jefe",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Centro de Costo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New jefe user ID",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ReassignJefeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.JefeHandoverReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/cc/{id}/members": {
+            "get": {
+                "description": "Returns the users of the Centro de Costo with their role, ordered by username. Available to admins and to the jefes of the Centro de Costo or of one of its ancestors",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cc"
+                ],
+                "summary": "List centro de costo members",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Centro de Costo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/cc/{id}/reassign": {
+            "post": {
+                "description": "Moves the users and the open solicitudes of a Centro de Costo to another one in a single transaction and reports what was moved. Pending jefe steps of the source jefe are reassigned to the destination jefe and the budget committed by approved solicitudes moves to the destination's active budget",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cc"
+                ],
+                "summary": "Reassign centro de costo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Centro de Costo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Destination Centro de Costo",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ReassignCentroCostoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.CCReassignReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/cc/{id}/restore": {
+            "post": {
+                "description": "Restores a soft deleted Centro de Costo",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cc"
+                ],
+                "summary": "Restore centro de costo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Centro de Costo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/cc/{id}/spend": {
+            "get": {
+                "description": "Returns the spend of the solicitudes of the Centro de Costo and of each descendant, in the reporting currency, with each node's own spend and the total rolled up from its descendants",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cc"
+                ],
+                "summary": "Centro de costo spend roll-up",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Centro de Costo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "From fecha_solicitud (2006-01-02 or RFC3339)",
+                        "name": "desde",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To fecha_solicitud, inclusive (2006-01-02 or RFC3339)",
+                        "name": "hasta",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CCSpendNode"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/cc/{id}/subtree": {
+            "get": {
+                "description": "Returns the Centro de Costo with all its descendants. Available to admins and to the jefes of the Centro de Costo or of one of its ancestors",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "cc"
+                ],
+                "summary": "Centro de costo subtree",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Centro de Costo ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.CCNode"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/delegation/": {
+            "get": {
+                "description": "Returns the delegations given or received by the user, all of them for admins",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "delegations"
+                ],
+                "summary": "List delegations",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only delegations active now",
+                        "name": "vigentes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Delegates the approval authority of the user to another user for a date range, optionally limited to some centros de costo. Only admins can set a different delegante",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "delegations"
+                ],
+                "summary": "Create delegation",
+                "parameters": [
+                    {
+                        "description": "Delegation",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Delegation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Delegation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/delegation/{id}": {
+            "delete": {
+                "description": "Ends a delegation before its end date. Only the delegante or an admin can revoke it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "delegations"
+                ],
+                "summary": "Revoke delegation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Delegation ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Delegation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/exchange-rate/": {
+            "get": {
+                "description": "Returns the loaded exchange rates, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchange-rates"
+                ],
+                "summary": "List exchange rates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Currency (UF, USD, EUR)",
+                        "name": "moneda",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates or replaces daily exchange rates (CLP per unit), one per currency and date",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchange-rates"
+                ],
+                "summary": "Save exchange rates",
+                "parameters": [
+                    {
+                        "description": "Exchange rates",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ExchangeRate"
+                            }
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate without writing",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/exchange-rate/import": {
+            "post": {
+                "description": "Imports a CSV/XLSX file with the columns moneda, fecha, valor and fuente (optional)",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exchange-rates"
+                ],
+                "summary": "Import exchange rates",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV or XLSX file",
+                        "name": "archivo",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate without writing",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/product/": {
+            "get": {
+                "description": "Returns all products",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "List products",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Product"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a new product with the provided information",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Create product",
+                "parameters": [
+                    {
+                        "description": "Product info",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Product"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/product/import": {
+            "post": {
+                "description": "Imports a convenio marco CSV/XLSX export, upserting by id_convenio, id_product and region",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Import products",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "CSV or XLSX file",
+                        "name": "archivo",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Validate without writing",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/product/paginated": {
+            "get": {
+                "description": "Returns paginated products",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "List products paginated",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/product/{id}/price-history": {
+            "get": {
+                "description": "Returns the chronological price timeline of a product",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Product price history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/product/{id}/restore": {
+            "post": {
+                "description": "Restores a soft deleted product",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "products"
+                ],
+                "summary": "Restore product",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Product ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/solicitud/": {
+            "get": {
+                "description": "Returns solicitudes paginated",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "List solicitudes paginated",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a new solicitud with optional files",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "Create solicitud",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Solicitud JSON",
+                        "name": "solicitud",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Attached files",
+                        "name": "archivos",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/solicitud/aprobar": {
+            "get": {
+                "description": "Returns solicitudes whose pending approval step belongs to the caller",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "List solicitudes to approve",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "State",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Solicitud ID",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Centro de costo",
+                        "name": "cc",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha inicio",
+                        "name": "fechaInicio",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha fin",
+                        "name": "fechaFin",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/solicitud/filtradas": {
+            "get": {
+                "description": "Returns solicitudes using filters and pagination",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "List solicitudes filtered paginated",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "State",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Solicitud ID",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha inicio",
+                        "name": "fechaInicio",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Fecha fin",
+                        "name": "fechaFin",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "description": "Centros de costo",
+                        "name": "ccs",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/solicitud/{id}": {
+            "get": {
+                "description": "Returns a solicitud by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "Get solicitud by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Solicitud ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Solicitud"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Updates the editable fields of a solicitud (cc, lines, description, documents, fecha_solicitud, fecha_contable, moneda, nombre_solicitud). Keys with dots or starting with $ are rejected. Once submitted, lines, cc, moneda and fecha_solicitud can no longer be changed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "Update solicitud",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Solicitud ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update data",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Soft deletes a solicitud by ID and records it in its log, releasing the budget committed by an approved solicitud. It can be restored until the purge removes it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "Delete solicitud",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Solicitud ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/solicitud/{id}/history": {
+            "get": {
+                "description": "Returns the timeline of a solicitud, oldest event first. State snapshots are only included with snapshots=true",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "Solicitud history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Solicitud ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "create",
+                            "update",
+                            "transition",
+                            "line_decision",
+                            "delete",
+                            "restore",
+                            "handover"
+                        ],
+                        "type": "string",
+                        "description": "Event types, comma separated",
+                        "name": "eventType",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User who performed the event",
+                        "name": "userId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "From date (2006-01-02 or RFC3339)",
+                        "name": "desde",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "To date, inclusive (2006-01-02 or RFC3339)",
+                        "name": "hasta",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include previous and new state",
+                        "name": "snapshots",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/solicitud/{id}/lines/{numero}/approve": {
+            "post": {
+                "description": "Approves a single line of a solicitud and derives the solicitud state",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "Approve solicitud line",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Solicitud ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Numero de linea",
+                        "name": "numero",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comentario",
+                        "name": "payload",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Solicitud"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/solicitud/{id}/lines/{numero}/reject": {
+            "post": {
+                "description": "Rejects a single line of a solicitud, a reason is required",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "Reject solicitud line",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Solicitud ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Numero de linea",
+                        "name": "numero",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Motivo del rechazo",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Solicitud"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/solicitud/{id}/restore": {
+            "post": {
+                "description": "Restores a soft deleted solicitud and records it in its log. An approved solicitud commits its budget again",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "Restore solicitud",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Solicitud ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/solicitud/{id}/transitions": {
+            "get": {
+                "description": "Returns the transitions the current user may apply to a solicitud",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "List available transitions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Solicitud ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/solicitud/{id}/transitions/{transition}": {
+            "post": {
+                "description": "Moves a solicitud through the state machine (submit, approve, reject, close)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "solicitudes"
+                ],
+                "summary": "Apply state transition",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Solicitud ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "submit",
+                            "approve",
+                            "reject",
+                            "close"
+                        ],
+                        "type": "string",
+                        "description": "Transition name",
+                        "name": "transition",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Comentario",
+                        "name": "payload",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Solicitud"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/": {
+            "get": {
+                "description": "Returns the soft deleted solicitudes, products or centros de costo, most recently deleted first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "List deleted records",
+                "parameters": [
+                    {
+                        "enum": [
+                            "solicitud",
+                            "product",
+                            "cc"
+                        ],
+                        "type": "string",
+                        "description": "Record type",
+                        "name": "tipo",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page size",
+                        "name": "pageSize",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/trash/purge": {
+            "post": {
+                "description": "Permanently removes the records deleted before the retention window, with their files and price history",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "trash"
+                ],
+                "summary": "Purge deleted records",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Retention days, defaults to SOFT_DELETE_RETENTION_DAYS",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report what would be removed",
+                        "name": "dryRun",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.PurgeReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Creates a new user. With an external AUTH_PROVIDER (usach, ldap) the credentials are verified against it; with the local provider the password is stored",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Register new user",
+                "parameters": [
+                    {
+                        "description": "User info",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.CreateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/by-cc": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get users by centros de costo",
+                "parameters": [
+                    {
+                        "description": "CC IDs",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/email/{email}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{email}": {
+            "put": {
+                "description": "Updates the user. cc and cc_observador are ignored, memberships are managed with /user/{id}/cc/{cc}",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Update user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User info",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Delete user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "deleted",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/cc": {
+            "get": {
+                "description": "Returns the Centros de Costo of the user with the role in each one",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "List user centros de costo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Membership"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{id}/cc/{cc}": {
+            "put": {
+                "description": "Adds the Centro de Costo to the user or changes the user's role in it. The jefe role changes the jefe of the Centro de Costo like PUT /cc/{id}/jefe. The change is recorded in the membership audit",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Add user to centro de costo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Centro de Costo ID",
+                        "name": "cc",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role",
+                        "name": "payload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.SetMembershipRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Membership"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Removes the Centro de Costo from the user, recording it in the membership audit. The jefe cannot be removed until the Centro de Costo has another jefe",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Remove user from centro de costo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Centro de Costo ID",
+                        "name": "cc",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
         "controllers.CreateUserRequest": {
             "type": "object",
             "properties": {
-                "cc": {
+                "cc": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "password": {
+                    "type": "string"
+                },
+                "rut": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ReassignCentroCostoRequest": {
+            "type": "object",
+            "required": [
+                "destino"
+            ],
+            "properties": {
+                "destino": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.ReassignJefeRequest": {
+            "type": "object",
+            "required": [
+                "jefe"
+            ],
+            "properties": {
+                "jefe": {
+                    "type": "string"
+                }
+            }
+        },
+        "controllers.SetMembershipRequest": {
+            "type": "object",
+            "required": [
+                "rol"
+            ],
+            "properties": {
+                "rol": {
+                    "enum": [
+                        "solicitante",
+                        "jefe",
+                        "observador"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MembershipRole"
+                        }
+                    ]
+                }
+            }
+        },
+        "controllers.TransitionRequest": {
+            "type": "object",
+            "properties": {
+                "comentario": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "nombre": {
+                    "type": "string"
+                },
+                "prefijo": {
+                    "description": "Prefijo es el comienzo de la key, para reconocerla en los listados sin exponerla",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "revoked_by": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Role"
+                    }
+                },
+                "usuario": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AppliedRate": {
+            "type": "object",
+            "properties": {
+                "fecha": {
+                    "type": "string"
+                },
+                "moneda": {
+                    "type": "string"
+                },
+                "valor": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.ApprovalPolicy": {
+            "type": "object",
+            "properties": {
+                "cc": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "nombre": {
+                    "type": "string"
+                },
+                "pasos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ApprovalPolicyStep"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ApprovalPolicyStep": {
+            "type": "object",
+            "properties": {
+                "aprobador": {
+                    "description": "para tipo usuario",
+                    "type": "string"
+                },
+                "moneda": {
+                    "description": "moneda de monto_minimo, por defecto la de reporte",
+                    "type": "string"
+                },
+                "monto_minimo": {
+                    "description": "0: siempre se exige",
+                    "type": "number"
+                },
+                "nombre": {
+                    "type": "string"
+                },
+                "rol": {
+                    "description": "para tipo rol",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Role"
+                        }
+                    ]
+                },
+                "tipo": {
+                    "$ref": "#/definitions/models.ApproverType"
+                }
+            }
+        },
+        "models.ApprovalStep": {
+            "type": "object",
+            "properties": {
+                "aprobador": {
+                    "type": "string"
+                },
+                "comentario": {
+                    "type": "string"
+                },
+                "decidido_por": {
+                    "type": "string"
+                },
+                "en_nombre_de": {
+                    "description": "delegante, si se decidió por una delegación",
+                    "type": "string"
+                },
+                "estado": {
+                    "$ref": "#/definitions/models.ApprovalStepState"
+                },
+                "fecha": {
+                    "type": "string"
+                },
+                "nombre": {
+                    "type": "string"
+                },
+                "orden": {
+                    "type": "integer"
+                },
+                "rol": {
+                    "$ref": "#/definitions/models.Role"
+                },
+                "tipo": {
+                    "$ref": "#/definitions/models.ApproverType"
+                }
+            }
+        },
+        "models.ApprovalStepState": {
+            "type": "string",
+            "enum": [
+                "P",
+                "A",
+                "D"
+            ],
+            "x-enum-varnames": [
+                "ApprovalStepPendiente",
+                "ApprovalStepAprobado",
+                "ApprovalStepRechazado"
+            ]
+        },
+        "models.ApproverType": {
+            "type": "string",
+            "enum": [
+                "jefe_cc",
+                "usuario",
+                "rol"
+            ],
+            "x-enum-comments": {
+                "ApproverJefeCC": "el jefe del centro de costo de la solicitud",
+                "ApproverRol": "cualquier usuario con el rol indicado",
+                "ApproverUsuario": "un usuario específico, ej: el director de finanzas"
+            },
+            "x-enum-varnames": [
+                "ApproverJefeCC",
+                "ApproverUsuario",
+                "ApproverRol"
+            ]
+        },
+        "models.Budget": {
+            "type": "object",
+            "properties": {
+                "cc": {
+                    "type": "string"
+                },
+                "comprometido": {
+                    "type": "number"
+                },
+                "consumido": {
+                    "type": "number"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "fecha_fin": {
+                    "type": "string"
+                },
+                "fecha_inicio": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "moneda": {
+                    "type": "string"
+                },
+                "monto": {
+                    "type": "number"
+                },
+                "periodo": {
+                    "description": "etiqueta del período, ej: \"2026\" o \"2026-S1\"",
+                    "type": "string"
+                },
+                "politica": {
+                    "$ref": "#/definitions/models.BudgetPolicy"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.BudgetBalance": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "$ref": "#/definitions/models.Budget"
+                },
+                "disponible": {
+                    "type": "number"
+                },
+                "excedido": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.BudgetCommitment": {
+            "type": "object",
+            "properties": {
+                "budget_id": {
+                    "type": "string"
+                },
+                "estado": {
+                    "type": "string"
+                },
+                "excedido": {
+                    "description": "aprobada sobre el saldo con política advertir",
+                    "type": "boolean"
+                },
+                "fecha": {
+                    "type": "string"
+                },
+                "monto": {
+                    "description": "en la moneda del presupuesto",
+                    "type": "number"
+                }
+            }
+        },
+        "models.BudgetPolicy": {
+            "type": "string",
+            "enum": [
+                "bloquear",
+                "advertir"
+            ],
+            "x-enum-comments": {
+                "BudgetPolicyBlock": "la aprobación se rechaza",
+                "BudgetPolicyFlag": "la aprobación se permite y la solicitud queda marcada"
+            },
+            "x-enum-varnames": [
+                "BudgetPolicyBlock",
+                "BudgetPolicyFlag"
+            ]
+        },
+        "models.CC": {
+            "type": "object",
+            "properties": {
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "jefe": {
+                    "type": "string"
+                },
+                "nombre": {
+                    "type": "string"
+                },
+                "numero": {
+                    "type": "integer"
+                },
+                "padre": {
+                    "description": "centro de costo superior en la estructura, ej: el departamento de una unidad",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CCNode": {
+            "type": "object",
+            "properties": {
+                "cc": {
+                    "$ref": "#/definitions/models.CC"
+                },
+                "hijos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CCNode"
+                    }
+                }
+            }
+        },
+        "models.CCSpend": {
+            "type": "object",
+            "properties": {
+                "aprobado": {
+                    "description": "solicitudes aprobadas (A)",
+                    "type": "number"
+                },
+                "finalizado": {
+                    "description": "solicitudes finalizadas (C)",
+                    "type": "number"
+                },
+                "pendiente": {
+                    "description": "solicitudes en aprobación (P, LA)",
+                    "type": "number"
+                },
+                "solicitudes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CCSpendNode": {
+            "type": "object",
+            "properties": {
+                "cc": {
+                    "$ref": "#/definitions/models.CC"
+                },
+                "hijos": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CCSpendNode"
+                    }
+                },
+                "propio": {
+                    "$ref": "#/definitions/models.CCSpend"
+                },
+                "total": {
+                    "$ref": "#/definitions/models.CCSpend"
+                }
+            }
+        },
+        "models.CreatedAPIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "nombre": {
+                    "type": "string"
+                },
+                "prefijo": {
+                    "description": "Prefijo es el comienzo de la key, para reconocerla en los listados sin exponerla",
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "revoked_by": {
+                    "type": "string"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Role"
+                    }
+                },
+                "usuario": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Delegation": {
+            "type": "object",
+            "properties": {
+                "ccs": {
+                    "description": "vacío: todos los centros de costo",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "string"
+                },
+                "delegado": {
+                    "type": "string"
+                },
+                "delegante": {
+                    "type": "string"
+                },
+                "fecha_fin": {
+                    "type": "string"
+                },
+                "fecha_inicio": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "motivo": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "revoked_by": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExchangeRate": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "fecha": {
+                    "type": "string"
+                },
+                "fuente": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "moneda": {
+                    "type": "string"
+                },
+                "valor": {
+                    "description": "pesos (CLP) por unidad de la moneda",
+                    "type": "number"
+                }
+            }
+        },
+        "models.ImportAction": {
+            "type": "string",
+            "enum": [
+                "created",
+                "updated",
+                "rejected",
+                "unchanged"
+            ],
+            "x-enum-varnames": [
+                "ImportCreated",
+                "ImportUpdated",
+                "ImportRejected",
+                "ImportUnchanged"
+            ]
+        },
+        "models.ImportReport": {
+            "type": "object",
+            "properties": {
+                "actualizados": {
+                    "type": "integer"
+                },
+                "creados": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "filas": {
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/models.ImportRowResult"
                     }
                 },
-                "password": {
-                    "type": "string"
+                "rechazados": {
+                    "type": "integer"
                 },
-                "rut": {
-                    "type": "string"
+                "sin_cambios": {
+                    "type": "integer"
                 },
-                "username": {
-                    "type": "string"
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "models.CC": {
+        "models.ImportRowResult": {
             "type": "object",
             "properties": {
-                "id": {
-                    "type": "string"
+                "accion": {
+                    "$ref": "#/definitions/models.ImportAction"
                 },
-                "jefe": {
+                "clave": {
                     "type": "string"
                 },
-                "nombre": {
-                    "type": "string"
+                "errores": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "numero": {
+                "fila": {
+                    "description": "número de fila en el archivo, incluyendo encabezados",
                     "type": "integer"
+                },
+                "id": {
+                    "type": "string"
                 }
             }
         },
@@ -1042,20 +4220,80 @@ const docTemplate = `{
                 "comentario": {
                     "type": "string"
                 },
+                "decidido_por": {
+                    "type": "string"
+                },
+                "en_nombre_de": {
+                    "description": "delegante, si se decidió por una delegación",
+                    "type": "string"
+                },
+                "estado": {
+                    "$ref": "#/definitions/models.LineState"
+                },
+                "fecha_decision": {
+                    "type": "string"
+                },
                 "importe_linea": {
                     "type": "number"
                 },
+                "motivo_decision": {
+                    "type": "string"
+                },
                 "numero_linea": {
                     "type": "integer"
                 },
+                "precio_unitario": {
+                    "description": "precio del catálogo al momento de crear la línea",
+                    "type": "number"
+                },
                 "product_id": {
                     "type": "string"
                 },
+                "producto": {
+                    "$ref": "#/definitions/models.ProductSnapshot"
+                },
                 "um": {
                     "type": "string"
                 }
             }
         },
+        "models.LineState": {
+            "type": "string",
+            "enum": [
+                "P",
+                "A",
+                "D"
+            ],
+            "x-enum-varnames": [
+                "LineStatePendiente",
+                "LineStateAprobada",
+                "LineStateRechazada"
+            ]
+        },
+        "models.Membership": {
+            "type": "object",
+            "properties": {
+                "cc": {
+                    "type": "string"
+                },
+                "rol": {
+                    "$ref": "#/definitions/models.MembershipRole"
+                }
+            }
+        },
+        "models.MembershipRole": {
+            "type": "string",
+            "enum": [
+                "solicitante",
+                "jefe",
+                "observador"
+            ],
+            "x-enum-varnames": [
+                "MembershipSolicitante",
+                "MembershipJefe",
+                "MembershipObservador"
+            ]
+        },
         "models.Product": {
             "type": "object",
             "properties": {
@@ -1068,6 +4306,12 @@ const docTemplate = `{
                 "convenio_marco": {
                     "type": "string"
                 },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
                 "descripcion": {
                     "type": "string"
                 },
@@ -1095,6 +4339,10 @@ const docTemplate = `{
                 "modelo": {
                     "type": "string"
                 },
+                "moneda": {
+                    "description": "moneda del precio, CLP si no se indica",
+                    "type": "string"
+                },
                 "nombre_proveedor": {
                     "type": "string"
                 },
@@ -1112,26 +4360,88 @@ const docTemplate = `{
                 }
             }
         },
+        "models.ProductSnapshot": {
+            "type": "object",
+            "properties": {
+                "UM": {
+                    "type": "string"
+                },
+                "convenio_marco": {
+                    "type": "string"
+                },
+                "descripcion": {
+                    "type": "string"
+                },
+                "fecha_actualizacion": {
+                    "type": "string"
+                },
+                "id_convenio": {
+                    "type": "string"
+                },
+                "id_product": {
+                    "type": "string"
+                },
+                "marca": {
+                    "type": "string"
+                },
+                "modelo": {
+                    "type": "string"
+                },
+                "moneda": {
+                    "type": "string"
+                },
+                "nombre_proveedor": {
+                    "type": "string"
+                },
+                "precio": {
+                    "type": "number"
+                },
+                "region": {
+                    "type": "string"
+                },
+                "rut_proveedor": {
+                    "type": "string"
+                }
+            }
+        },
         "models.Role": {
             "type": "string",
             "enum": [
                 "Usuario",
-                "Administrador"
+                "Administrador",
+                "Jefe"
             ],
             "x-enum-varnames": [
                 "USER",
-                "ADMIN"
+                "ADMIN",
+                "JEFE"
             ]
         },
         "models.Solicitud": {
             "type": "object",
             "properties": {
+                "aprobaciones": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ApprovalStep"
+                    }
+                },
                 "aprobador": {
                     "type": "string"
                 },
+                "aprobador_actual": {
+                    "description": "aprobador del paso pendiente, se copia del paso para poder filtrar las solicitudes por aprobador",
+                    "type": "string"
+                },
                 "cc": {
                     "type": "string"
                 },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "deleted_by": {
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -1150,6 +4460,10 @@ const docTemplate = `{
                 "id": {
                     "type": "string"
                 },
+                "importe_reporte": {
+                    "description": "importe_total convertido a moneda_reporte",
+                    "type": "number"
+                },
                 "importe_total": {
                     "type": "number"
                 },
@@ -1159,17 +4473,43 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.Line"
                     }
                 },
+                "log_hash": {
+                    "type": "string"
+                },
+                "log_secuencia": {
+                    "description": "secuencia y hash del último log de la cadena, para detectar logs eliminados al final",
+                    "type": "integer"
+                },
                 "moneda": {
                     "type": "string"
                 },
+                "moneda_reporte": {
+                    "type": "string"
+                },
                 "nombre_solicitud": {
                     "type": "string"
                 },
+                "paso_actual": {
+                    "description": "índice en aprobaciones del paso pendiente",
+                    "type": "integer"
+                },
+                "presupuesto": {
+                    "$ref": "#/definitions/models.BudgetCommitment"
+                },
+                "rol_actual": {
+                    "$ref": "#/definitions/models.Role"
+                },
                 "solicitante": {
                     "type": "string"
                 },
                 "state": {
                     "type": "string"
+                },
+                "tipos_cambio": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AppliedRate"
+                    }
                 }
             }
         },
@@ -1185,6 +4525,13 @@ const docTemplate = `{
                         "type": "string"
                     }
                 },
+                "cc_observador": {
+                    "description": "centros de costo de CC en los que el usuario es observador, en el resto es solicitante",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "created_at": {
                     "type": "string"
                 },
@@ -1204,6 +4551,149 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "services.CCDependents": {
+            "type": "object",
+            "properties": {
+                "hijos": {
+                    "description": "centros de costo que lo tienen como padre",
+                    "type": "integer"
+                },
+                "solicitudes_abiertas": {
+                    "type": "integer"
+                },
+                "usuarios": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.CCReassignReport": {
+            "type": "object",
+            "properties": {
+                "destino": {
+                    "type": "string"
+                },
+                "origen": {
+                    "type": "string"
+                },
+                "solicitudes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "usuarios": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "services.ChainIssue": {
+            "type": "object",
+            "properties": {
+                "log_id": {
+                    "type": "string"
+                },
+                "problema": {
+                    "type": "string"
+                },
+                "secuencia": {
+                    "type": "integer"
+                }
+            }
+        },
+        "services.ChainReport": {
+            "type": "object",
+            "properties": {
+                "encadenados": {
+                    "type": "integer"
+                },
+                "problemas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/services.ChainIssue"
+                    }
+                },
+                "request_id": {
+                    "type": "string"
+                },
+                "sin_encadenar": {
+                    "description": "logs anteriores a la cadena, no se pueden verificar",
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "valida": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "services.JefeHandoverReport": {
+            "type": "object",
+            "properties": {
+                "cc": {
+                    "type": "string"
+                },
+                "jefe_anterior": {
+                    "type": "string"
+                },
+                "jefe_nuevo": {
+                    "type": "string"
+                },
+                "solicitudes": {
+                    "description": "solicitudes con pasos traspasados al nuevo jefe",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "services.PurgeReport": {
+            "type": "object",
+            "properties": {
+                "antes": {
+                    "description": "se purgan los eliminados antes de esta fecha",
+                    "type": "string"
+                },
+                "centros_costo": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "centros_costo_conservados": {
+                    "description": "centros de costo eliminados que no se purgan porque todavía los referencian solicitudes",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "errores": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "productos": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "solicitudes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
         }
     }
 }`
@@ -14,8 +14,10 @@ import (
 	"log"
 	"time"
 
+	"catalogo-backend/auth"
 	"catalogo-backend/database"
 	"catalogo-backend/middleware"
+	"catalogo-backend/repositories"
 	"catalogo-backend/routes"
 	"catalogo-backend/utils"
 
@@ -34,6 +36,16 @@ func main() {
 	// Inicializar conexión Mongo
 	database.InitMongo()
 
+	// Índices de las colecciones
+	repositories.EnsureIndexes(context.Background())
+
+	// Proveedor de autenticación del login (AUTH_PROVIDER)
+	provider, err := auth.Current()
+	if err != nil {
+		log.Fatal("Error en la configuración de autenticación: ", err)
+	}
+	log.Println("Proveedor de autenticación:", provider.Name())
+
 	// Desconectar al final
 	defer func() {
 		if err := database.Client.Disconnect(ctx); err != nil {
@@ -42,8 +54,13 @@ func main() {
 	}()
 
 	r := gin.Default()
+	// la IP de los logs de auditoría solo se toma de X-Forwarded-For si viene de un proxy confiable
+	if err := r.SetTrustedProxies(middleware.TrustedProxies()); err != nil {
+		log.Fatal("TRUSTED_PROXIES inválido: ", err)
+	}
 
 	r.Use(middleware.CorsMiddleware())
+	r.Use(middleware.RequestID())
 
 	docs.SwaggerInfo.BasePath = "/"
 
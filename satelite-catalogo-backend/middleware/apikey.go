@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+
+	jwt "github.com/appleboy/gin-jwt/v2"
+	"github.com/gin-gonic/gin"
+)
+
+const apiKeyKey = "api_key"
+
+// Authenticate : funcion tipo middleware que autentica la llamada con la API key del header X-API-Key
+// o, si no viene, con el token jwt. Con una API key el principal queda cargado con los roles de la key
+func Authenticate() gin.HandlerFunc {
+	jwtMiddleware := LoadJWTAuth().MiddlewareFunc()
+	return func(c *gin.Context) {
+		plain := c.GetHeader(models.APIKeyHeader)
+		if plain == "" {
+			jwtMiddleware(c)
+			return
+		}
+
+		key, principal, err := services.AuthenticateAPIKeyService(c.Request.Context(), plain)
+		if errors.Is(err, services.ErrAPIKeyInvalida) {
+			UnauthorizedFunc(c, http.StatusUnauthorized, err.Error())
+			c.Abort()
+			return
+		}
+		if err != nil {
+			UnauthorizedFunc(c, http.StatusInternalServerError, "error al verificar la API key")
+			c.Abort()
+			return
+		}
+
+		// mismas claims que el jwt, para que GetUserID y GetRoles funcionen igual
+		roles := make([]interface{}, 0, len(principal.Roles))
+		for _, r := range principal.Roles {
+			roles = append(roles, string(r))
+		}
+		user := map[string]interface{}{"_id": principal.UserID.Hex(), "username": principal.Username, "role": roles}
+		c.Set("JWT_PAYLOAD", jwt.MapClaims{"user": user, "rol": roles})
+		c.Set(jwt.IdentityKey, user)
+		c.Set(apiKeyKey, key)
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// RequireSession : funcion tipo middleware que rechaza las llamadas autenticadas con API key, para
+// las operaciones que requieren un usuario con sesión iniciada (ej: crear API keys)
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetAPIKey(c); ok {
+			UnauthorizedFunc(c, http.StatusForbidden, "esta operación no está disponible con API key")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetAPIKey retorna la API key con la que se autenticó la llamada
+func GetAPIKey(c *gin.Context) (*models.APIKey, bool) {
+	value, exists := c.Get(apiKeyKey)
+	if !exists {
+		return nil, false
+	}
+	key, ok := value.(*models.APIKey)
+	return key, ok
+}
@@ -1,58 +1,65 @@
 package middleware
 
 import (
-	"bytes"
-	"encoding/base64"
-	"encoding/json"
 	"errors"
-	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
-	"strings"
 	"time"
 
+	"catalogo-backend/auth"
 	"catalogo-backend/models"
 	"catalogo-backend/services"
-	"catalogo-backend/utils"
 
 	jwt "github.com/appleboy/gin-jwt/v2"
 	"github.com/gin-gonic/gin"
-)
-
-// Roles en el sistema, para registrar nuevos roles, hacerlo aca
-const (
-	RoleUser  = "User"
-	RoleAdmin = "Admin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // AuthorizatorFunc : funcion tipo middleware que define si el usuario esta autorizado a utilizar un servicio
 func AuthorizatorFunc(data interface{}, c *gin.Context) bool {
-
-	//Se consiguen los datos entrantes a verificar
-	userData := data.(map[string]interface{})
 	// Se consiguen los roles registrados para la ruta a verificar
 	roles, exists := c.Get("roles")
 	if !exists {
 		return true
 	}
+	// Se consiguen los roles del usuario a verificar
+	userRoles := GetRoles(c)
 	for _, r := range roles.([]models.Role) {
-		//Si el usuario tienea algun rol vinculado a la ruta, se le permite su acceso a ella
-		if userData["role"] == string(r) {
-			return true
+		//Si el usuario tiene algun rol vinculado a la ruta, se le permite su acceso a ella
+		for _, userRole := range userRoles {
+			if userRole == r {
+				return true
+			}
 		}
 	}
 	// En caso contrario, se le deniega el permiso
 	return false
 }
 
+// GetRoles retorna los roles efectivos del usuario autenticado según el principal cargado por
+// LoadPrincipal. No se usan los roles del jwt, que pueden estar desactualizados hasta que expire;
+// sin principal no tiene roles
+func GetRoles(c *gin.Context) []models.Role {
+	if principal, ok := GetPrincipal(c); ok {
+		return principal.Roles
+	}
+	return nil
+}
+
 // SetRoles : funcion tipo middleware que define los roles que pueden realizar la siguiente funcion
 // Se implementa sobre las rutas para definir que rol puede ocupar el servicio
 func SetRoles(roles ...models.Role) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Set example variable
 		c.Set("roles", roles)
-		// before request
+		// El middleware de jwt ya validó el token, aca se verifica el rol con AuthorizatorFunc
+		identity, _ := c.Get(jwt.IdentityKey)
+		if !AuthorizatorFunc(identity, c) {
+			UnauthorizedFunc(c, http.StatusForbidden, "no tiene permisos para realizar esta acción")
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -85,93 +92,64 @@ func IdentityHandlerFunc(c *gin.Context) interface{} {
 	return jwtClaims["user"] //Retrona la claim registrada para usuario en payload.
 }
 
-// Función que permite hacer login en la aplicación y conseguir un token jwt
+// GetUserID retorna el ID del usuario autenticado a partir de las claims del jwt
+func GetUserID(c *gin.Context) (primitive.ObjectID, error) {
+	user, ok := IdentityHandlerFunc(c).(map[string]interface{})
+	if !ok {
+		return primitive.NilObjectID, errors.New("usuario no autenticado")
+	}
+	id, _ := user["_id"].(string)
+	return primitive.ObjectIDFromHex(id)
+}
+
+// UserLoader busca el usuario del login por su email, las pruebas lo reemplazan para no depender de la
+// base de datos
+var UserLoader = services.GetUserByEmailService
+
+// Función que permite hacer login en la aplicación y conseguir un token jwt. Las credenciales se
+// verifican con el proveedor de auth.Current y el usuario debe estar registrado con el email verificado
 func LoginFunc(c *gin.Context) (interface{}, error) {
 	var loginValues models.Login
 	// Se asocian los valores entrantes por contexto al modelo de Login creado
 	if err := c.ShouldBind(&loginValues); err != nil {
 		return "", jwt.ErrMissingLoginValues
 	}
-	username := loginValues.User
-	password := loginValues.Password
-
-	username = strings.ReplaceAll(username, "@usach.cl", "")
 
-	//Verificar si el usuario existe en la base de datos
-	user, err := services.GetUserByEmailService(username + "@usach.cl")
+	provider, err := auth.Current()
 	if err != nil {
 		return models.User{}, err
 	}
-
-	response, err := RequestLogin(username, password)
+	identity, err := provider.Authenticate(c.Request.Context(), loginValues.User, loginValues.Password)
 	if err != nil {
 		return models.User{}, err
 	}
 
-	responseBody, err := io.ReadAll(response.Body)
+	//Verificar si el usuario existe en la base de datos
+	user, err := UserLoader(identity.Email)
 	if err != nil {
 		return models.User{}, err
 	}
-
-	//Verificar la respuesta de la API de autenticacion
-	if response.StatusCode != 200 && password != "gest-password" {
-		var errorMessage map[string]string
-		err = json.Unmarshal(responseBody, &errorMessage)
-		if err != nil {
-			return models.User{}, errors.New("error al obtener el error")
-		}
-
-		return models.User{}, errors.New(errorMessage["message"])
-	}
-
-	var responseLogin models.ResponseLogin
-	err = json.Unmarshal(responseBody, &responseLogin)
-	if err != nil {
-		return nil, jwt.ErrFailedAuthentication
-	}
-	//rut := responseLogin.Data["rut"].(string)
 	//Retorna al usuario
 	c.Set("user", user)
 	return user, nil
 }
 
-func RequestLogin(username string, password string) (*http.Response, error) {
-	//Realizar una solicitud a la API de autenticacion para verificar si las credenciales del usuario son validas
-	hashPassword := utils.HashPassword(password)
-	body := models.Login{
-		User:     username,
-		Password: hashPassword,
-	}
-	jsonData, err := json.Marshal(body)
-	if err != nil {
-		return nil, err
-	}
-
-	request, err := http.NewRequest("POST", os.Getenv("API_AUTH_URL"), bytes.NewBuffer(jsonData))
+// SSOLoginResponse responde el inicio de sesión único con el mismo token jwt de /auth/login. Si
+// SSO_FRONTEND_URL está configurada redirige al frontend con el token en el fragmento de la URL, si
+// no responde como LoginResponse
+func SSOLoginResponse(c *gin.Context, user models.User) {
+	token, expire, err := LoadJWTAuth().TokenGenerator(user)
 	if err != nil {
-
-		return nil, err
-
+		UnauthorizedFunc(c, http.StatusInternalServerError, "error al generar el token")
+		return
 	}
-	//Se agregan los headers a la solicitud
-	request.Header.Set("Authorization", GetBasicAuth())
-	request.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	response, err := client.Do(request)
-	if err != nil {
-		return nil, err
-
+	if frontend := auth.FrontendURL(); frontend != "" {
+		fragment := url.Values{"token": {token}, "expire": {expire.Format(time.RFC3339)}}
+		c.Redirect(http.StatusFound, frontend+"#"+fragment.Encode())
+		return
 	}
-
-	return response, nil
-}
-
-func GetBasicAuth() string {
-	username := os.Getenv("API_AUTH_USER")
-	password := os.Getenv("API_AUTH_PASS")
-	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
-
+	c.Set("user", user)
+	LoginResponse(c, http.StatusOK, token, expire)
 }
 
 func LoginResponse(c *gin.Context, code int, token string, expire time.Time) {
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"catalogo-backend/auth"
+	"catalogo-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// loginRouter instala un proveedor Fake y usuarios registrados en memoria en vez de la base de datos
+func loginRouter(t *testing.T, passwords map[string]string, registrados ...models.User) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("AUTH_EMAIL_DOMAIN", "usach.cl")
+
+	anterior, _ := auth.Current()
+	auth.SetProvider(auth.NewFake(passwords))
+	t.Cleanup(func() { auth.SetProvider(anterior) })
+
+	anteriorLoader := UserLoader
+	UserLoader = func(email string) (models.User, error) {
+		for _, u := range registrados {
+			if u.Email == email {
+				return u, nil
+			}
+		}
+		return models.User{}, fmt.Errorf("usuario no encontrado con el email: %s", email)
+	}
+	t.Cleanup(func() { UserLoader = anteriorLoader })
+
+	router := gin.New()
+	router.POST("/auth/login", LoadJWTAuth().LoginHandler)
+	return router
+}
+
+func login(router *gin.Engine, user, password string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(models.Login{User: user, Password: password})
+	req := httptest.NewRequest(http.MethodPost, "/auth/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestLoginConProveedorConfigurado(t *testing.T) {
+	usuario := models.User{ID: primitive.NewObjectID(), Username: "ana", Email: "ana@usach.cl", Role: []models.Role{models.USER}}
+	router := loginRouter(t, map[string]string{"ana": "secreta"}, usuario)
+
+	// el usuario puede indicarse con o sin el dominio
+	for _, user := range []string{"ana", "ana@usach.cl"} {
+		w := login(router, user, "secreta")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d, se esperaba 200: %s", user, w.Code, w.Body)
+		}
+		var response struct {
+			Token string      `json:"token"`
+			User  models.User `json:"user"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Token == "" || response.User.ID != usuario.ID {
+			t.Errorf("%s: respuesta inesperada: %s", user, w.Body)
+		}
+	}
+}
+
+func TestLoginRechazado(t *testing.T) {
+	registrado := models.User{ID: primitive.NewObjectID(), Username: "ana", Email: "ana@usach.cl"}
+	router := loginRouter(t, map[string]string{"ana": "secreta", "beto": "secreta"}, registrado)
+
+	casos := []struct {
+		nombre, user, password string
+	}{
+		{"contraseña incorrecta", "ana", "otra"},
+		{"usuario desconocido para el proveedor", "carla", "secreta"},
+		{"verificado pero no registrado", "beto", "secreta"},
+		{"sin contraseña", "ana", ""},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if w := login(router, c.user, c.password); w.Code != http.StatusUnauthorized {
+				t.Errorf("status %d, se esperaba 401: %s", w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -13,8 +13,8 @@ func CorsMiddleware() gin.HandlerFunc {
 	config := cors.DefaultConfig()
 
 	config.AllowMethods = append(config.AllowMethods, "DELETE", "OPTIONS", "POST", "GET", "PUT")
-	config.AllowHeaders = append(config.AllowHeaders, "Authorization", "Pagination-Count")
-	config.ExposeHeaders = append(config.ExposeHeaders, "Pagination-Count")
+	config.AllowHeaders = append(config.AllowHeaders, "Authorization", "Pagination-Count", RequestIDHeader)
+	config.ExposeHeaders = append(config.ExposeHeaders, "Pagination-Count", RequestIDHeader)
 	config.AllowOrigins = strings.Split(os.Getenv("CORS_URLS"), ",")
 	//config.AllowAllOrigins = true
 	config.AllowCredentials = false
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"catalogo-backend/models"
+	"catalogo-backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const principalKey = "principal"
+
+// PrincipalLoader resuelve el principal del usuario autenticado, las pruebas lo reemplazan para no
+// depender de la base de datos
+var PrincipalLoader = services.GetPrincipalService
+
+// LoadPrincipal : funcion tipo middleware que resuelve el usuario del jwt en un principal del servidor.
+// Debe ir después de Authenticate, que ya carga el principal de las llamadas con API key
+func LoadPrincipal() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetPrincipal(c); ok {
+			c.Next()
+			return
+		}
+		userID, err := GetUserID(c)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "usuario no autenticado"})
+			return
+		}
+		principal, err := PrincipalLoader(userID)
+		if errors.Is(err, services.ErrUsuarioNoEncontrado) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "usuario no encontrado"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": "error al cargar el usuario"})
+			return
+		}
+		c.Set(principalKey, principal)
+		c.Next()
+	}
+}
+
+// GetPrincipal retorna el principal cargado por LoadPrincipal
+func GetPrincipal(c *gin.Context) (*models.Principal, bool) {
+	value, exists := c.Get(principalKey)
+	if !exists {
+		return nil, false
+	}
+	principal, ok := value.(*models.Principal)
+	return principal, ok
+}
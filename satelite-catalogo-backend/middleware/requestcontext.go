@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"os"
+	"strings"
+
+	"catalogo-backend/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader es el header con el ID de correlación de la llamada, si el cliente no lo envía se genera
+const RequestIDHeader = "X-Request-ID"
+
+const requestIDKey = "request_id"
+
+// RequestID : funcion tipo middleware que asigna a cada llamada un ID de correlación y lo retorna
+// en la respuesta
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// RequestContext retorna el contexto de la llamada con el usuario autenticado, la IP, el user agent
+// y el ID de correlación, para pasarlo a los servicios que registran logs
+func RequestContext(c *gin.Context) context.Context {
+	info := utils.RequestInfo{
+		IP:            c.ClientIP(),
+		UserAgent:     c.Request.UserAgent(),
+		CorrelationID: c.GetString(requestIDKey),
+	}
+	if principal, ok := GetPrincipal(c); ok {
+		info.UserID = principal.UserID
+	} else if userID, err := GetUserID(c); err == nil {
+		info.UserID = userID
+	}
+	return utils.WithRequestInfo(c.Request.Context(), info)
+}
+
+// TrustedProxies retorna los proxies (IPs o CIDR) de TRUSTED_PROXIES, separados por coma, de los que
+// se acepta X-Forwarded-For para la IP del cliente que queda en los logs y X-Forwarded-Proto para las
+// cookies del SSO. Por defecto ninguno: se usa la conexión, que el cliente no puede falsificar
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// IsHTTPS indica si la llamada llegó por https, directamente o con X-Forwarded-Proto enviado por uno
+// de los TrustedProxies. El header de cualquier otro origen se ignora
+func IsHTTPS(c *gin.Context) bool {
+	if c.Request.TLS != nil {
+		return true
+	}
+	if c.GetHeader("X-Forwarded-Proto") != "https" {
+		return false
+	}
+	ip := net.ParseIP(c.RemoteIP())
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range TrustedProxies() {
+		if _, cidr, err := net.ParseCIDR(proxy); err == nil {
+			if cidr.Contains(ip) {
+				return true
+			}
+		} else if net.ParseIP(proxy).Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIsHTTPSSoloConfiaEnProxiesConfigurados(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1, 192.168.0.0/16")
+	casos := []struct {
+		nombre     string
+		remoteAddr string
+		proto      string
+		tls        bool
+		esperado   bool
+	}{
+		{"conexión tls", "203.0.113.5:1234", "", true, true},
+		{"proxy confiable", "10.0.0.1:1234", "https", false, true},
+		{"proxy en rango confiable", "192.168.1.20:1234", "https", false, true},
+		{"header del cliente", "203.0.113.5:1234", "https", false, false},
+		{"proxy confiable con http", "10.0.0.1:1234", "http", false, false},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/auth/oidc/login", nil)
+			ctx.Request.RemoteAddr = c.remoteAddr
+			if c.proto != "" {
+				ctx.Request.Header.Set("X-Forwarded-Proto", c.proto)
+			}
+			if c.tls {
+				ctx.Request.TLS = &tls.ConnectionState{}
+			}
+			if got := IsHTTPS(ctx); got != c.esperado {
+				t.Errorf("IsHTTPS = %v, se esperaba %v", got, c.esperado)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// APIKeyHeader es el header con el que las integraciones y cuentas de servicio envían su API key
+// en vez del token jwt
+const APIKeyHeader = "X-API-Key"
+
+// APIKey da acceso no interactivo a la API en nombre de un usuario, limitado a los roles de la
+// key. Solo se guarda el hash SHA-256 de la key, que se muestra una única vez al crearla.
+// Colección api_keys
+type APIKey struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Nombre  string             `bson:"nombre" json:"nombre"`
+	Usuario primitive.ObjectID `bson:"usuario" json:"usuario"`
+	// Prefijo es el comienzo de la key, para reconocerla en los listados sin exponerla
+	Prefijo    string             `bson:"prefijo" json:"prefijo"`
+	Hash       string             `bson:"hash" json:"-"`
+	Roles      []Role             `bson:"roles" json:"roles"`
+	ExpiresAt  time.Time          `bson:"expires_at" json:"expires_at"`
+	CreatedBy  primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedBy  primitive.ObjectID `bson:"revoked_by,omitempty" json:"revoked_by,omitempty"`
+	RevokedAt  *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// IsActive indica si la key no fue revocada ni expiró en la fecha
+func (k *APIKey) IsActive(fecha time.Time) bool {
+	return k.RevokedAt == nil && fecha.Before(k.ExpiresAt)
+}
+
+// CreatedAPIKey es la key recién creada, Key es el único momento en que se entrega en texto plano
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
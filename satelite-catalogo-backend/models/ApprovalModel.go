@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ApproverType indica cómo se resuelve el aprobador de un paso
+type ApproverType string
+
+const (
+	ApproverJefeCC  ApproverType = "jefe_cc" // el jefe del centro de costo de la solicitud
+	ApproverUsuario ApproverType = "usuario" // un usuario específico, ej: el director de finanzas
+	ApproverRol     ApproverType = "rol"     // cualquier usuario con el rol indicado
+)
+
+// ApprovalPolicy define la cadena de aprobación de un centro de costo, colección approval_policies.
+// La política sin centro de costo es la global y se usa para los centros que no tienen una propia
+type ApprovalPolicy struct {
+	ID        primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	CC        primitive.ObjectID   `bson:"cc,omitempty" json:"cc,omitempty"`
+	Nombre    string               `bson:"nombre" json:"nombre"`
+	Pasos     []ApprovalPolicyStep `bson:"pasos" json:"pasos"`
+	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+// IsGlobal indica si la política aplica a todos los centros de costo sin política propia
+func (p *ApprovalPolicy) IsGlobal() bool {
+	return p.CC.IsZero()
+}
+
+// ApprovalPolicyStep es un paso de la política, se exige cuando el importe supera MontoMinimo
+type ApprovalPolicyStep struct {
+	Nombre      string             `bson:"nombre" json:"nombre"`
+	Tipo        ApproverType       `bson:"tipo" json:"tipo"`
+	Aprobador   primitive.ObjectID `bson:"aprobador,omitempty" json:"aprobador,omitempty"` // para tipo usuario
+	Rol         Role               `bson:"rol,omitempty" json:"rol,omitempty"`             // para tipo rol
+	MontoMinimo float64            `bson:"monto_minimo" json:"monto_minimo"`               // 0: siempre se exige
+	Moneda      string             `bson:"moneda,omitempty" json:"moneda,omitempty"`       // moneda de monto_minimo, por defecto la de reporte
+}
+
+// ApprovalStepState es el estado de un paso de aprobación de la solicitud
+type ApprovalStepState string
+
+const (
+	ApprovalStepPendiente ApprovalStepState = "P"
+	ApprovalStepAprobado  ApprovalStepState = "A"
+	ApprovalStepRechazado ApprovalStepState = "D"
+)
+
+// ApprovalStep es un paso de la cadena de aprobación de una solicitud, con el aprobador ya resuelto
+type ApprovalStep struct {
+	Orden       int                `bson:"orden" json:"orden"`
+	Nombre      string             `bson:"nombre" json:"nombre"`
+	Tipo        ApproverType       `bson:"tipo" json:"tipo"`
+	Aprobador   primitive.ObjectID `bson:"aprobador,omitempty" json:"aprobador,omitempty"`
+	Rol         Role               `bson:"rol,omitempty" json:"rol,omitempty"`
+	Estado      ApprovalStepState  `bson:"estado" json:"estado"`
+	DecididoPor primitive.ObjectID `bson:"decidido_por,omitempty" json:"decidido_por,omitempty"`
+	EnNombreDe  primitive.ObjectID `bson:"en_nombre_de,omitempty" json:"en_nombre_de,omitempty"` // delegante, si se decidió por una delegación
+	Fecha       *time.Time         `bson:"fecha,omitempty" json:"fecha,omitempty"`
+	Comentario  string             `bson:"comentario,omitempty" json:"comentario,omitempty"`
+}
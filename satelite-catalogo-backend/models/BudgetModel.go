@@ -0,0 +1,71 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BudgetPolicy indica qué hacer cuando una aprobación excede el saldo del presupuesto
+type BudgetPolicy string
+
+const (
+	BudgetPolicyBlock BudgetPolicy = "bloquear" // la aprobación se rechaza
+	BudgetPolicyFlag  BudgetPolicy = "advertir" // la aprobación se permite y la solicitud queda marcada
+)
+
+// Estados del compromiso de presupuesto de una solicitud
+const (
+	BudgetCommitted = "comprometido"
+	BudgetConsumed  = "consumido"
+	BudgetReleased  = "liberado" // la solicitud aprobada se eliminó, se vuelve a comprometer al restaurarla
+)
+
+// Budget es el presupuesto de un centro de costo para un período, colección budgets.
+// Comprometido suma las solicitudes aprobadas y Consumido las finalizadas
+type Budget struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CC           primitive.ObjectID `bson:"cc" json:"cc"`
+	Periodo      string             `bson:"periodo" json:"periodo"` // etiqueta del período, ej: "2026" o "2026-S1"
+	FechaInicio  time.Time          `bson:"fecha_inicio" json:"fecha_inicio"`
+	FechaFin     time.Time          `bson:"fecha_fin" json:"fecha_fin"`
+	Moneda       string             `bson:"moneda" json:"moneda"`
+	Monto        float64            `bson:"monto" json:"monto"`
+	Comprometido float64            `bson:"comprometido" json:"comprometido"`
+	Consumido    float64            `bson:"consumido" json:"consumido"`
+	Politica     BudgetPolicy       `bson:"politica" json:"politica"`
+	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Disponible retorna el saldo que queda por comprometer
+func (b *Budget) Disponible() float64 {
+	return b.Monto - b.Comprometido - b.Consumido
+}
+
+// Contains indica si la fecha cae dentro del período del presupuesto
+func (b *Budget) Contains(fecha time.Time) bool {
+	return !fecha.Before(b.FechaInicio) && !fecha.After(b.FechaFin)
+}
+
+// BudgetBalance es el saldo de un presupuesto
+type BudgetBalance struct {
+	Budget     *Budget `json:"budget"`
+	Disponible float64 `json:"disponible"`
+	Excedido   bool    `json:"excedido"`
+}
+
+func NewBudgetBalance(b *Budget) *BudgetBalance {
+	disponible := math.Round(b.Disponible()*100) / 100
+	return &BudgetBalance{Budget: b, Disponible: disponible, Excedido: disponible < 0}
+}
+
+// BudgetCommitment es el monto de la solicitud imputado a un presupuesto al aprobarse
+type BudgetCommitment struct {
+	BudgetID primitive.ObjectID `bson:"budget_id" json:"budget_id"`
+	Monto    float64            `bson:"monto" json:"monto"` // en la moneda del presupuesto
+	Estado   string             `bson:"estado" json:"estado"`
+	Excedido bool               `bson:"excedido,omitempty" json:"excedido,omitempty"` // aprobada sobre el saldo con política advertir
+	Fecha    time.Time          `bson:"fecha" json:"fecha"`
+}
@@ -9,4 +9,38 @@ type CC struct {
 	Numero int                `bson:"numero,omitempty" json:"numero"`
 	Nombre string             `bson:"nombre" json:"nombre"`
 	Jefe   primitive.ObjectID `bson:"jefe,omitempty" json:"jefe,omitempty"`
+	// centro de costo superior en la estructura, ej: el departamento de una unidad
+	Padre primitive.ObjectID `bson:"padre,omitempty" json:"padre,omitempty"`
+
+	SoftDelete `bson:",inline"`
+}
+
+// CCNode es un centro de costo con sus descendientes
+type CCNode struct {
+	CC    *CC       `json:"cc"`
+	Hijos []*CCNode `json:"hijos"`
+}
+
+// CCSpend suma los importes de las solicitudes de un centro de costo en la moneda de reporte
+type CCSpend struct {
+	Pendiente   float64 `json:"pendiente"`  // solicitudes en aprobación (P, LA)
+	Aprobado    float64 `json:"aprobado"`   // solicitudes aprobadas (A)
+	Finalizado  float64 `json:"finalizado"` // solicitudes finalizadas (C)
+	Solicitudes int64   `json:"solicitudes"`
+}
+
+// Add suma otro gasto a este
+func (s *CCSpend) Add(o CCSpend) {
+	s.Pendiente += o.Pendiente
+	s.Aprobado += o.Aprobado
+	s.Finalizado += o.Finalizado
+	s.Solicitudes += o.Solicitudes
+}
+
+// CCSpendNode es el gasto de un centro de costo: el propio y el total con sus descendientes
+type CCSpendNode struct {
+	CC     *CC            `json:"cc"`
+	Propio CCSpend        `json:"propio"`
+	Total  CCSpend        `json:"total"`
+	Hijos  []*CCSpendNode `json:"hijos"`
 }
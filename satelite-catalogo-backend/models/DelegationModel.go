@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Delegation traspasa la autoridad de aprobación del delegante al delegado durante un rango de
+// fechas, opcionalmente solo para algunos centros de costo. Colección delegations
+type Delegation struct {
+	ID          primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Delegante   primitive.ObjectID   `bson:"delegante" json:"delegante"`
+	Delegado    primitive.ObjectID   `bson:"delegado" json:"delegado"`
+	FechaInicio time.Time            `bson:"fecha_inicio" json:"fecha_inicio"`
+	FechaFin    time.Time            `bson:"fecha_fin" json:"fecha_fin"`
+	CCs         []primitive.ObjectID `bson:"ccs,omitempty" json:"ccs,omitempty"` // vacío: todos los centros de costo
+	Motivo      string               `bson:"motivo,omitempty" json:"motivo,omitempty"`
+	CreatedBy   primitive.ObjectID   `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time            `bson:"created_at" json:"created_at"`
+	RevokedBy   primitive.ObjectID   `bson:"revoked_by,omitempty" json:"revoked_by,omitempty"`
+	RevokedAt   *time.Time           `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// IsActive indica si la delegación está vigente en la fecha
+func (d *Delegation) IsActive(fecha time.Time) bool {
+	return d.RevokedAt == nil && !fecha.Before(d.FechaInicio) && !fecha.After(d.FechaFin)
+}
+
+// ActiveDelegation es una delegación vigente recibida por el usuario autenticado, con los
+// centros de costo que dirige el delegante ya resueltos
+type ActiveDelegation struct {
+	ID        primitive.ObjectID   `json:"id"`
+	Delegante primitive.ObjectID   `json:"delegante"`
+	CCs       []primitive.ObjectID `json:"ccs,omitempty"`
+	JefeDe    []primitive.ObjectID `json:"jefe_de"` // centros de costo del delegante cubiertos por la delegación
+}
+
+// Covers indica si la delegación aplica a las solicitudes del centro de costo
+func (d ActiveDelegation) Covers(cc primitive.ObjectID) bool {
+	return len(d.CCs) == 0 || containsID(d.CCs, cc)
+}
+
+// LeadsCC indica si la delegación cubre la jefatura del delegante sobre el centro de costo
+func (d ActiveDelegation) LeadsCC(cc primitive.ObjectID) bool {
+	return containsID(d.JefeDe, cc)
+}
+
+// Scope restringe el filtro a los centros de costo cubiertos por la delegación
+func (d ActiveDelegation) Scope(filter bson.M) bson.M {
+	if len(d.CCs) > 0 {
+		filter["cc"] = bson.M{"$in": d.CCs}
+	}
+	return filter
+}
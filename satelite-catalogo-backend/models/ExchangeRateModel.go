@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Monedas soportadas, los tipos de cambio se expresan en pesos (CLP) por unidad
+const (
+	CLP = "CLP"
+	UF  = "UF"
+	USD = "USD"
+	EUR = "EUR"
+)
+
+var SupportedCurrencies = []string{CLP, UF, USD, EUR}
+
+// IsSupportedCurrency indica si la moneda está soportada
+func IsSupportedCurrency(moneda string) bool {
+	for _, m := range SupportedCurrencies {
+		if m == moneda {
+			return true
+		}
+	}
+	return false
+}
+
+// ExchangeRate es el valor diario de una moneda en pesos, colección exchange_rates
+type ExchangeRate struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Moneda    string             `bson:"moneda" json:"moneda"`
+	Fecha     time.Time          `bson:"fecha" json:"fecha"`
+	Valor     float64            `bson:"valor" json:"valor"` // pesos (CLP) por unidad de la moneda
+	Fuente    string             `bson:"fuente,omitempty" json:"fuente,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// AppliedRate es el tipo de cambio usado en un cálculo, se guarda en la solicitud para que
+// los reportes históricos sean reproducibles
+type AppliedRate struct {
+	Moneda string    `bson:"moneda" json:"moneda"`
+	Fecha  time.Time `bson:"fecha" json:"fecha"`
+	Valor  float64   `bson:"valor" json:"valor"`
+}
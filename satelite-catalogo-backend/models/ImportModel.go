@@ -0,0 +1,48 @@
+package models
+
+// ImportAction es el resultado de procesar una fila de una importación masiva
+type ImportAction string
+
+const (
+	ImportCreated  ImportAction = "created"
+	ImportUpdated  ImportAction = "updated"
+	ImportRejected ImportAction = "rejected"
+	// la fila coincide con lo que ya está guardado
+	ImportUnchanged ImportAction = "unchanged"
+)
+
+// ImportRowResult es el detalle de una fila de la importación
+type ImportRowResult struct {
+	Fila    int          `json:"fila"` // número de fila en el archivo, incluyendo encabezados
+	Clave   string       `json:"clave,omitempty"`
+	Accion  ImportAction `json:"accion"`
+	ID      string       `json:"id,omitempty"`
+	Errores []string     `json:"errores,omitempty"`
+}
+
+// ImportReport resume una importación masiva, en modo dry-run no se escribe en la base de datos
+type ImportReport struct {
+	DryRun       bool              `json:"dry_run"`
+	Total        int               `json:"total"`
+	Creados      int               `json:"creados"`
+	Actualizados int               `json:"actualizados"`
+	Rechazados   int               `json:"rechazados"`
+	SinCambios   int               `json:"sin_cambios"`
+	Filas        []ImportRowResult `json:"filas"`
+}
+
+// Add agrega el resultado de una fila y actualiza los contadores
+func (r *ImportReport) Add(row ImportRowResult) {
+	r.Total++
+	switch row.Accion {
+	case ImportCreated:
+		r.Creados++
+	case ImportUpdated:
+		r.Actualizados++
+	case ImportRejected:
+		r.Rechazados++
+	case ImportUnchanged:
+		r.SinCambios++
+	}
+	r.Filas = append(r.Filas, row)
+}
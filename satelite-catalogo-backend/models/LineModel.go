@@ -1,14 +1,46 @@
 package models
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// LineState es el estado de aprobación de una línea de la solicitud
+type LineState string
+
+const (
+	LineStatePendiente LineState = "P"
+	LineStateAprobada  LineState = "A"
+	LineStateRechazada LineState = "D"
+)
+
 type Line struct {
-	NumeroLinea int                `bson:"numero_linea" json:"numero_linea"`
-	ProductID   primitive.ObjectID `bson:"product_id" json:"product_id"`
-	Cantidad    int                `bson:"cantidad" json:"cantidad"`
-	Importe     float64            `bson:"importe_linea" json:"importe_linea"`
-	UM          string             `bson:"um" json:"um"`
-	Comentario  string             `bson:"comentario" json:"comentario"`
+	NumeroLinea    int                `bson:"numero_linea" json:"numero_linea"`
+	ProductID      primitive.ObjectID `bson:"product_id" json:"product_id"`
+	Cantidad       int                `bson:"cantidad" json:"cantidad"`
+	Importe        float64            `bson:"importe_linea" json:"importe_linea"`
+	UM             string             `bson:"um" json:"um"`
+	Comentario     string             `bson:"comentario" json:"comentario"`
+	PrecioUnitario float64            `bson:"precio_unitario" json:"precio_unitario"` // precio del catálogo al momento de crear la línea
+	Producto       *ProductSnapshot   `bson:"producto,omitempty" json:"producto,omitempty"`
+	Estado         LineState          `bson:"estado,omitempty" json:"estado,omitempty"`
+	FechaDecision  *time.Time         `bson:"fecha_decision,omitempty" json:"fecha_decision,omitempty"`
+	DecididoPor    primitive.ObjectID `bson:"decidido_por,omitempty" json:"decidido_por,omitempty"`
+	EnNombreDe     primitive.ObjectID `bson:"en_nombre_de,omitempty" json:"en_nombre_de,omitempty"` // delegante, si se decidió por una delegación
+	MotivoDecision string             `bson:"motivo_decision,omitempty" json:"motivo_decision,omitempty"`
+}
+
+// IsDecided indica si la línea ya fue aprobada o rechazada
+func (l Line) IsDecided() bool {
+	return l.Estado == LineStateAprobada || l.Estado == LineStateRechazada
+}
+
+// ResetDecision deja la línea pendiente, las decisiones solo se registran mediante /solicitud/:id/lines
+func (l *Line) ResetDecision() {
+	l.Estado = LineStatePendiente
+	l.FechaDecision = nil
+	l.DecididoPor = primitive.NilObjectID
+	l.EnNombreDe = primitive.NilObjectID
+	l.MotivoDecision = ""
 }
@@ -6,13 +6,66 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// LogEventType clasifica los eventos registrados en RequestLog
+type LogEventType string
+
+const (
+	LogEventCreate       LogEventType = "create"
+	LogEventUpdate       LogEventType = "update"
+	LogEventTransition   LogEventType = "transition"
+	LogEventLineDecision LogEventType = "line_decision"
+	LogEventDelete       LogEventType = "delete"
+	LogEventRestore      LogEventType = "restore"
+	LogEventHandover     LogEventType = "handover" // traspaso de la aprobación al nuevo jefe del centro de costo
+)
+
+// LogEventTypes son los tipos de evento que se pueden consultar en la auditoría
+var LogEventTypes = []LogEventType{LogEventCreate, LogEventUpdate, LogEventTransition, LogEventLineDecision, LogEventDelete, LogEventRestore, LogEventHandover}
+
+// IsLogEventType indica si el tipo de evento existe
+func IsLogEventType(t LogEventType) bool {
+	for _, e := range LogEventTypes {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
 type RequestLog struct {
 	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
 	RequestID     primitive.ObjectID `json:"request_id" bson:"request_id"`                             // ID del RequestModel asociado
 	Timestamp     time.Time          `json:"timestamp" bson:"timestamp"`                               // Fecha y hora del evento
-	EventType     string             `json:"event_type" bson:"event_type"`                             // Tipo de evento (creación, actualización, cambio de estado, validación, error, etc.)
+	EventType     LogEventType       `json:"event_type" bson:"event_type"`                             // Tipo de evento (creación, actualización, cambio de estado, validación, error, etc.)
 	Description   string             `json:"description" bson:"description"`                           // Descripción detallada del evento
-	PreviousState *Solicitud         `json:"previous_state,omitempty" bson:"previous_state,omitempty"` // (opcional) Estado previo de la solicitud (para cambios importantes)
-	NewState      *Solicitud         `json:"new_state,omitempty" bson:"new_state,omitempty"`           // (opcional) Nuevo estado de la solicitud (para cambios importantes)
-	UserID        primitive.ObjectID `json:"user_id" bson:"user_id,omitempty"`                         // (opcional) Usuario que realizó el cambio (analista, solicitante, sistema, etc.)
+	PreviousState *Solicitud         `json:"previous_state,omitempty" bson:"previous_state,omitempty"` // (opcional) Estado previo de la solicitud, solo en logs anteriores a cambios
+	NewState      *Solicitud         `json:"new_state,omitempty" bson:"new_state,omitempty"`           // (opcional) Estado de la solicitud al crearla, o en logs anteriores a cambios
+	Cambios       []FieldChange      `json:"cambios,omitempty" bson:"cambios,omitempty"`               // Campos que cambiaron respecto del estado previo
+	Secuencia     int64              `json:"secuencia,omitempty" bson:"secuencia,omitempty"`           // Posición del log en la cadena de la solicitud, desde 1
+	PrevHash      string             `json:"prev_hash,omitempty" bson:"prev_hash,omitempty"`           // Hash del log anterior de la solicitud, vacío en el primero
+	Hash          string             `json:"hash,omitempty" bson:"hash,omitempty"`                     // HMAC-SHA256 (LOG_CHAIN_KEY) del log guardado sin este campo
+	UserID        primitive.ObjectID `json:"user_id" bson:"user_id,omitempty"`                         // Usuario autenticado que hizo la llamada, vacío para procesos sin usuario (ej: la CLI)
+	CC            primitive.ObjectID `json:"cc,omitempty" bson:"cc,omitempty"`                         // Centro de costo de la solicitud, para buscar en la auditoría sin leer los estados
+	IP            string             `json:"ip,omitempty" bson:"ip,omitempty"`                         // IP del cliente que hizo la llamada
+	UserAgent     string             `json:"user_agent,omitempty" bson:"user_agent,omitempty"`         // User agent del cliente que hizo la llamada
+	CorrelationID string             `json:"correlation_id,omitempty" bson:"correlation_id,omitempty"` // ID de correlación de la llamada (header X-Request-ID)
+	Transition    string             `json:"transition,omitempty" bson:"transition,omitempty"`         // (solo transiciones) Nombre de la transición ejecutada
+	FromState     string             `json:"from_state,omitempty" bson:"from_state,omitempty"`         // (solo transiciones) Estado de origen
+	ToState       string             `json:"to_state,omitempty" bson:"to_state,omitempty"`             // (solo transiciones) Estado de destino
+	Comentario    string             `json:"comentario,omitempty" bson:"comentario,omitempty"`         // (opcional) Comentario del usuario, ej: motivo de rechazo
+	NumeroLinea   int                `json:"numero_linea,omitempty" bson:"numero_linea,omitempty"`     // (solo decisiones de línea) Línea aprobada o rechazada
+	OrdenPaso     int                `json:"orden_paso,omitempty" bson:"orden_paso,omitempty"`         // (solo aprobaciones) Orden del paso de la cadena de aprobación decidido
+	PasoNombre    string             `json:"paso_nombre,omitempty" bson:"paso_nombre,omitempty"`       // (solo aprobaciones) Nombre del paso decidido
+	EnNombreDe    primitive.ObjectID `json:"en_nombre_de,omitempty" bson:"en_nombre_de,omitempty"`     // (solo delegaciones) Usuario en cuyo nombre actuó user_id
+	DelegacionID  primitive.ObjectID `json:"delegacion_id,omitempty" bson:"delegacion_id,omitempty"`   // (solo delegaciones) Delegación usada
+}
+
+// FieldChange es un campo de la solicitud que cambió. Campo usa los nombres de la base de datos y
+// las líneas y pasos de aprobación se identifican por número, ej: lines[2].cantidad, aprobaciones[1].estado.
+// Un elemento agregado no tiene Anterior y uno eliminado no tiene Nuevo
+type FieldChange struct {
+	Campo    string      `json:"campo" bson:"campo"`
+	Anterior interface{} `json:"anterior" bson:"anterior,omitempty"`
+	Nuevo    interface{} `json:"nuevo" bson:"nuevo,omitempty"`
+	Texto    string      `json:"texto,omitempty" bson:"-"` // descripción legible, se arma al consultar el historial
 }
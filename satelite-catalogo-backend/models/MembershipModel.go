@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MembershipRole es el rol de un usuario en uno de sus centros de costo
+type MembershipRole string
+
+const (
+	MembershipSolicitante MembershipRole = "solicitante"
+	// el jefe es el de centros_costo.jefe, se cambia con el traspaso de jefatura
+	MembershipJefe MembershipRole = "jefe"
+	// ve las solicitudes del centro de costo pero no crea solicitudes en él
+	MembershipObservador MembershipRole = "observador"
+)
+
+// IsMembershipRole indica si el rol existe
+func IsMembershipRole(r MembershipRole) bool {
+	return r == MembershipSolicitante || r == MembershipJefe || r == MembershipObservador
+}
+
+// Membership es un centro de costo del usuario con su rol
+type Membership struct {
+	CC  primitive.ObjectID `json:"cc"`
+	Rol MembershipRole     `json:"rol"`
+}
+
+// CCMember es un usuario de un centro de costo con su rol
+type CCMember struct {
+	UserID   primitive.ObjectID `json:"user_id"`
+	Username string             `json:"username,omitempty"`
+	Email    string             `json:"email,omitempty"`
+	Rol      MembershipRole     `json:"rol"`
+}
+
+// MembershipLog registra un cambio de membresía de un usuario en un centro de costo, colección
+// membership_logs. Sin rol anterior es un alta y sin rol nuevo una baja
+type MembershipLog struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Timestamp     time.Time          `json:"timestamp" bson:"timestamp"`
+	Usuario       primitive.ObjectID `json:"usuario" bson:"usuario"`
+	CC            primitive.ObjectID `json:"cc" bson:"cc"`
+	RolAnterior   MembershipRole     `json:"rol_anterior,omitempty" bson:"rol_anterior,omitempty"`
+	RolNuevo      MembershipRole     `json:"rol_nuevo,omitempty" bson:"rol_nuevo,omitempty"`
+	Origen        string             `json:"origen" bson:"origen"`             // api, import o handover
+	UserID        primitive.ObjectID `json:"user_id" bson:"user_id,omitempty"` // usuario que hizo el cambio, vacío desde la CLI
+	IP            string             `json:"ip,omitempty" bson:"ip,omitempty"` // IP del cliente que hizo la llamada
+	UserAgent     string             `json:"user_agent,omitempty" bson:"user_agent,omitempty"`
+	CorrelationID string             `json:"correlation_id,omitempty" bson:"correlation_id,omitempty"`
+}
+
+// Origen del cambio de membresía registrado
+const (
+	MembershipOriginAPI      = "api"
+	MembershipOriginImport   = "import"
+	MembershipOriginHandover = "handover"
+)
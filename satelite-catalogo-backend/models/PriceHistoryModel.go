@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Origen del cambio de precio registrado en el historial
+const (
+	PriceOriginCreate = "create"
+	PriceOriginUpdate = "update"
+	PriceOriginImport = "import"
+)
+
+// PriceHistory es un cambio de precio de un producto, colección product_price_history
+type PriceHistory struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ProductID      primitive.ObjectID `bson:"product_id" json:"product_id"`
+	PrecioAnterior float64            `bson:"precio_anterior" json:"precio_anterior"`
+	PrecioNuevo    float64            `bson:"precio_nuevo" json:"precio_nuevo"`
+	Moneda         string             `bson:"moneda,omitempty" json:"moneda,omitempty"` // moneda de precio_nuevo
+	Fecha          time.Time          `bson:"fecha" json:"fecha"`
+	Origen         string             `bson:"origen" json:"origen"`
+}
+
+// ProductSnapshot es la copia del producto congelada en una línea al momento de solicitarlo
+type ProductSnapshot struct {
+	IDProduct          string    `bson:"id_product,omitempty" json:"id_product,omitempty"`
+	Descripcion        string    `bson:"descripcion" json:"descripcion"`
+	IDConvenio         string    `bson:"id_convenio,omitempty" json:"id_convenio,omitempty"`
+	ConvenioMarco      string    `bson:"convenio_marco,omitempty" json:"convenio_marco,omitempty"`
+	NombreProveedor    string    `bson:"nombre_proveedor,omitempty" json:"nombre_proveedor,omitempty"`
+	RutProveedor       string    `bson:"rut_proveedor,omitempty" json:"rut_proveedor,omitempty"`
+	Region             string    `bson:"region,omitempty" json:"region,omitempty"`
+	Marca              string    `bson:"marca,omitempty" json:"marca,omitempty"`
+	Modelo             string    `bson:"modelo,omitempty" json:"modelo,omitempty"`
+	UM                 string    `bson:"UM,omitempty" json:"UM,omitempty"`
+	Precio             float64   `bson:"precio" json:"precio"`
+	Moneda             string    `bson:"moneda,omitempty" json:"moneda,omitempty"`
+	FechaActualizacion time.Time `bson:"fecha_actualizacion,omitempty" json:"fecha_actualizacion,omitempty"`
+}
+
+// NewProductSnapshot copia los datos del producto relevantes para la solicitud
+func NewProductSnapshot(p *Product) *ProductSnapshot {
+	return &ProductSnapshot{
+		IDProduct:          p.IDProduct,
+		Descripcion:        p.Descripcion,
+		IDConvenio:         p.IDConvenio,
+		ConvenioMarco:      p.ConvenioMarco,
+		NombreProveedor:    p.NombreProveedor,
+		RutProveedor:       p.RutProveedor,
+		Region:             p.Region,
+		Marca:              p.Marca,
+		Modelo:             p.Modelo,
+		UM:                 p.UM,
+		Precio:             p.Precio,
+		Moneda:             p.PriceCurrency(),
+		FechaActualizacion: p.FechaActualizacion,
+	}
+}
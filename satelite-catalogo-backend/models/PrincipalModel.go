@@ -0,0 +1,166 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Principal es la identidad del usuario autenticado resuelta en el servidor,
+// con sus centros de costo y los centros de costo de los que es jefe
+type Principal struct {
+	UserID   primitive.ObjectID   `json:"user_id"`
+	Username string               `json:"username"`
+	Email    string               `json:"email"`
+	Roles    []Role               `json:"roles"`
+	CC       []primitive.ObjectID `json:"cc"`
+	JefeDe   []primitive.ObjectID `json:"jefe_de"`
+	// centros de costo de CC en los que solo es observador
+	Observa []primitive.ObjectID `json:"observa,omitempty"`
+	// centros de costo descendientes de los que dirige, que no dirige directamente
+	SupervisaDe []primitive.ObjectID `json:"supervisa_de,omitempty"`
+	// delegaciones vigentes recibidas, permiten aprobar en nombre del delegante
+	Delegaciones []ActiveDelegation `json:"delegaciones,omitempty"`
+}
+
+func (p *Principal) HasRole(role Role) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Principal) IsAdmin() bool {
+	return p.HasRole(ADMIN)
+}
+
+// RestrictRoles limita el principal a los roles indicados, ej: los de la API key con la que se
+// autenticó. Sin el rol Jefe pierde también las jefaturas, supervisiones y delegaciones
+func (p *Principal) RestrictRoles(roles []Role) {
+	restringidos := []Role{}
+	for _, r := range p.Roles {
+		for _, permitido := range roles {
+			if r == permitido {
+				restringidos = append(restringidos, r)
+				break
+			}
+		}
+	}
+	p.Roles = restringidos
+	if !p.HasRole(JEFE) {
+		p.JefeDe = nil
+		p.SupervisaDe = nil
+		p.Delegaciones = nil
+	}
+}
+
+// LeadsCC indica si el usuario es jefe del centro de costo
+func (p *Principal) LeadsCC(ccID primitive.ObjectID) bool {
+	return containsID(p.JefeDe, ccID)
+}
+
+// SupervisesCC indica si el usuario dirige un centro de costo ancestro del indicado
+func (p *Principal) SupervisesCC(ccID primitive.ObjectID) bool {
+	return containsID(p.SupervisaDe, ccID)
+}
+
+// VisibleCCs retorna los centros de costo a los que pertenece o que dirige el usuario, con los
+// descendientes de los que dirige, incluidos los que dirige por una delegación vigente
+func (p *Principal) VisibleCCs() []primitive.ObjectID {
+	ids := append([]primitive.ObjectID{}, p.CC...)
+	for _, id := range append(append([]primitive.ObjectID{}, p.JefeDe...), p.SupervisaDe...) {
+		if !containsID(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	for _, d := range p.Delegaciones {
+		for _, id := range d.JefeDe {
+			if !containsID(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// CanSeeCC indica si el usuario puede ver las solicitudes del centro de costo
+func (p *Principal) CanSeeCC(ccID primitive.ObjectID) bool {
+	return p.IsAdmin() || containsID(p.VisibleCCs(), ccID)
+}
+
+// CanRequestForCC indica si el usuario puede crear solicitudes en el centro de costo: los que ve,
+// salvo aquellos en los que solo es observador
+func (p *Principal) CanRequestForCC(ccID primitive.ObjectID) bool {
+	if p.IsAdmin() || p.LeadsCC(ccID) {
+		return true
+	}
+	return p.CanSeeCC(ccID) && !containsID(p.Observa, ccID)
+}
+
+// CanAccessSolicitud indica si el usuario puede ver o modificar la solicitud
+func (p *Principal) CanAccessSolicitud(s *Solicitud) bool {
+	return p.IsAdmin() || s.Solicitante == p.UserID || p.CanSeeCC(s.CC) || p.IsApproverOf(s)
+}
+
+// CanModifySolicitud indica si el usuario puede modificar o eliminar la solicitud: el solicitante,
+// el jefe de su centro de costo o un administrador. Los observadores, aprobadores por rol y
+// delegados solo pueden verla
+func (p *Principal) CanModifySolicitud(s *Solicitud) bool {
+	if p.IsAdmin() || p.LeadsCC(s.CC) {
+		return true
+	}
+	return s.Solicitante == p.UserID && !containsID(p.Observa, s.CC)
+}
+
+// IsApproverOf indica si el usuario es aprobador de algún paso de la cadena de aprobación de la
+// solicitud, directamente o por una delegación vigente
+func (p *Principal) IsApproverOf(s *Solicitud) bool {
+	for _, paso := range s.Aprobaciones {
+		if paso.Tipo == ApproverRol {
+			if p.HasRole(paso.Rol) {
+				return true
+			}
+			continue
+		}
+		if paso.Aprobador.IsZero() {
+			continue
+		}
+		if paso.Aprobador == p.UserID {
+			return true
+		}
+		for _, d := range p.Delegaciones {
+			if d.Delegante == paso.Aprobador && d.Covers(s.CC) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SolicitudScope retorna el filtro de mongo con las solicitudes visibles para el usuario
+func (p *Principal) SolicitudScope() bson.M {
+	if p.IsAdmin() {
+		return bson.M{}
+	}
+	or := []bson.M{
+		{"cc": bson.M{"$in": p.VisibleCCs()}},
+		{"solicitante": p.UserID},
+		{"aprobaciones.aprobador": p.UserID},
+		// el rol solo cuenta en los pasos de tipo rol, igual que en IsApproverOf
+		{"aprobaciones": bson.M{"$elemMatch": bson.M{"tipo": ApproverRol, "rol": bson.M{"$in": p.Roles}}}},
+	}
+	for _, d := range p.Delegaciones {
+		or = append(or, d.Scope(bson.M{"aprobaciones.aprobador": d.Delegante}))
+	}
+	return bson.M{"$or": or}
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
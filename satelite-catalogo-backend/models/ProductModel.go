@@ -19,9 +19,20 @@ type Product struct {
 	Marca              string             `bson:"marca,omitempty" json:"marca,omitempty"`
 	Modelo             string             `bson:"modelo,omitempty" json:"modelo,omitempty"`
 	Precio             float64            `bson:"precio,omitempty" json:"precio,omitempty"`
+	Moneda             string             `bson:"moneda,omitempty" json:"moneda,omitempty"` // moneda del precio, CLP si no se indica
 	FechaActualizacion time.Time          `bson:"fecha_actualizacion,omitempty" json:"fecha_actualizacion,omitempty"`
 	ConvenioMarco      string             `bson:"convenio_marco,omitempty" json:"convenio_marco,omitempty"`
 	UM                 string             `bson:"UM,omitempty" json:"UM,omitempty"`
 	Categoria          string             `bson:"categoria,omitempty" json:"categoria,omitempty"`
 	IDCategoria        string             `bson:"id_categoria,omitempty" json:"id_categoria,omitempty"`
+
+	SoftDelete `bson:",inline"`
+}
+
+// PriceCurrency retorna la moneda del precio, los productos antiguos no la tienen y están en pesos
+func (p *Product) PriceCurrency() string {
+	if p.Moneda == "" {
+		return CLP
+	}
+	return p.Moneda
 }
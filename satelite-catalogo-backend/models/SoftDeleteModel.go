@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SoftDelete marca un documento como eliminado sin borrarlo, se incluye en los modelos que se
+// pueden eliminar y restaurar. Los documentos eliminados se borran definitivamente con purge-deleted
+type SoftDelete struct {
+	DeletedAt *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
+	DeletedBy primitive.ObjectID `bson:"deleted_by,omitempty" json:"deleted_by,omitempty"`
+}
+
+// IsDeleted indica si el documento está eliminado
+func (d SoftDelete) IsDeleted() bool {
+	return d.DeletedAt != nil
+}
+
+// NotDeleted retorna una copia del filtro que excluye los documentos eliminados, salvo que el
+// filtro ya indique algo sobre deleted_at
+func NotDeleted(filter bson.M) bson.M {
+	result := bson.M{"deleted_at": bson.M{"$exists": false}}
+	for k, v := range filter {
+		result[k] = v
+	}
+	return result
+}
+
+// OnlyDeleted es el filtro de los documentos eliminados
+func OnlyDeleted() bson.M {
+	return bson.M{"deleted_at": bson.M{"$exists": true}}
+}
+
+// SoftDeleteKind identifica las colecciones que admiten eliminación lógica
+type SoftDeleteKind string
+
+const (
+	SoftDeleteSolicitud   SoftDeleteKind = "solicitud"
+	SoftDeleteProduct     SoftDeleteKind = "product"
+	SoftDeleteCentroCosto SoftDeleteKind = "cc"
+)
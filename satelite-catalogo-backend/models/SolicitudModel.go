@@ -20,4 +20,18 @@ type Solicitud struct {
 	Moneda          string             `bson:"moneda" json:"moneda"`
 	NombreSolicitud string             `bson:"nombre_solicitud" json:"nombre_solicitud"`
 	ImporteTotal    float64            `bson:"importe_total" json:"importe_total"`
+	MonedaReporte   string             `bson:"moneda_reporte,omitempty" json:"moneda_reporte,omitempty"`
+	ImporteReporte  float64            `bson:"importe_reporte,omitempty" json:"importe_reporte,omitempty"` // importe_total convertido a moneda_reporte
+	TiposCambio     []AppliedRate      `bson:"tipos_cambio,omitempty" json:"tipos_cambio,omitempty"`
+	Presupuesto     *BudgetCommitment  `bson:"presupuesto,omitempty" json:"presupuesto,omitempty"`
+	Aprobaciones    []ApprovalStep     `bson:"aprobaciones,omitempty" json:"aprobaciones,omitempty"`
+	PasoActual      int                `bson:"paso_actual" json:"paso_actual"` // índice en aprobaciones del paso pendiente
+	// aprobador del paso pendiente, se copia del paso para poder filtrar las solicitudes por aprobador
+	AprobadorActual primitive.ObjectID `bson:"aprobador_actual,omitempty" json:"aprobador_actual,omitempty"`
+	RolActual       Role               `bson:"rol_actual,omitempty" json:"rol_actual,omitempty"`
+	// secuencia y hash del último log de la cadena, para detectar logs eliminados al final
+	LogSecuencia int64  `bson:"log_secuencia,omitempty" json:"log_secuencia,omitempty"`
+	LogHash      string `bson:"log_hash,omitempty" json:"log_hash,omitempty"`
+
+	SoftDelete `bson:",inline"`
 }
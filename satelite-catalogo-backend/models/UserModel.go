@@ -16,13 +16,20 @@ type User struct {
 	Role      []Role               `json:"role,omitempty"   bson:"role,omitempty"`
 	CreatedAt primitive.DateTime   `json:"created_at,omitempty" bson:"created_at,omitempty" swaggertype:"string"`
 	CC        []primitive.ObjectID `bson:"cc" json:"cc"`
+	// centros de costo de CC en los que el usuario es observador, en el resto es solicitante
+	CCObservador []primitive.ObjectID `bson:"cc_observador,omitempty" json:"cc_observador,omitempty"`
+	// hash bcrypt de la contraseña, solo lo usa el proveedor de autenticación local
+	Password string `json:"-" bson:"password,omitempty"`
 }
 
 type Role string
 
+// Roles en el sistema, para registrar nuevos roles, hacerlo aca
 const (
 	USER  Role = "Usuario"
 	ADMIN Role = "Administrador"
+	// JEFE aprueba solicitudes, se asigna también de forma efectiva a quien dirige un centro de costo
+	JEFE Role = "Jefe"
 )
 
 func NewUser(username string, email string, rut string, role []Role) *User {
@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"catalogo-backend/database"
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var approvalPolicyRepo *ApprovalPolicyRepository
+
+type ApprovalPolicyRepository struct {
+	collection *mongo.Collection
+}
+
+func NewApprovalPolicyRepository() *ApprovalPolicyRepository {
+	if database.Client == nil {
+		log.Fatal("MongoDB client not initialized. Call InitMongo() first.")
+	}
+	if approvalPolicyRepo == nil {
+		log.Println("Inicializando ApprovalPolicyRepository")
+		db := database.GetDatabase()
+		collection := db.Collection("approval_policies")
+		approvalPolicyRepo = &ApprovalPolicyRepository{collection: collection}
+	}
+	return approvalPolicyRepo
+}
+
+func (r *ApprovalPolicyRepository) InsertOne(ctx context.Context, policy *models.ApprovalPolicy) error {
+	_, err := r.collection.InsertOne(ctx, policy)
+	return err
+}
+
+func (r *ApprovalPolicyRepository) findOne(ctx context.Context, filter bson.M) (*models.ApprovalPolicy, error) {
+	var policy models.ApprovalPolicy
+	err := r.collection.FindOne(ctx, filter).Decode(&policy)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *ApprovalPolicyRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.ApprovalPolicy, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindByCC retorna la política del centro de costo; con un ID vacío retorna la política global
+func (r *ApprovalPolicyRepository) FindByCC(ctx context.Context, cc primitive.ObjectID) (*models.ApprovalPolicy, error) {
+	if cc.IsZero() {
+		return r.findOne(ctx, bson.M{"cc": bson.M{"$exists": false}})
+	}
+	return r.findOne(ctx, bson.M{"cc": cc})
+}
+
+func (r *ApprovalPolicyRepository) Replace(ctx context.Context, policy *models.ApprovalPolicy) error {
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": policy.ID}, policy)
+	return err
+}
+
+func (r *ApprovalPolicyRepository) DeleteOne(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+func (r *ApprovalPolicyRepository) FindPaginated(ctx context.Context, filter bson.M, page, pageSize int) ([]*models.ApprovalPolicy, int64, error) {
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	policies := []*models.ApprovalPolicy{}
+	if err := cursor.All(ctx, &policies); err != nil {
+		return nil, 0, err
+	}
+	return policies, total, nil
+}
@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"catalogo-backend/database"
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var budgetRepo *BudgetRepository
+
+type BudgetRepository struct {
+	collection *mongo.Collection
+}
+
+func NewBudgetRepository() *BudgetRepository {
+	if database.Client == nil {
+		log.Fatal("MongoDB client not initialized. Call InitMongo() first.")
+	}
+	if budgetRepo == nil {
+		log.Println("Inicializando BudgetRepository")
+		db := database.GetDatabase()
+		collection := db.Collection("budgets")
+		budgetRepo = &BudgetRepository{collection: collection}
+	}
+	return budgetRepo
+}
+
+func (r *BudgetRepository) InsertOne(ctx context.Context, budget *models.Budget) error {
+	_, err := r.collection.InsertOne(ctx, budget)
+	return err
+}
+
+func (r *BudgetRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Budget, error) {
+	var budget models.Budget
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&budget)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// FindActive retorna el presupuesto del centro de costo cuyo período incluye la fecha
+func (r *BudgetRepository) FindActive(ctx context.Context, cc primitive.ObjectID, fecha time.Time) (*models.Budget, error) {
+	filter := bson.M{
+		"cc":           cc,
+		"fecha_inicio": bson.M{"$lte": fecha},
+		"fecha_fin":    bson.M{"$gte": fecha},
+	}
+	var budget models.Budget
+	err := r.collection.FindOne(ctx, filter).Decode(&budget)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// ExistsOverlapping indica si el centro de costo ya tiene otro presupuesto que se cruza con el período
+func (r *BudgetRepository) ExistsOverlapping(ctx context.Context, budget *models.Budget) (bool, error) {
+	filter := bson.M{
+		"_id":          bson.M{"$ne": budget.ID},
+		"cc":           budget.CC,
+		"fecha_inicio": bson.M{"$lte": budget.FechaFin},
+		"fecha_fin":    bson.M{"$gte": budget.FechaInicio},
+	}
+	count, err := r.collection.CountDocuments(ctx, filter)
+	return count > 0, err
+}
+
+// UpdateDefinition actualiza los datos del presupuesto sin tocar los montos comprometidos y consumidos
+func (r *BudgetRepository) UpdateDefinition(ctx context.Context, budget *models.Budget) error {
+	set := bson.M{
+		"periodo":      budget.Periodo,
+		"fecha_inicio": budget.FechaInicio,
+		"fecha_fin":    budget.FechaFin,
+		"monto":        budget.Monto,
+		"politica":     budget.Politica,
+		"updated_at":   time.Now(),
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": budget.ID}, bson.M{"$set": set})
+	return err
+}
+
+// Reserve suma el monto a lo comprometido. Con onlyIfAvailable solo lo hace si el saldo alcanza,
+// la condición se evalúa en la misma operación para no aprobar dos veces el mismo saldo.
+// Retorna si se reservó
+func (r *BudgetRepository) Reserve(ctx context.Context, id primitive.ObjectID, monto float64, onlyIfAvailable bool) (bool, error) {
+	filter := bson.M{"_id": id}
+	if onlyIfAvailable {
+		filter["$expr"] = bson.M{"$gte": bson.A{
+			bson.M{"$subtract": bson.A{"$monto", bson.M{"$add": bson.A{"$comprometido", "$consumido"}}}},
+			monto,
+		}}
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{
+		"$inc": bson.M{"comprometido": monto},
+		"$set": bson.M{"updated_at": time.Now()},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Move ajusta lo comprometido y lo consumido del presupuesto
+func (r *BudgetRepository) Move(ctx context.Context, id primitive.ObjectID, comprometido, consumido float64) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$inc": bson.M{"comprometido": comprometido, "consumido": consumido},
+		"$set": bson.M{"updated_at": time.Now()},
+	})
+	return err
+}
+
+func (r *BudgetRepository) FindPaginated(ctx context.Context, filter bson.M, page, pageSize int) ([]*models.Budget, int64, error) {
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "fecha_inicio", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	budgets := []*models.Budget{}
+	if err := cursor.All(ctx, &budgets); err != nil {
+		return nil, 0, err
+	}
+	return budgets, total, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"time"
 
 	"catalogo-backend/database"
 	"catalogo-backend/models"
@@ -43,7 +44,7 @@ func (repo *CentroCostoRepository) InsertOne(cc *models.CC) (primitive.ObjectID,
 
 func (repo *CentroCostoRepository) FindOne(filter bson.M) (*models.CC, error) {
 	var cc models.CC
-	err := repo.collection.FindOne(context.Background(), filter).Decode(&cc)
+	err := repo.collection.FindOne(context.Background(), models.NotDeleted(filter)).Decode(&cc)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, nil
@@ -53,7 +54,7 @@ func (repo *CentroCostoRepository) FindOne(filter bson.M) (*models.CC, error) {
 	return &cc, nil
 }
 func (repo *CentroCostoRepository) UpdateOne(filter, update bson.M) error {
-	result, err := repo.collection.UpdateOne(context.Background(), filter, update)
+	result, err := repo.collection.UpdateOne(context.Background(), models.NotDeleted(filter), update)
 	if err != nil {
 		return err
 	}
@@ -62,19 +63,48 @@ func (repo *CentroCostoRepository) UpdateOne(filter, update bson.M) error {
 	}
 	return nil
 }
-func (repo *CentroCostoRepository) DeleteOne(filter bson.M) error {
-	result, err := repo.collection.DeleteOne(context.Background(), filter)
+
+// SoftDelete marca el centro de costo como eliminado, retorna false si no existe o ya estaba eliminado
+func (repo *CentroCostoRepository) SoftDelete(ctx context.Context, id, by primitive.ObjectID, at time.Time) (bool, error) {
+	result, err := repo.collection.UpdateOne(ctx, models.NotDeleted(bson.M{"_id": id}),
+		bson.M{"$set": bson.M{"deleted_at": at, "deleted_by": by}})
 	if err != nil {
-		return err
+		return false, err
 	}
-	if result.DeletedCount == 0 {
-		return mongo.ErrNoDocuments
+	return result.MatchedCount > 0, nil
+}
+
+// Restore quita la marca de eliminado, retorna false si el centro de costo no está eliminado
+func (repo *CentroCostoRepository) Restore(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := models.OnlyDeleted()
+	filter["_id"] = id
+	result, err := repo.collection.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"deleted_at": "", "deleted_by": ""}})
+	if err != nil {
+		return false, err
 	}
-	return nil
+	return result.MatchedCount > 0, nil
+}
+
+// FindDeletedPaginated lista los centros de costo eliminados, el más reciente primero
+func (repo *CentroCostoRepository) FindDeletedPaginated(ctx context.Context, page, pageSize int) ([]*models.CC, int64, error) {
+	centros := []*models.CC{}
+	total, err := findDeletedPaginated(ctx, repo.collection, page, pageSize, &centros)
+	return centros, total, err
+}
+
+// FindDeletedBeforeIDs retorna los IDs de los centros de costo eliminados antes de la fecha
+func (repo *CentroCostoRepository) FindDeletedBeforeIDs(ctx context.Context, fecha time.Time) ([]primitive.ObjectID, error) {
+	return findIDs(ctx, repo.collection, bson.M{"deleted_at": bson.M{"$lt": fecha}})
+}
+
+// DeleteOneByID elimina definitivamente el centro de costo, solo lo usa la purga de eliminados
+func (repo *CentroCostoRepository) DeleteOneByID(ctx context.Context, id primitive.ObjectID) error {
+	_, err := repo.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
 }
 func (repo *CentroCostoRepository) FindAll() ([]*models.CC, error) {
 	var centrosCosto []*models.CC
-	cursor, err := repo.collection.Find(context.Background(), bson.M{})
+	cursor, err := repo.collection.Find(context.Background(), models.NotDeleted(nil))
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +117,7 @@ func (repo *CentroCostoRepository) FindAll() ([]*models.CC, error) {
 }
 func (repo *CentroCostoRepository) FindAllFiltered(filter bson.M) ([]*models.CC, error) {
 	var centrosCosto []*models.CC
-	cursor, err := repo.collection.Find(context.Background(), filter)
+	cursor, err := repo.collection.Find(context.Background(), models.NotDeleted(filter))
 	if err != nil {
 		return nil, err
 	}
@@ -101,7 +131,7 @@ func (repo *CentroCostoRepository) FindAllFiltered(filter bson.M) ([]*models.CC,
 func (repo *CentroCostoRepository) FindByID(id primitive.ObjectID) (*models.CC, error) {
 	filter := bson.M{"_id": id}
 	var cc models.CC
-	err := repo.collection.FindOne(context.Background(), filter).Decode(&cc)
+	err := repo.collection.FindOne(context.Background(), models.NotDeleted(filter)).Decode(&cc)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, nil
@@ -113,7 +143,7 @@ func (repo *CentroCostoRepository) FindByID(id primitive.ObjectID) (*models.CC,
 func (repo *CentroCostoRepository) FindByJefe(jefeID primitive.ObjectID) ([]*models.CC, error) {
 	filter := bson.M{"jefe": jefeID}
 	var centrosCosto []*models.CC
-	cursor, err := repo.collection.Find(context.Background(), filter)
+	cursor, err := repo.collection.Find(context.Background(), models.NotDeleted(filter))
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +158,7 @@ func (repo *CentroCostoRepository) FindByJefe(jefeID primitive.ObjectID) ([]*mod
 func (repo *CentroCostoRepository) FindByNumero(numero int) (*models.CC, error) {
 	filter := bson.M{"numero": numero}
 	var cc models.CC
-	err := repo.collection.FindOne(context.Background(), filter).Decode(&cc)
+	err := repo.collection.FindOne(context.Background(), models.NotDeleted(filter)).Decode(&cc)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, nil
@@ -137,3 +167,21 @@ func (repo *CentroCostoRepository) FindByNumero(numero int) (*models.CC, error)
 	}
 	return &cc, nil
 }
+
+// SetJefe cambia el jefe del centro de costo solo si el jefe actual sigue siendo anterior, retorna si hubo coincidencia
+func (repo *CentroCostoRepository) SetJefe(ctx context.Context, id, anterior, nuevo primitive.ObjectID) (bool, error) {
+	filter := models.NotDeleted(bson.M{"_id": id, "jefe": anterior})
+	if anterior.IsZero() {
+		filter["jefe"] = bson.M{"$in": bson.A{nil, primitive.NilObjectID}}
+	}
+	result, err := repo.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"jefe": nuevo}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// CountChildren cuenta los centros de costo no eliminados cuyo padre es el indicado
+func (repo *CentroCostoRepository) CountChildren(ctx context.Context, id primitive.ObjectID) (int64, error) {
+	return repo.collection.CountDocuments(ctx, models.NotDeleted(bson.M{"padre": id}))
+}
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"catalogo-backend/database"
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var delegationRepo *DelegationRepository
+
+type DelegationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewDelegationRepository() *DelegationRepository {
+	if database.Client == nil {
+		log.Fatal("MongoDB client not initialized. Call InitMongo() first.")
+	}
+	if delegationRepo == nil {
+		log.Println("Inicializando DelegationRepository")
+		db := database.GetDatabase()
+		collection := db.Collection("delegations")
+		delegationRepo = &DelegationRepository{collection: collection}
+	}
+	return delegationRepo
+}
+
+func (r *DelegationRepository) InsertOne(ctx context.Context, delegation *models.Delegation) error {
+	_, err := r.collection.InsertOne(ctx, delegation)
+	return err
+}
+
+func (r *DelegationRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Delegation, error) {
+	var delegation models.Delegation
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&delegation)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delegation, nil
+}
+
+// FindActiveByDelegado retorna las delegaciones recibidas por el usuario vigentes en la fecha
+func (r *DelegationRepository) FindActiveByDelegado(ctx context.Context, delegado primitive.ObjectID, fecha time.Time) ([]models.Delegation, error) {
+	filter := bson.M{
+		"delegado":     delegado,
+		"fecha_inicio": bson.M{"$lte": fecha},
+		"fecha_fin":    bson.M{"$gte": fecha},
+		"revoked_at":   bson.M{"$exists": false},
+	}
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	delegations := []models.Delegation{}
+	if err := cursor.All(ctx, &delegations); err != nil {
+		return nil, err
+	}
+	return delegations, nil
+}
+
+// Revoke marca la delegación como revocada, retorna false si ya estaba revocada
+func (r *DelegationRepository) Revoke(ctx context.Context, id, by primitive.ObjectID, at time.Time) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "revoked_at": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"revoked_at": at, "revoked_by": by}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (r *DelegationRepository) FindPaginated(ctx context.Context, filter bson.M, page, pageSize int) ([]*models.Delegation, int64, error) {
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "fecha_inicio", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	delegations := []*models.Delegation{}
+	if err := cursor.All(ctx, &delegations); err != nil {
+		return nil, 0, err
+	}
+	return delegations, total, nil
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"catalogo-backend/database"
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var exchangeRateRepo *ExchangeRateRepository
+
+type ExchangeRateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewExchangeRateRepository() *ExchangeRateRepository {
+	if database.Client == nil {
+		log.Fatal("MongoDB client not initialized. Call InitMongo() first.")
+	}
+	if exchangeRateRepo == nil {
+		log.Println("Inicializando ExchangeRateRepository")
+		db := database.GetDatabase()
+		collection := db.Collection("exchange_rates")
+		exchangeRateRepo = &ExchangeRateRepository{collection: collection}
+	}
+	return exchangeRateRepo
+}
+
+// Upsert guarda el valor de la moneda para el día, reemplazando el existente; retorna si fue creado
+func (r *ExchangeRateRepository) Upsert(ctx context.Context, rate *models.ExchangeRate) (bool, error) {
+	filter := bson.M{"moneda": rate.Moneda, "fecha": rate.Fecha}
+	update := bson.M{
+		"$set":         bson.M{"valor": rate.Valor, "fuente": rate.Fuente},
+		"$setOnInsert": bson.M{"created_at": time.Now()},
+	}
+	result, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, err
+	}
+	return result.UpsertedCount > 0, nil
+}
+
+// FindLatest retorna el último valor de la moneda vigente a la fecha indicada
+func (r *ExchangeRateRepository) FindLatest(ctx context.Context, moneda string, fecha time.Time) (*models.ExchangeRate, error) {
+	filter := bson.M{"moneda": moneda, "fecha": bson.M{"$lte": fecha}}
+	opts := options.FindOne().SetSort(bson.D{{Key: "fecha", Value: -1}})
+
+	var rate models.ExchangeRate
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&rate)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rate, nil
+}
+
+func (r *ExchangeRateRepository) FindPaginated(ctx context.Context, filter bson.M, page, pageSize int) ([]*models.ExchangeRate, int64, error) {
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "fecha", Value: -1}, {Key: "moneda", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	rates := []*models.ExchangeRate{}
+	if err := cursor.All(ctx, &rates); err != nil {
+		return nil, 0, err
+	}
+	return rates, total, nil
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"log"
+	"time"
 
 	"catalogo-backend/database"
 	"catalogo-backend/models"
@@ -10,6 +11,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var logRepo *LogRepository
@@ -51,16 +53,60 @@ func (repo *LogRepository) FindAll() ([]*models.RequestLog, error) {
 	return logs, nil
 }
 
-// Insertar un log
-func (repo *LogRepository) InsertOne(logEntry *models.RequestLog) (string, error) {
-	ctx := context.Background()
-	result, err := repo.collection.InsertOne(ctx, logEntry)
+// InsertRaw inserta un log ya sellado con su hash. Se inserta el documento tal cual para que los
+// bytes guardados sean los mismos sobre los que se calculó el hash
+func (repo *LogRepository) InsertRaw(ctx context.Context, doc bson.Raw) error {
+	_, err := repo.collection.InsertOne(ctx, doc)
+	return err
+}
+
+// FindLastChained retorna el último log encadenado de la solicitud, solo con secuencia y hash
+func (repo *LogRepository) FindLastChained(ctx context.Context, requestID primitive.ObjectID) (*models.RequestLog, error) {
+	opts := options.FindOne().
+		SetSort(bson.D{{Key: "secuencia", Value: -1}}).
+		SetProjection(bson.M{"secuencia": 1, "hash": 1})
+	var logEntry models.RequestLog
+	err := repo.collection.FindOne(ctx, bson.M{"request_id": requestID, "hash": bson.M{"$exists": true}}, opts).Decode(&logEntry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &logEntry, nil
+}
+
+// EachRawByRequest recorre los logs de la solicitud tal como están guardados, en el orden de la cadena.
+// Los logs anteriores a la cadena no tienen secuencia y quedan primero
+func (repo *LogRepository) EachRawByRequest(ctx context.Context, requestID primitive.ObjectID, fn func(bson.Raw) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "secuencia", Value: 1}, {Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := repo.collection.Find(ctx, bson.M{"request_id": requestID}, opts)
 	if err != nil {
-		return "", err
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		if err := fn(cursor.Current); err != nil {
+			return err
+		}
 	}
+	return cursor.Err()
+}
 
-	id := result.InsertedID.(primitive.ObjectID).Hex()
-	return id, nil
+// DistinctRequestIDs retorna las solicitudes que tienen logs
+func (repo *LogRepository) DistinctRequestIDs(ctx context.Context) ([]primitive.ObjectID, error) {
+	values, err := repo.collection.Distinct(ctx, "request_id", bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(values))
+	for _, v := range values {
+		if id, ok := v.(primitive.ObjectID); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
 }
 
 // Buscar un log por ID
@@ -83,37 +129,91 @@ func (repo *LogRepository) FindByID(id string) (*models.RequestLog, error) {
 	return &logEntry, nil
 }
 
-// Eliminar un log por ID
-func (repo *LogRepository) DeleteByID(id string) error {
-	ctx := context.Background()
-	objectID, err := primitive.ObjectIDFromHex(id)
+// FindPaginated busca logs ordenados por fecha, ascendente para la línea de tiempo de una solicitud y
+// descendente para la auditoría. Sin snapshots se omiten previous_state y new_state
+func (repo *LogRepository) FindPaginated(ctx context.Context, filter bson.M, page, pageSize int, ascendente, snapshots bool) ([]*models.RequestLog, int64, error) {
+	total, err := repo.collection.CountDocuments(ctx, filter)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
-	_, err = repo.collection.DeleteOne(ctx, bson.M{"_id": objectID})
-	return err
-}
+	orden := -1
+	if ascendente {
+		orden = 1
+	}
+	opts := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "timestamp", Value: orden}, {Key: "_id", Value: orden}})
+	if !snapshots {
+		opts.SetProjection(bson.M{"previous_state": 0, "new_state": 0})
+	}
 
-// Actualizar un log
-func (repo *LogRepository) UpdateOne(id string, logEntry *models.RequestLog) error {
-	ctx := context.Background()
-	objectID, err := primitive.ObjectIDFromHex(id)
+	cursor, err := repo.collection.Find(ctx, filter, opts)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
+	defer cursor.Close(ctx)
 
-	update := bson.M{
-		"$set": logEntry,
+	logs := []*models.RequestLog{}
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, 0, err
 	}
+	return logs, total, nil
+}
+
+// EnsureIndexes crea los índices de la línea de tiempo por solicitud y de la búsqueda de auditoría
+func (repo *LogRepository) EnsureIndexes(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	_, err := repo.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "request_id", Value: 1}, {Key: "timestamp", Value: 1}}},
+		{Keys: bson.D{{Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "cc", Value: 1}, {Key: "timestamp", Value: -1}}},
+		{Keys: bson.D{{Key: "correlation_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		// impide que dos logs de la solicitud tomen el mismo lugar en la cadena
+		{
+			Keys:    bson.D{{Key: "request_id", Value: 1}, {Key: "secuencia", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"hash": bson.M{"$exists": true}}),
+		},
+	})
+	return err
+}
 
-	result, err := repo.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
+// EachPendingChanges recorre los logs con ambos estados completos y sin cambios calculados
+func (repo *LogRepository) EachPendingChanges(ctx context.Context, fn func(*models.RequestLog) error) error {
+	filter := bson.M{
+		"cambios":        bson.M{"$exists": false},
+		"hash":           bson.M{"$exists": false}, // los logs encadenados no se modifican
+		"previous_state": bson.M{"$exists": true},
+		"new_state":      bson.M{"$exists": true},
+	}
+	cursor, err := repo.collection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}}))
 	if err != nil {
 		return err
 	}
-	if result.MatchedCount == 0 {
-		return mongo.ErrNoDocuments
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var logEntry models.RequestLog
+		if err := cursor.Decode(&logEntry); err != nil {
+			return err
+		}
+		if err := fn(&logEntry); err != nil {
+			return err
+		}
 	}
+	return cursor.Err()
+}
 
-	return nil
+// SetChanges guarda los cambios calculados del log y, si se indica, elimina los estados completos
+func (repo *LogRepository) SetChanges(ctx context.Context, id primitive.ObjectID, cambios []models.FieldChange, dropSnapshots bool) error {
+	update := bson.M{"$set": bson.M{"cambios": cambios}}
+	if dropSnapshots {
+		update["$unset"] = bson.M{"previous_state": "", "new_state": ""}
+	}
+	_, err := repo.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
 }
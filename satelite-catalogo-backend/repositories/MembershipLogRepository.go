@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+	"log"
+
+	"catalogo-backend/database"
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var membershipLogRepo *MembershipLogRepository
+
+type MembershipLogRepository struct {
+	collection *mongo.Collection
+}
+
+func NewMembershipLogRepository() *MembershipLogRepository {
+	if database.Client == nil {
+		log.Fatal("MongoDB client not initialized. Call InitMongo() first.")
+	}
+	if membershipLogRepo == nil {
+		log.Println("Inicializando MembershipLogRepository")
+		db := database.GetDatabase()
+		collection := db.Collection("membership_logs")
+		membershipLogRepo = &MembershipLogRepository{collection: collection}
+	}
+	return membershipLogRepo
+}
+
+func (r *MembershipLogRepository) InsertOne(ctx context.Context, entry *models.MembershipLog) error {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+// FindPaginated busca los cambios de membresía, el más reciente primero
+func (r *MembershipLogRepository) FindPaginated(ctx context.Context, filter bson.M, page, pageSize int) ([]*models.MembershipLog, int64, error) {
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	logs := []*models.MembershipLog{}
+	if err := cursor.All(ctx, &logs); err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
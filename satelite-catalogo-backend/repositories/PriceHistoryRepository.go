@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"log"
+
+	"catalogo-backend/database"
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var priceHistoryRepo *PriceHistoryRepository
+
+type PriceHistoryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPriceHistoryRepository() *PriceHistoryRepository {
+	if database.Client == nil {
+		log.Fatal("MongoDB client not initialized. Call InitMongo() first.")
+	}
+	if priceHistoryRepo == nil {
+		log.Println("Inicializando PriceHistoryRepository")
+		db := database.GetDatabase()
+		collection := db.Collection("product_price_history")
+		priceHistoryRepo = &PriceHistoryRepository{collection: collection}
+	}
+	return priceHistoryRepo
+}
+
+func (r *PriceHistoryRepository) InsertOne(ctx context.Context, entry *models.PriceHistory) error {
+	if entry.ID.IsZero() {
+		entry.ID = primitive.NewObjectID()
+	}
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
+
+// FindByProductPaginated retorna el historial de precios de un producto en orden cronológico
+func (r *PriceHistoryRepository) FindByProductPaginated(ctx context.Context, productID primitive.ObjectID, page, pageSize int) ([]*models.PriceHistory, int64, error) {
+	filter := bson.M{"product_id": productID}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "fecha", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	history := []*models.PriceHistory{}
+	if err := cursor.All(ctx, &history); err != nil {
+		return nil, 0, err
+	}
+	return history, total, nil
+}
+
+// DeleteByProducts elimina el historial de precios de los productos
+func (r *PriceHistoryRepository) DeleteByProducts(ctx context.Context, productIDs []primitive.ObjectID) error {
+	_, err := r.collection.DeleteMany(ctx, bson.M{"product_id": bson.M{"$in": productIDs}})
+	return err
+}
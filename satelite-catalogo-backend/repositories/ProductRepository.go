@@ -33,7 +33,9 @@ func NewProductRepository() *ProductRepository {
 }
 
 func (r *ProductRepository) Create(ctx context.Context, product models.Product) error {
-	product.ID = primitive.NewObjectID()
+	if product.ID.IsZero() {
+		product.ID = primitive.NewObjectID()
+	}
 	product.FechaActualizacion = time.Now()
 
 	_, err := r.collection.InsertOne(ctx, &product)
@@ -41,7 +43,7 @@ func (r *ProductRepository) Create(ctx context.Context, product models.Product)
 }
 
 func (r *ProductRepository) FindAll(ctx context.Context) ([]models.Product, error) {
-	cursor, err := r.collection.Find(ctx, bson.M{})
+	cursor, err := r.collection.Find(ctx, models.NotDeleted(nil))
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +64,7 @@ func (r *ProductRepository) FindByID(ctx context.Context, id string) (*models.Pr
 	}
 
 	var product models.Product
-	err = r.collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&product)
+	err = r.collection.FindOne(ctx, models.NotDeleted(bson.M{"_id": objectID})).Decode(&product)
 	if err != nil {
 		return nil, err
 	}
@@ -86,14 +88,43 @@ func (r *ProductRepository) Update(ctx context.Context, id string, product model
 	return err
 }
 
-func (r *ProductRepository) Delete(ctx context.Context, id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+// SoftDelete marca el producto como eliminado, retorna false si no existe o ya estaba eliminado
+func (r *ProductRepository) SoftDelete(ctx context.Context, id, by primitive.ObjectID, at time.Time) (bool, error) {
+	result, err := r.collection.UpdateOne(ctx, models.NotDeleted(bson.M{"_id": id}),
+		bson.M{"$set": bson.M{"deleted_at": at, "deleted_by": by}})
 	if err != nil {
-		return err
+		return false, err
 	}
+	return result.MatchedCount > 0, nil
+}
 
-	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
-	return err
+// Restore quita la marca de eliminado, retorna false si el producto no está eliminado
+func (r *ProductRepository) Restore(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := models.OnlyDeleted()
+	filter["_id"] = id
+	result, err := r.collection.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"deleted_at": "", "deleted_by": ""}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// FindDeletedPaginated lista los productos eliminados, el más reciente primero
+func (r *ProductRepository) FindDeletedPaginated(ctx context.Context, page, pageSize int) ([]*models.Product, int64, error) {
+	products := []*models.Product{}
+	total, err := findDeletedPaginated(ctx, r.collection, page, pageSize, &products)
+	return products, total, err
+}
+
+// DeleteDeletedBefore elimina definitivamente los productos eliminados antes de la fecha y retorna sus IDs
+func (r *ProductRepository) DeleteDeletedBefore(ctx context.Context, fecha time.Time, dryRun bool) ([]primitive.ObjectID, error) {
+	filter := bson.M{"deleted_at": bson.M{"$lt": fecha}}
+	ids, err := findIDs(ctx, r.collection, filter)
+	if err != nil || dryRun || len(ids) == 0 {
+		return ids, err
+	}
+	_, err = r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	return ids, err
 }
 
 func (r *ProductRepository) Search(ctx context.Context, query string) ([]models.Product, error) {
@@ -112,7 +143,7 @@ func (r *ProductRepository) Search(ctx context.Context, query string) ([]models.
 		},
 	}
 
-	cursor, err := r.collection.Find(ctx, filter)
+	cursor, err := r.collection.Find(ctx, models.NotDeleted(filter))
 	if err != nil {
 		return nil, err
 	}
@@ -127,6 +158,7 @@ func (r *ProductRepository) Search(ctx context.Context, query string) ([]models.
 }
 
 func (r *ProductRepository) FindAllPaginated(page, pageSize int, query bson.M) ([]*models.Product, int64, error) {
+	query = models.NotDeleted(query)
 	var products []*models.Product
 	ctx := context.Background()
 	// Obtener el total de registros que coinciden con el filtro
@@ -196,3 +228,19 @@ func (r *ProductRepository) BuildSearchQuery(params map[string]string) bson.M {
 
 	return query
 }
+
+// FindByConvenioKey busca un producto por su clave de convenio marco (id_convenio, id_product, region).
+// Incluye los eliminados, así la importación actualiza el producto eliminado en vez de duplicarlo
+func (r *ProductRepository) FindByConvenioKey(ctx context.Context, idConvenio, idProduct, region string) (*models.Product, error) {
+	filter := bson.M{"id_convenio": idConvenio, "id_product": idProduct, "region": region}
+
+	var product models.Product
+	err := r.collection.FindOne(ctx, filter).Decode(&product)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &product, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"time"
 
 	"catalogo-backend/database"
 	"catalogo-backend/models"
@@ -35,14 +36,27 @@ func NewSolicitudRepository() *SolicitudRepository {
 	return solicitudRepo
 }
 
-func (repo *SolicitudRepository) InsertOne(solicitud *models.Solicitud) error {
-	_, err := repo.collection.InsertOne(context.Background(), solicitud)
+func (repo *SolicitudRepository) InsertOne(ctx context.Context, solicitud *models.Solicitud) error {
+	_, err := repo.collection.InsertOne(ctx, solicitud)
 	return err
 }
 
+// FindByID busca la solicitud con el ctx recibido, para leerla dentro de una transacción
+func (repo *SolicitudRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*models.Solicitud, error) {
+	var solicitud models.Solicitud
+	err := repo.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&solicitud)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &solicitud, nil
+}
+
 func (repo *SolicitudRepository) FindOne(filter bson.M) (*models.Solicitud, error) {
 	var solicitud models.Solicitud
-	err := repo.collection.FindOne(context.Background(), filter).Decode(&solicitud)
+	err := repo.collection.FindOne(context.Background(), models.NotDeleted(filter)).Decode(&solicitud)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
 			return nil, nil
@@ -52,8 +66,8 @@ func (repo *SolicitudRepository) FindOne(filter bson.M) (*models.Solicitud, erro
 	return &solicitud, nil
 }
 
-func (repo *SolicitudRepository) UpdateOne(filter, update bson.M) error {
-	result, err := repo.collection.UpdateOne(context.Background(), filter, update)
+func (repo *SolicitudRepository) UpdateOne(ctx context.Context, filter, update bson.M) error {
+	result, err := repo.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
 		return err
 	}
@@ -63,9 +77,29 @@ func (repo *SolicitudRepository) UpdateOne(filter, update bson.M) error {
 	return nil
 }
 
+// UpdateOneIfMatch aplica el $set solo si la solicitud coincide con el filtro, retorna si hubo coincidencia
+func (repo *SolicitudRepository) UpdateOneIfMatch(ctx context.Context, filter, set bson.M) (bool, error) {
+	result, err := repo.collection.UpdateOne(ctx, models.NotDeleted(filter), bson.M{"$set": set})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// SetLogAnchor registra en la solicitud la secuencia y el hash de su último log. No retrocede si un
+// log posterior ya quedó registrado
+func (repo *SolicitudRepository) SetLogAnchor(ctx context.Context, id primitive.ObjectID, secuencia int64, hash string) error {
+	filter := bson.M{"_id": id, "$or": bson.A{
+		bson.M{"log_secuencia": bson.M{"$lt": secuencia}},
+		bson.M{"log_secuencia": bson.M{"$exists": false}},
+	}}
+	_, err := repo.collection.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"log_secuencia": secuencia, "log_hash": hash}})
+	return err
+}
+
 func (repo *SolicitudRepository) FindAll() ([]*models.Solicitud, error) {
 	var solicitudes []*models.Solicitud
-	cursor, err := repo.collection.Find(context.Background(), bson.M{})
+	cursor, err := repo.collection.Find(context.Background(), models.NotDeleted(nil))
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +111,7 @@ func (repo *SolicitudRepository) FindAll() ([]*models.Solicitud, error) {
 
 // index solicitudes
 func (repo *SolicitudRepository) FindFilteredPaginated(page, pageSize int, filter bson.M) ([]*models.Solicitud, int64, error) {
+	filter = models.NotDeleted(filter)
 	var solicitudes []*models.Solicitud
 
 	total, err := repo.collection.CountDocuments(context.Background(), filter)
@@ -104,18 +139,62 @@ func (repo *SolicitudRepository) FindFilteredPaginated(page, pageSize int, filte
 	return solicitudes, total, nil
 }
 
-func (repo *SolicitudRepository) DeleteOneByID(id primitive.ObjectID) error {
-	result, err := repo.collection.DeleteOne(context.Background(), bson.M{"_id": id})
+// SoftDelete marca la solicitud como eliminada, retorna false si no existe o ya estaba eliminada
+func (repo *SolicitudRepository) SoftDelete(ctx context.Context, id, by primitive.ObjectID, at time.Time) (bool, error) {
+	result, err := repo.collection.UpdateOne(ctx, models.NotDeleted(bson.M{"_id": id}),
+		bson.M{"$set": bson.M{"deleted_at": at, "deleted_by": by}})
 	if err != nil {
-		return err
+		return false, err
 	}
-	if result.DeletedCount == 0 {
-		return errors.New("no se encontró la solicitud")
+	return result.MatchedCount > 0, nil
+}
+
+// Restore quita la marca de eliminada, retorna false si la solicitud no está eliminada
+func (repo *SolicitudRepository) Restore(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := models.OnlyDeleted()
+	filter["_id"] = id
+	result, err := repo.collection.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"deleted_at": "", "deleted_by": ""}})
+	if err != nil {
+		return false, err
 	}
-	return nil
+	return result.MatchedCount > 0, nil
+}
+
+// FindDeletedBefore retorna las solicitudes eliminadas antes de la fecha
+func (repo *SolicitudRepository) FindDeletedBefore(ctx context.Context, fecha time.Time) ([]*models.Solicitud, error) {
+	cursor, err := repo.collection.Find(ctx, bson.M{"deleted_at": bson.M{"$lt": fecha}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	solicitudes := []*models.Solicitud{}
+	if err := cursor.All(ctx, &solicitudes); err != nil {
+		return nil, err
+	}
+	return solicitudes, nil
+}
+
+// FindDeletedPaginated lista las solicitudes eliminadas, la más reciente primero
+func (repo *SolicitudRepository) FindDeletedPaginated(ctx context.Context, page, pageSize int) ([]*models.Solicitud, int64, error) {
+	solicitudes := []*models.Solicitud{}
+	total, err := findDeletedPaginated(ctx, repo.collection, page, pageSize, &solicitudes)
+	return solicitudes, total, err
+}
+
+// DeleteOneByID elimina definitivamente la solicitud, solo lo usa la purga de eliminadas
+func (repo *SolicitudRepository) DeleteOneByID(ctx context.Context, id primitive.ObjectID) error {
+	_, err := repo.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// CountByCC cuenta las solicitudes del centro de costo, incluidas las eliminadas
+func (repo *SolicitudRepository) CountByCC(ctx context.Context, cc primitive.ObjectID) (int64, error) {
+	return repo.collection.CountDocuments(ctx, bson.M{"cc": cc})
 }
 
 func (repo *SolicitudRepository) FindAllPaginated(page, pageSize int, filter bson.M) ([]*models.Solicitud, int64, error) {
+	filter = models.NotDeleted(filter)
 	var solicitudes []*models.Solicitud
 
 	// Obtener el número total de documentos que cumplen con el filtro
@@ -149,10 +228,10 @@ func (repo *SolicitudRepository) FindAllPaginated(page, pageSize int, filter bso
 }
 
 func (repo *SolicitudRepository) FindByCCAndStatePaginated(cc int, state string, page, pageSize int) ([]*models.Solicitud, int64, error) {
-	filter := bson.M{
+	filter := models.NotDeleted(bson.M{
 		"cc":    cc,
 		"state": state,
-	}
+	})
 
 	totalCount, err := repo.collection.CountDocuments(context.Background(), filter)
 	if err != nil {
@@ -177,3 +256,98 @@ func (repo *SolicitudRepository) FindByCCAndStatePaginated(cc int, state string,
 
 	return solicitudes, totalCount, nil
 }
+
+// CountOpenByCC cuenta las solicitudes no eliminadas del centro de costo que no están en los estados cerrados
+func (repo *SolicitudRepository) CountOpenByCC(ctx context.Context, cc primitive.ObjectID, cerrados []string) (int64, error) {
+	return repo.collection.CountDocuments(ctx, models.NotDeleted(bson.M{"cc": cc, "state": bson.M{"$nin": cerrados}}))
+}
+
+// FindOpenByCC retorna las solicitudes no eliminadas del centro de costo que no están en los estados cerrados
+func (repo *SolicitudRepository) FindOpenByCC(ctx context.Context, cc primitive.ObjectID, cerrados []string) ([]*models.Solicitud, error) {
+	cursor, err := repo.collection.Find(ctx, models.NotDeleted(bson.M{"cc": cc, "state": bson.M{"$nin": cerrados}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	solicitudes := []*models.Solicitud{}
+	if err := cursor.All(ctx, &solicitudes); err != nil {
+		return nil, err
+	}
+	return solicitudes, nil
+}
+
+// FindPendingStepsOf retorna las solicitudes no eliminadas del centro de costo en los estados indicados
+// que tienen un paso de aprobación pendiente del tipo indicado asignado al aprobador
+func (repo *SolicitudRepository) FindPendingStepsOf(ctx context.Context, cc primitive.ObjectID, estados []string, tipo models.ApproverType, aprobador primitive.ObjectID) ([]*models.Solicitud, error) {
+	paso := bson.M{"tipo": tipo, "estado": models.ApprovalStepPendiente, "aprobador": aprobador}
+	if aprobador.IsZero() {
+		paso["aprobador"] = bson.M{"$in": bson.A{nil, primitive.NilObjectID}}
+	}
+	filter := models.NotDeleted(bson.M{
+		"cc":           cc,
+		"state":        bson.M{"$in": estados},
+		"aprobaciones": bson.M{"$elemMatch": paso},
+	})
+	cursor, err := repo.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	solicitudes := []*models.Solicitud{}
+	if err := cursor.All(ctx, &solicitudes); err != nil {
+		return nil, err
+	}
+	return solicitudes, nil
+}
+
+// CCStateTotal es la suma de importe_reporte de las solicitudes de un centro de costo en un estado
+type CCStateTotal struct {
+	CC       primitive.ObjectID `bson:"cc"`
+	State    string             `bson:"state"`
+	Total    float64            `bson:"total"`
+	Cantidad int64              `bson:"cantidad"`
+}
+
+// SumByCCAndState suma importe_reporte de las solicitudes no eliminadas de los centros de costo en los
+// estados indicados, con moneda_reporte igual a moneda y fecha_solicitud en [desde, hasta). Las fechas
+// cero no filtran
+func (repo *SolicitudRepository) SumByCCAndState(ctx context.Context, ccs []primitive.ObjectID, estados []string, moneda string, desde, hasta time.Time) ([]CCStateTotal, error) {
+	match := models.NotDeleted(bson.M{
+		"cc":             bson.M{"$in": ccs},
+		"state":          bson.M{"$in": estados},
+		"moneda_reporte": moneda,
+	})
+	fecha := bson.M{}
+	if !desde.IsZero() {
+		fecha["$gte"] = desde
+	}
+	if !hasta.IsZero() {
+		fecha["$lt"] = hasta
+	}
+	if len(fecha) > 0 {
+		match["fecha_solicitud"] = fecha
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"cc": "$cc", "state": "$state"},
+			"total":    bson.M{"$sum": "$importe_reporte"},
+			"cantidad": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "cc": "$_id.cc", "state": "$_id.state", "total": 1, "cantidad": 1}}},
+	}
+	cursor, err := repo.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	totales := []CCStateTotal{}
+	if err := cursor.All(ctx, &totales); err != nil {
+		return nil, err
+	}
+	return totales, nil
+}
@@ -13,6 +13,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var userRepo *UserRepository
@@ -142,3 +143,93 @@ func (userRepo *UserRepository) AddCCToUser(userID, ccID primitive.ObjectID) err
 	_, err := userRepo.collection.UpdateOne(context.Background(), filter, update)
 	return err
 }
+
+// PullCC quita el centro de costo de todos los usuarios
+func (userRepo *UserRepository) PullCC(ctx context.Context, ccID primitive.ObjectID) error {
+	_, err := userRepo.collection.UpdateMany(ctx, bson.M{"cc": ccID}, bson.M{"$pull": bson.M{"cc": ccID, "cc_observador": ccID}})
+	return err
+}
+
+// FindIDsByCC retorna los IDs de los usuarios del centro de costo
+func (userRepo *UserRepository) FindIDsByCC(ctx context.Context, ccID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return findIDs(ctx, userRepo.collection, bson.M{"cc": ccID})
+}
+
+// MoveCC reemplaza el centro de costo origen por destino en todos los usuarios que tienen origen
+func (userRepo *UserRepository) MoveCC(ctx context.Context, origen, destino primitive.ObjectID) error {
+	// los observadores de origen siguen siendo observadores en destino, salvo que ya fueran miembros de destino
+	_, err := userRepo.collection.UpdateMany(ctx, bson.M{"cc_observador": origen, "cc": bson.M{"$ne": destino}},
+		bson.M{"$addToSet": bson.M{"cc_observador": destino}})
+	if err != nil {
+		return err
+	}
+	// primero se agrega destino, no se puede hacer $addToSet y $pull sobre el mismo campo en una operación
+	_, err = userRepo.collection.UpdateMany(ctx, bson.M{"cc": origen}, bson.M{"$addToSet": bson.M{"cc": destino}})
+	if err != nil {
+		return err
+	}
+	return userRepo.PullCC(ctx, origen)
+}
+
+// AddCC agrega el centro de costo al usuario con el ctx recibido, para usarlo dentro de una transacción
+func (userRepo *UserRepository) AddCC(ctx context.Context, userID, ccID primitive.ObjectID) error {
+	_, err := userRepo.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$addToSet": bson.M{"cc": ccID}})
+	return err
+}
+
+// RemoveCC quita el centro de costo del usuario, con su rol
+func (userRepo *UserRepository) RemoveCC(ctx context.Context, userID, ccID primitive.ObjectID) error {
+	_, err := userRepo.collection.UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$pull": bson.M{"cc": ccID, "cc_observador": ccID}})
+	return err
+}
+
+// SetPassword guarda el hash de la contraseña del usuario, retorna false si no existe
+func (userRepo *UserRepository) SetPassword(ctx context.Context, email, hash string) (bool, error) {
+	result, err := userRepo.collection.UpdateOne(ctx, bson.M{"email": email}, bson.M{"$set": bson.M{"password": hash}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// SetMembership agrega el centro de costo al usuario si no lo tiene y fija si es observador en él
+func (userRepo *UserRepository) SetMembership(ctx context.Context, userID, ccID primitive.ObjectID, observador bool) error {
+	update := bson.M{"$addToSet": bson.M{"cc": ccID}}
+	if observador {
+		update["$addToSet"] = bson.M{"cc": ccID, "cc_observador": ccID}
+	} else {
+		update["$pull"] = bson.M{"cc_observador": ccID}
+	}
+	_, err := userRepo.collection.UpdateOne(ctx, bson.M{"_id": userID}, update)
+	return err
+}
+
+// FindMembersPaginated lista los usuarios del centro de costo, y su jefe aunque no lo tenga en cc,
+// ordenados por nombre de usuario
+func (userRepo *UserRepository) FindMembersPaginated(ctx context.Context, ccID, jefe primitive.ObjectID, page, pageSize int) ([]*models.User, int64, error) {
+	filter := bson.M{"cc": ccID}
+	if !jefe.IsZero() {
+		filter = bson.M{"$or": []bson.M{{"cc": ccID}, {"_id": jefe}}}
+	}
+	total, err := userRepo.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize)).
+		SetSort(bson.D{{Key: "username", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := userRepo.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []*models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
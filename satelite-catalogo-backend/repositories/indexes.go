@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"log"
+)
+
+// EnsureIndexes crea los índices de las colecciones al iniciar el servidor. CreateMany no hace nada
+// si el índice ya existe, por lo que es seguro llamarlo en cada inicio
+func EnsureIndexes(ctx context.Context) {
+	if err := NewLogRepository().EnsureIndexes(ctx); err != nil {
+		log.Println("Error al crear los índices de logs:", err)
+	}
+	if err := NewAPIKeyRepository().EnsureIndexes(ctx); err != nil {
+		log.Println("Error al crear los índices de api keys:", err)
+	}
+}
@@ -3,26 +3,34 @@ package routes
 import (
 	"catalogo-backend/controllers"
 	"catalogo-backend/middleware"
+	"catalogo-backend/models"
 
 	"github.com/gin-gonic/gin"
 )
 
 func RegisterRoutes(router *gin.Engine) {
+	// Roles requeridos por las rutas, las rutas sin SetRoles quedan disponibles para cualquier usuario autenticado.
+	// SetRoles verifica los roles actuales del principal, los grupos que lo usan deben cargar LoadPrincipal
+	soloAdmin := middleware.SetRoles(models.ADMIN)
+
 	// Expone los archivos estáticos de uploads solo para usuarios autenticados
 	archivosGroup := router.Group("/archivos")
-	archivosGroup.Use(middleware.LoadJWTAuth().MiddlewareFunc())
+	archivosGroup.Use(middleware.Authenticate())
 	archivosGroup.GET("/*filepath", controllers.ServeArchivo)
 	// User routes
 	userGroup := router.Group("/user")
-	userGroup.Use(middleware.LoadJWTAuth().MiddlewareFunc())
+	userGroup.Use(middleware.Authenticate(), middleware.LoadPrincipal())
 	{
-		userGroup.POST("/", controllers.CreateUser)
+		userGroup.POST("/", soloAdmin, controllers.CreateUser)
 		userGroup.GET("/:id", controllers.GetUserById)
 		userGroup.GET("/email/:email", controllers.GetUserByEmail)
-		userGroup.PUT("/:id", controllers.UpdateUser)
-		userGroup.GET("/", controllers.GetAllUsers)
-		userGroup.DELETE("/:id", controllers.DeleteUser)
+		userGroup.PUT("/:id", soloAdmin, controllers.UpdateUser)
+		userGroup.GET("/", soloAdmin, controllers.GetAllUsers)
+		userGroup.DELETE("/:id", soloAdmin, controllers.DeleteUser)
 		userGroup.POST("/by-cc", controllers.GetUsersByCC)
+		userGroup.GET("/:id/cc", soloAdmin, controllers.GetUserMemberships)
+		userGroup.PUT("/:id/cc/:cc", soloAdmin, controllers.SetUserMembership)
+		userGroup.DELETE("/:id/cc/:cc", soloAdmin, controllers.RemoveUserMembership)
 	}
 
 	// Auth routes
@@ -31,11 +39,17 @@ func RegisterRoutes(router *gin.Engine) {
 		authGroup.POST("/login", middleware.LoadJWTAuth().LoginHandler)
 		authGroup.POST("/refresh_token", middleware.LoadJWTAuth().RefreshHandler)
 		authGroup.POST("/logout", middleware.LoadJWTAuth().LogoutHandler)
+		// inicio de sesión único con el IdP institucional
+		authGroup.GET("/oidc/login", controllers.OIDCLogin)
+		authGroup.GET("/oidc/callback", controllers.OIDCCallback)
+		authGroup.GET("/saml/metadata", controllers.SAMLMetadata)
+		authGroup.GET("/saml/login", controllers.SAMLLogin)
+		authGroup.POST("/saml/acs", controllers.SAMLACS)
 	}
 
 	// Solicitud routes
 	solicitudGroup := router.Group("/solicitud")
-	solicitudGroup.Use(middleware.LoadJWTAuth().MiddlewareFunc())
+	solicitudGroup.Use(middleware.Authenticate(), middleware.LoadPrincipal())
 	{
 		solicitudGroup.GET("/filtradas", controllers.GetSolicitudesFiltradasPaginated)
 		solicitudGroup.POST("/", controllers.CreateSolicitud)
@@ -43,28 +57,113 @@ func RegisterRoutes(router *gin.Engine) {
 		solicitudGroup.PUT("/:id", controllers.UpdateSolicitud)
 		solicitudGroup.GET("/:id", controllers.GetSolicitud)
 		solicitudGroup.DELETE("/:id", controllers.DeleteSolicitud)
+		solicitudGroup.POST("/:id/restore", soloAdmin, controllers.RestoreSolicitud)
+		solicitudGroup.GET("/:id/history", controllers.GetSolicitudHistory)
+		solicitudGroup.GET("/:id/transitions", controllers.GetTransitionsSolicitud)
+		solicitudGroup.POST("/:id/transitions/:transition", controllers.TransitionSolicitud)
+		solicitudGroup.POST("/:id/lines/:numero/approve", controllers.ApproveLineSolicitud)
+		solicitudGroup.POST("/:id/lines/:numero/reject", controllers.RejectLineSolicitud)
+		// cualquier usuario puede ser aprobador de un paso, el listado se filtra por el paso pendiente
 		solicitudGroup.GET("/aprobar", controllers.GetSolicitudesAprobarPaginated)
 	}
 	// Centro de Costo routes
 	ccGroup := router.Group("/cc")
-	ccGroup.Use(middleware.LoadJWTAuth().MiddlewareFunc())
+	ccGroup.Use(middleware.Authenticate(), middleware.LoadPrincipal())
 	{
-		ccGroup.POST("/", controllers.CreateCentroCosto)
+		ccGroup.POST("/", soloAdmin, controllers.CreateCentroCosto)
+		ccGroup.POST("/import", soloAdmin, controllers.ImportCentrosCosto)
+		ccGroup.POST("/import-memberships", soloAdmin, controllers.ImportCentroCostoMemberships)
 		ccGroup.GET("/:id", controllers.GetCentroCostoByID)
-		ccGroup.PUT("/:id", controllers.UpdateCentroCosto)
+		ccGroup.PUT("/:id", soloAdmin, controllers.UpdateCentroCosto)
 		ccGroup.GET("/", controllers.GetAllCentroCostos)
-		ccGroup.DELETE("/:id", controllers.DeleteCentroCosto)
+		ccGroup.DELETE("/:id", soloAdmin, controllers.DeleteCentroCosto)
+		ccGroup.POST("/:id/restore", soloAdmin, controllers.RestoreCentroCosto)
+		ccGroup.GET("/:id/dependents", soloAdmin, controllers.GetCentroCostoDependents)
+		ccGroup.POST("/:id/reassign", soloAdmin, controllers.ReassignCentroCosto)
+		ccGroup.PUT("/:id/jefe", soloAdmin, controllers.ReassignJefeCentroCosto)
+		ccGroup.GET("/:id/subtree", controllers.GetCentroCostoSubtree)
+		ccGroup.GET("/:id/spend", controllers.GetCentroCostoSpend)
+		ccGroup.GET("/:id/members", controllers.GetCentroCostoMembers)
 	}
 
 	products := router.Group("/product")
-	products.Use(middleware.LoadJWTAuth().MiddlewareFunc())
+	products.Use(middleware.Authenticate(), middleware.LoadPrincipal())
 	{
-		products.POST("/", controllers.CreateProduct)
+		products.POST("/", soloAdmin, controllers.CreateProduct)
+		products.POST("/import", soloAdmin, controllers.ImportProducts)
 		products.GET("/", controllers.GetAllProducts)
 		products.GET("/paginated", controllers.GetProductsPaginated)
 		products.GET("/filtradas", controllers.GetProductsFiltradasPaginated)
 		products.GET("/:id", controllers.GetProductByID)
-		products.PUT("/:id", controllers.UpdateProduct)
-		products.DELETE("/:id", controllers.DeleteProduct)
+		products.GET("/:id/price-history", controllers.GetProductPriceHistory)
+		products.PUT("/:id", soloAdmin, controllers.UpdateProduct)
+		products.DELETE("/:id", soloAdmin, controllers.DeleteProduct)
+		products.POST("/:id/restore", soloAdmin, controllers.RestoreProduct)
+	}
+
+	// Tipos de cambio diarios
+	exchangeRates := router.Group("/exchange-rate")
+	exchangeRates.Use(middleware.Authenticate(), middleware.LoadPrincipal())
+	{
+		exchangeRates.GET("/", controllers.GetExchangeRates)
+		exchangeRates.POST("/", soloAdmin, controllers.SaveExchangeRates)
+		exchangeRates.POST("/import", soloAdmin, controllers.ImportExchangeRates)
+	}
+
+	// Presupuestos por centro de costo
+	budgets := router.Group("/budget")
+	budgets.Use(middleware.Authenticate(), middleware.LoadPrincipal())
+	{
+		budgets.POST("/", soloAdmin, controllers.CreateBudget)
+		budgets.GET("/", controllers.GetBudgets)
+		budgets.GET("/cc/:cc/balance", controllers.GetBudgetBalance)
+		budgets.GET("/:id", controllers.GetBudget)
+		budgets.PUT("/:id", soloAdmin, controllers.UpdateBudget)
+	}
+
+	// Políticas de aprobación por monto
+	approvalPolicies := router.Group("/approval-policy")
+	approvalPolicies.Use(middleware.Authenticate(), middleware.LoadPrincipal(), soloAdmin)
+	{
+		approvalPolicies.POST("/", controllers.CreateApprovalPolicy)
+		approvalPolicies.GET("/", controllers.GetApprovalPolicies)
+		approvalPolicies.GET("/:id", controllers.GetApprovalPolicy)
+		approvalPolicies.PUT("/:id", controllers.UpdateApprovalPolicy)
+		approvalPolicies.DELETE("/:id", controllers.DeleteApprovalPolicy)
+	}
+
+	// Delegaciones de la autoridad de aprobación
+	delegations := router.Group("/delegation")
+	delegations.Use(middleware.Authenticate(), middleware.LoadPrincipal())
+	{
+		delegations.POST("/", controllers.CreateDelegation)
+		delegations.GET("/", controllers.GetDelegations)
+		delegations.DELETE("/:id", controllers.RevokeDelegation)
+	}
+
+	// Papelera: registros eliminados lógicamente y su purga
+	trash := router.Group("/trash")
+	trash.Use(middleware.Authenticate(), middleware.LoadPrincipal(), soloAdmin)
+	{
+		trash.GET("/", controllers.GetDeleted)
+		trash.POST("/purge", controllers.PurgeDeleted)
+	}
+
+	// Auditoría de los logs de todas las solicitudes
+	audit := router.Group("/audit")
+	audit.Use(middleware.Authenticate(), middleware.LoadPrincipal(), soloAdmin)
+	{
+		audit.GET("/logs", controllers.SearchAuditLogs)
+		audit.GET("/solicitud/:id/verify", controllers.VerifyLogChain)
+		audit.GET("/memberships", controllers.SearchMembershipLogs)
+	}
+
+	// API keys para integraciones y cuentas de servicio, solo se administran con sesión iniciada
+	apiKeys := router.Group("/api-key")
+	apiKeys.Use(middleware.Authenticate(), middleware.LoadPrincipal(), middleware.RequireSession(), soloAdmin)
+	{
+		apiKeys.POST("/", controllers.CreateAPIKey)
+		apiKeys.GET("/", controllers.GetAPIKeys)
+		apiKeys.DELETE("/:id", controllers.RevokeAPIKey)
 	}
 }
@@ -0,0 +1,146 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"catalogo-backend/middleware"
+	"catalogo-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rutas que solo puede usar un administrador, una por grupo con SetRoles
+var rutasAdmin = []struct{ method, path string }{
+	{http.MethodGet, "/user/"},
+	{http.MethodPost, "/user/"},
+	{http.MethodDelete, "/user/someone@usach.cl"},
+	{http.MethodPost, "/solicitud/000000000000000000000001/restore"},
+	{http.MethodPost, "/cc/"},
+	{http.MethodPut, "/cc/000000000000000000000001/jefe"},
+	{http.MethodPost, "/product/"},
+	{http.MethodPost, "/product/import"},
+	{http.MethodPost, "/exchange-rate/"},
+	{http.MethodPost, "/budget/"},
+	{http.MethodGet, "/approval-policy/"},
+	{http.MethodGet, "/trash/"},
+	{http.MethodGet, "/audit/logs"},
+	{http.MethodGet, "/api-key/"},
+}
+
+// newRouter arma las rutas con un principal fijo en vez del que se carga de la base de datos
+func newRouter(t *testing.T, principal *models.Principal) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	anterior := middleware.PrincipalLoader
+	middleware.PrincipalLoader = func(userID primitive.ObjectID) (*models.Principal, error) {
+		p := *principal
+		p.UserID = userID
+		return &p, nil
+	}
+	t.Cleanup(func() { middleware.PrincipalLoader = anterior })
+
+	router := gin.New()
+	RegisterRoutes(router)
+	return router
+}
+
+func token(t *testing.T, roles ...models.Role) string {
+	t.Helper()
+	tok, _, err := middleware.LoadJWTAuth().TokenGenerator(models.User{ID: primitive.NewObjectID(), Username: "test", Role: roles})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tok
+}
+
+func request(router *gin.Engine, method, path, tok string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if tok != "" {
+		req.Header.Set("Authorization", "Bearer "+tok)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestUsuarioNoAccedeARutasAdmin(t *testing.T) {
+	router := newRouter(t, &models.Principal{Roles: []models.Role{models.USER}})
+	tok := token(t, models.USER)
+	for _, ruta := range rutasAdmin {
+		if code := request(router, ruta.method, ruta.path, tok); code != http.StatusForbidden {
+			t.Errorf("%s %s: status %d, se esperaba 403", ruta.method, ruta.path, code)
+		}
+	}
+}
+
+// un administrador degradado conserva el rol en el jwt hasta que expira, pero se usan sus roles actuales
+func TestAdminDegradadoNoAccedeARutasAdmin(t *testing.T) {
+	router := newRouter(t, &models.Principal{Roles: []models.Role{models.USER}})
+	tok := token(t, models.ADMIN)
+	for _, ruta := range rutasAdmin {
+		if code := request(router, ruta.method, ruta.path, tok); code != http.StatusForbidden {
+			t.Errorf("%s %s: status %d, se esperaba 403", ruta.method, ruta.path, code)
+		}
+	}
+}
+
+func TestRutasAdminRequierenToken(t *testing.T) {
+	router := newRouter(t, &models.Principal{Roles: []models.Role{models.ADMIN}})
+	for _, ruta := range rutasAdmin {
+		if code := request(router, ruta.method, ruta.path, ""); code != http.StatusUnauthorized {
+			t.Errorf("%s %s: status %d, se esperaba 401", ruta.method, ruta.path, code)
+		}
+	}
+}
+
+func TestSetRolesUsaRolesDelPrincipal(t *testing.T) {
+	casos := []struct {
+		nombre     string
+		tokenRoles []models.Role
+		roles      []models.Role
+		esperado   int
+	}{
+		{"admin", []models.Role{models.ADMIN}, []models.Role{models.ADMIN}, http.StatusNoContent},
+		{"usuario", []models.Role{models.USER}, []models.Role{models.USER}, http.StatusForbidden},
+		{"admin degradado", []models.Role{models.ADMIN}, []models.Role{models.USER}, http.StatusForbidden},
+		{"promovido sin nuevo token", []models.Role{models.USER}, []models.Role{models.USER, models.ADMIN}, http.StatusNoContent},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			router := newRouter(t, &models.Principal{Roles: c.roles})
+			group := router.Group("/test")
+			group.Use(middleware.Authenticate(), middleware.LoadPrincipal(), middleware.SetRoles(models.ADMIN))
+			group.GET("/", func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) })
+
+			if code := request(router, http.MethodGet, "/test/", token(t, c.tokenRoles...)); code != c.esperado {
+				t.Errorf("status %d, se esperaba %d", code, c.esperado)
+			}
+		})
+	}
+}
+
+// las claves con punto o con $ no deben llegar al $set de la actualización
+func TestUpdateSolicitudRechazaClavesAnidadas(t *testing.T) {
+	router := newRouter(t, &models.Principal{Roles: []models.Role{models.USER}})
+	tok := token(t, models.USER)
+	for _, body := range []string{
+		`{"lines.0.estado": "A"}`,
+		`{"lines.0.cantidad": 1000}`,
+		`{"aprobaciones.0.estado": "A"}`,
+		`{"presupuesto.estado": "liberado"}`,
+		`{"description": "x", "$set": {"state": "A"}}`,
+	} {
+		req := httptest.NewRequest(http.MethodPut, "/solicitud/000000000000000000000001", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+tok)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, se esperaba 400", body, w.Code)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"catalogo-backend/models"
+	"catalogo-backend/repositories"
+	"catalogo-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// apiKeyStore son las operaciones sobre api_keys que usa el servicio, las pruebas la reemplazan por
+// una en memoria
+type apiKeyStore interface {
+	InsertOne(ctx context.Context, key *models.APIKey) error
+	FindByID(ctx context.Context, id primitive.ObjectID) (*models.APIKey, error)
+	FindByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	Revoke(ctx context.Context, id, by primitive.ObjectID, at time.Time) (bool, error)
+	FindPaginated(ctx context.Context, filter bson.M, page, pageSize int) ([]*models.APIKey, int64, error)
+}
+
+var (
+	apiKeyRepo  apiKeyStore
+	onceAPIKey  sync.Once
+	apiKeyTouch sync.Map // último registro de uso por key, para no escribir en cada llamada
+	// apiKeyPrincipal resuelve el usuario de la key, las pruebas lo reemplazan
+	apiKeyPrincipal = GetPrincipalService
+)
+
+var (
+	ErrAPIKeyInvalida        = errors.New("API key inválida, expirada o revocada")
+	ErrAPIKeyNoEncontrada    = errors.New("API key no encontrada")
+	apiKeyVigenciaPorDefecto = 90 * 24 * time.Hour
+)
+
+// prefijo de las keys, permite reconocerlas si se filtran en logs o repositorios
+const apiKeyPrefix = "cat_"
+
+func getAPIKeyRepo() apiKeyStore {
+	onceAPIKey.Do(func() {
+		apiKeyRepo = repositories.NewAPIKeyRepository()
+	})
+	return apiKeyRepo
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func newAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// validateAPIKey verifica la key, sus roles deben ser roles efectivos del usuario
+func validateAPIKey(key *models.APIKey) error {
+	verr := &ValidationError{}
+	key.Nombre = strings.TrimSpace(key.Nombre)
+	if key.Nombre == "" {
+		verr.add("nombre", "es obligatorio")
+	}
+	if key.ExpiresAt.IsZero() {
+		key.ExpiresAt = time.Now().Add(apiKeyVigenciaPorDefecto)
+	} else if !key.ExpiresAt.After(time.Now()) {
+		verr.add("expires_at", "debe ser una fecha futura")
+	}
+	if len(key.Roles) == 0 {
+		verr.add("roles", "debe indicar al menos un rol")
+	}
+
+	if key.Usuario.IsZero() {
+		verr.add("usuario", "es obligatorio")
+	} else {
+		principal, err := apiKeyPrincipal(key.Usuario)
+		if errors.Is(err, ErrUsuarioNoEncontrado) {
+			verr.add("usuario", "el usuario no existe")
+		} else if err != nil {
+			return err
+		} else {
+			for i, rol := range key.Roles {
+				if !principal.HasRole(rol) {
+					verr.add(fmt.Sprintf("roles[%d]", i), "el usuario no tiene el rol %s", rol)
+				}
+			}
+		}
+	}
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// CreateAPIKeyService crea una API key para el usuario con los roles indicados, que deben ser
+// roles que el usuario tiene. Sin expires_at la key vence en 90 días. La key en texto plano solo
+// se retorna en esta llamada, se guarda su hash
+func CreateAPIKeyService(ctx context.Context, key *models.APIKey) (*models.CreatedAPIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if err := validateAPIKey(key); err != nil {
+		return nil, err
+	}
+	plain, err := newAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key.ID = primitive.NewObjectID()
+	key.Prefijo = plain[:len(apiKeyPrefix)+8]
+	key.Hash = hashAPIKey(plain)
+	key.CreatedBy = utils.GetRequestInfo(ctx).UserID
+	key.CreatedAt = time.Now()
+	key.LastUsedAt = nil
+	key.RevokedAt = nil
+	key.RevokedBy = primitive.NilObjectID
+	if err := getAPIKeyRepo().InsertOne(ctx, key); err != nil {
+		return nil, err
+	}
+	return &models.CreatedAPIKey{APIKey: *key, Key: plain}, nil
+}
+
+// RevokeAPIKeyService revoca la key, deja de aceptarse en la siguiente llamada
+func RevokeAPIKeyService(ctx context.Context, id string) (*models.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("formato de ID inválido: %s", id)
+	}
+	key, err := getAPIKeyRepo().FindByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return nil, ErrAPIKeyNoEncontrada
+	}
+
+	ahora := time.Now()
+	by := utils.GetRequestInfo(ctx).UserID
+	revoked, err := getAPIKeyRepo().Revoke(ctx, objID, by, ahora)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		key.RevokedAt = &ahora
+		key.RevokedBy = by
+	}
+	return key, nil
+}
+
+// GetAPIKeysService lista las keys, opcionalmente de un usuario o solo las vigentes
+func GetAPIKeysService(usuario primitive.ObjectID, soloVigentes bool, page, pageSize int) ([]*models.APIKey, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if !usuario.IsZero() {
+		filter["usuario"] = usuario
+	}
+	if soloVigentes {
+		filter["expires_at"] = bson.M{"$gt": time.Now()}
+		filter["revoked_at"] = bson.M{"$exists": false}
+	}
+	return getAPIKeyRepo().FindPaginated(ctx, filter, page, pageSize)
+}
+
+// AuthenticateAPIKeyService resuelve la key recibida en el principal de su usuario, limitado a los
+// roles de la key. Retorna ErrAPIKeyInvalida si la key no existe, expiró o fue revocada
+func AuthenticateAPIKeyService(ctx context.Context, plain string) (*models.APIKey, *models.Principal, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	if !strings.HasPrefix(plain, apiKeyPrefix) {
+		return nil, nil, ErrAPIKeyInvalida
+	}
+	key, err := getAPIKeyRepo().FindByHash(ctx, hashAPIKey(plain))
+	if err != nil {
+		return nil, nil, err
+	}
+	ahora := time.Now()
+	if key == nil || !key.IsActive(ahora) {
+		return nil, nil, ErrAPIKeyInvalida
+	}
+
+	principal, err := apiKeyPrincipal(key.Usuario)
+	if errors.Is(err, ErrUsuarioNoEncontrado) {
+		return nil, nil, ErrAPIKeyInvalida
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	principal.RestrictRoles(key.Roles)
+
+	// el último uso se registra a lo más una vez por minuto por key
+	if last, ok := apiKeyTouch.Load(key.ID); !ok || ahora.Sub(last.(time.Time)) > time.Minute {
+		apiKeyTouch.Store(key.ID, ahora)
+		if err := getAPIKeyRepo().Touch(ctx, key.ID, ahora); err == nil {
+			key.LastUsedAt = &ahora
+		}
+	}
+	return key, principal, nil
+}
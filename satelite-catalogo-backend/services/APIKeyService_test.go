@@ -0,0 +1,236 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memAPIKeys guarda las keys en memoria
+type memAPIKeys struct {
+	mu   sync.Mutex
+	keys map[primitive.ObjectID]models.APIKey
+}
+
+func (m *memAPIKeys) InsertOne(ctx context.Context, key *models.APIKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[key.ID] = *key
+	return nil
+}
+
+func (m *memAPIKeys) FindByID(ctx context.Context, id primitive.ObjectID) (*models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.keys[id]
+	if !ok {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+func (m *memAPIKeys) FindByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range m.keys {
+		if key.Hash == hash {
+			return &key, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memAPIKeys) Touch(ctx context.Context, id primitive.ObjectID, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := m.keys[id]
+	key.LastUsedAt = &at
+	m.keys[id] = key
+	return nil
+}
+
+func (m *memAPIKeys) Revoke(ctx context.Context, id, by primitive.ObjectID, at time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, ok := m.keys[id]
+	if !ok || key.RevokedAt != nil {
+		return false, nil
+	}
+	key.RevokedAt, key.RevokedBy = &at, by
+	m.keys[id] = key
+	return true, nil
+}
+
+func (m *memAPIKeys) FindPaginated(ctx context.Context, filter bson.M, page, pageSize int) ([]*models.APIKey, int64, error) {
+	return nil, 0, errors.New("no implementado")
+}
+
+// usarAPIKeysEnMemoria reemplaza api_keys y los usuarios por los de la prueba
+func usarAPIKeysEnMemoria(t *testing.T, usuarios ...*models.Principal) *memAPIKeys {
+	t.Helper()
+	store := &memAPIKeys{keys: map[primitive.ObjectID]models.APIKey{}}
+	onceAPIKey.Do(func() {})
+	anteriorRepo, anteriorPrincipal := apiKeyRepo, apiKeyPrincipal
+	apiKeyRepo = store
+	apiKeyPrincipal = func(id primitive.ObjectID) (*models.Principal, error) {
+		for _, u := range usuarios {
+			if u.UserID == id {
+				p := *u
+				return &p, nil
+			}
+		}
+		return nil, ErrUsuarioNoEncontrado
+	}
+	t.Cleanup(func() { apiKeyRepo, apiKeyPrincipal = anteriorRepo, anteriorPrincipal })
+	return store
+}
+
+func jefeAdmin() *models.Principal {
+	return &models.Principal{
+		UserID: primitive.NewObjectID(),
+		Roles:  []models.Role{models.ADMIN, models.USER, models.JEFE},
+		JefeDe: []primitive.ObjectID{primitive.NewObjectID()},
+	}
+}
+
+func crearAPIKey(t *testing.T, usuario primitive.ObjectID, roles ...models.Role) *models.CreatedAPIKey {
+	t.Helper()
+	created, err := CreateAPIKeyService(context.Background(), &models.APIKey{Nombre: "integración", Usuario: usuario, Roles: roles})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return created
+}
+
+func TestAPIKeyLimitaLosRolesDelUsuario(t *testing.T) {
+	usuario := jefeAdmin()
+	usarAPIKeysEnMemoria(t, usuario)
+	created := crearAPIKey(t, usuario.UserID, models.USER)
+
+	key, principal, err := AuthenticateAPIKeyService(context.Background(), created.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.ID != created.ID || principal.UserID != usuario.UserID {
+		t.Fatalf("key %s de %s, se esperaba %s de %s", key.ID.Hex(), principal.UserID.Hex(), created.ID.Hex(), usuario.UserID.Hex())
+	}
+	if principal.IsAdmin() || principal.HasRole(models.JEFE) || !principal.HasRole(models.USER) {
+		t.Errorf("roles %v, se esperaba solo %s", principal.Roles, models.USER)
+	}
+	if len(principal.JefeDe) > 0 {
+		t.Error("sin el rol Jefe la key no debe conservar las jefaturas")
+	}
+	if key.LastUsedAt == nil {
+		t.Error("no se registró el último uso")
+	}
+}
+
+func TestAPIKeyConRolJefeConservaJefaturas(t *testing.T) {
+	usuario := jefeAdmin()
+	usarAPIKeysEnMemoria(t, usuario)
+	created := crearAPIKey(t, usuario.UserID, models.JEFE)
+
+	_, principal, err := AuthenticateAPIKeyService(context.Background(), created.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if principal.IsAdmin() || len(principal.JefeDe) != 1 {
+		t.Errorf("roles %v y jefaturas %v, se esperaba Jefe con su centro de costo", principal.Roles, principal.JefeDe)
+	}
+}
+
+func TestAPIKeySoloConRolesDelUsuario(t *testing.T) {
+	usuario := &models.Principal{UserID: primitive.NewObjectID(), Roles: []models.Role{models.USER}}
+	store := usarAPIKeysEnMemoria(t, usuario)
+
+	_, err := CreateAPIKeyService(context.Background(), &models.APIKey{Nombre: "escalada", Usuario: usuario.UserID, Roles: []models.Role{models.ADMIN}})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error %v, se esperaba un ValidationError", err)
+	}
+	if len(store.keys) != 0 {
+		t.Error("se guardó la key")
+	}
+}
+
+func TestAPIKeyExpirada(t *testing.T) {
+	usuario := jefeAdmin()
+	store := usarAPIKeysEnMemoria(t, usuario)
+	created := crearAPIKey(t, usuario.UserID, models.USER)
+
+	vencida := store.keys[created.ID]
+	vencida.ExpiresAt = time.Now().Add(-time.Minute)
+	store.keys[created.ID] = vencida
+
+	if _, _, err := AuthenticateAPIKeyService(context.Background(), created.Key); !errors.Is(err, ErrAPIKeyInvalida) {
+		t.Errorf("error %v, se esperaba ErrAPIKeyInvalida", err)
+	}
+
+	_, err := CreateAPIKeyService(context.Background(), &models.APIKey{Nombre: "vencida", Usuario: usuario.UserID, Roles: []models.Role{models.USER}, ExpiresAt: time.Now().Add(-time.Hour)})
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("error %v, una key no puede crearse vencida", err)
+	}
+}
+
+func TestAPIKeyVenceEn90DiasPorDefecto(t *testing.T) {
+	usuario := jefeAdmin()
+	usarAPIKeysEnMemoria(t, usuario)
+	created := crearAPIKey(t, usuario.UserID, models.USER)
+
+	if d := time.Until(created.ExpiresAt); d <= 89*24*time.Hour || d > 90*24*time.Hour {
+		t.Errorf("vence en %v, se esperaban 90 días", d)
+	}
+}
+
+func TestAPIKeyRevocada(t *testing.T) {
+	usuario := jefeAdmin()
+	usarAPIKeysEnMemoria(t, usuario)
+	created := crearAPIKey(t, usuario.UserID, models.USER)
+	if _, _, err := AuthenticateAPIKeyService(context.Background(), created.Key); err != nil {
+		t.Fatal(err)
+	}
+
+	revocada, err := RevokeAPIKeyService(context.Background(), created.ID.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revocada.RevokedAt == nil {
+		t.Error("la key retornada no quedó revocada")
+	}
+	if _, _, err := AuthenticateAPIKeyService(context.Background(), created.Key); !errors.Is(err, ErrAPIKeyInvalida) {
+		t.Errorf("error %v, se esperaba ErrAPIKeyInvalida", err)
+	}
+}
+
+func TestAPIKeyDesconocida(t *testing.T) {
+	usuario := jefeAdmin()
+	usarAPIKeysEnMemoria(t, usuario)
+	created := crearAPIKey(t, usuario.UserID, models.USER)
+
+	for _, plain := range []string{"", "sin-prefijo", apiKeyPrefix + "desconocida", created.Key[len(apiKeyPrefix):]} {
+		if _, _, err := AuthenticateAPIKeyService(context.Background(), plain); !errors.Is(err, ErrAPIKeyInvalida) {
+			t.Errorf("%q: error %v, se esperaba ErrAPIKeyInvalida", plain, err)
+		}
+	}
+}
+
+// si el usuario de la key se elimina la key deja de servir
+func TestAPIKeyDeUsuarioEliminado(t *testing.T) {
+	usuario := jefeAdmin()
+	usarAPIKeysEnMemoria(t, usuario)
+	created := crearAPIKey(t, usuario.UserID, models.USER)
+
+	usarAPIKeysEnMemoria(t)
+	apiKeyRepo.InsertOne(context.Background(), &created.APIKey)
+	if _, _, err := AuthenticateAPIKeyService(context.Background(), created.Key); !errors.Is(err, ErrAPIKeyInvalida) {
+		t.Errorf("error %v, se esperaba ErrAPIKeyInvalida", err)
+	}
+}
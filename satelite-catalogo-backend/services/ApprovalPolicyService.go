@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"catalogo-backend/models"
+	"catalogo-backend/repositories"
+	"catalogo-backend/transitions"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	approvalPolicyRepo *repositories.ApprovalPolicyRepository
+	onceApprovalPolicy sync.Once
+)
+
+var (
+	ErrPoliticaNoEncontrada = errors.New("política de aprobación no encontrada")
+	ErrPoliticaDuplicada    = errors.New("ya existe una política de aprobación para ese centro de costo")
+)
+
+// política usada cuando no hay una del centro de costo ni una global: aprueba el jefe del CC
+var defaultApprovalPolicy = models.ApprovalPolicy{
+	Nombre: "Jefe de centro de costo",
+	Pasos:  []models.ApprovalPolicyStep{{Nombre: "Jefe de centro de costo", Tipo: models.ApproverJefeCC}},
+}
+
+func getApprovalPolicyRepo() *repositories.ApprovalPolicyRepository {
+	onceApprovalPolicy.Do(func() {
+		approvalPolicyRepo = repositories.NewApprovalPolicyRepository()
+	})
+	return approvalPolicyRepo
+}
+
+func validateApprovalPolicy(policy *models.ApprovalPolicy) error {
+	verr := &ValidationError{}
+	if strings.TrimSpace(policy.Nombre) == "" {
+		verr.add("nombre", "es obligatorio")
+	}
+	if !policy.CC.IsZero() {
+		cc, err := NewCentroCostoService().GetCCByID(policy.CC.Hex())
+		if err != nil {
+			return err
+		}
+		if cc == nil {
+			verr.add("cc", "el centro de costo no existe")
+		}
+	}
+	if len(policy.Pasos) == 0 {
+		verr.add("pasos", "la política debe tener al menos un paso")
+	}
+	for i := range policy.Pasos {
+		paso := &policy.Pasos[i]
+		campo := fmt.Sprintf("pasos[%d]", i)
+		if strings.TrimSpace(paso.Nombre) == "" {
+			verr.add(campo+".nombre", "es obligatorio")
+		}
+		// cada tipo usa solo su campo, un rol en un paso de otro tipo lo haría visible para ese rol
+		switch paso.Tipo {
+		case models.ApproverJefeCC:
+			paso.Aprobador = primitive.NilObjectID
+			paso.Rol = ""
+		case models.ApproverUsuario:
+			paso.Rol = ""
+			if paso.Aprobador.IsZero() {
+				verr.add(campo+".aprobador", "es obligatorio para pasos de tipo %s", models.ApproverUsuario)
+			} else if user, err := getUserRepo().FindOne(bson.M{"_id": paso.Aprobador}); err != nil {
+				return err
+			} else if user == nil {
+				verr.add(campo+".aprobador", "el usuario no existe")
+			}
+		case models.ApproverRol:
+			paso.Aprobador = primitive.NilObjectID
+			if paso.Rol == "" {
+				verr.add(campo+".rol", "es obligatorio para pasos de tipo %s", models.ApproverRol)
+			}
+		default:
+			verr.add(campo+".tipo", "debe ser %s, %s o %s", models.ApproverJefeCC, models.ApproverUsuario, models.ApproverRol)
+		}
+		if paso.MontoMinimo < 0 {
+			verr.add(campo+".monto_minimo", "no puede ser negativo")
+		}
+		paso.Moneda = strings.ToUpper(strings.TrimSpace(paso.Moneda))
+		if paso.Moneda == "" {
+			paso.Moneda = ReportingCurrency()
+		} else if !models.IsSupportedCurrency(paso.Moneda) {
+			verr.add(campo+".moneda", "moneda no soportada: %s", paso.Moneda)
+		}
+	}
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// CreateApprovalPolicyService crea la política de un centro de costo, o la global si no indica centro de costo
+func CreateApprovalPolicyService(policy *models.ApprovalPolicy) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := validateApprovalPolicy(policy); err != nil {
+		return err
+	}
+	existing, err := getApprovalPolicyRepo().FindByCC(ctx, policy.CC)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return ErrPoliticaDuplicada
+	}
+
+	policy.ID = primitive.NewObjectID()
+	policy.CreatedAt = time.Now()
+	policy.UpdatedAt = policy.CreatedAt
+	return getApprovalPolicyRepo().InsertOne(ctx, policy)
+}
+
+// UpdateApprovalPolicyService reemplaza el nombre y los pasos de la política. Las solicitudes ya
+// enviadas conservan la cadena con que fueron enviadas
+func UpdateApprovalPolicyService(id string, cambios models.ApprovalPolicy) (*models.ApprovalPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	policy, err := GetApprovalPolicyService(id)
+	if err != nil {
+		return nil, err
+	}
+	policy.Nombre = cambios.Nombre
+	policy.Pasos = cambios.Pasos
+	if err := validateApprovalPolicy(policy); err != nil {
+		return nil, err
+	}
+	policy.UpdatedAt = time.Now()
+	if err := getApprovalPolicyRepo().Replace(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func GetApprovalPolicyService(id string) (*models.ApprovalPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("formato de ID inválido: %s", id)
+	}
+	policy, err := getApprovalPolicyRepo().FindByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return nil, ErrPoliticaNoEncontrada
+	}
+	return policy, nil
+}
+
+func GetApprovalPoliciesService(page, pageSize int) ([]*models.ApprovalPolicy, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return getApprovalPolicyRepo().FindPaginated(ctx, bson.M{}, page, pageSize)
+}
+
+func DeleteApprovalPolicyService(id string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("formato de ID inválido: %s", id)
+	}
+	deleted, err := getApprovalPolicyRepo().DeleteOne(ctx, objID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrPoliticaNoEncontrada
+	}
+	return nil
+}
+
+// resolveApprovalPolicy retorna la política del centro de costo, la global o la por defecto, en ese orden
+func resolveApprovalPolicy(ctx context.Context, cc primitive.ObjectID) (*models.ApprovalPolicy, error) {
+	if !cc.IsZero() {
+		policy, err := getApprovalPolicyRepo().FindByCC(ctx, cc)
+		if err != nil || policy != nil {
+			return policy, err
+		}
+	}
+	policy, err := getApprovalPolicyRepo().FindByCC(ctx, primitive.NilObjectID)
+	if err != nil || policy != nil {
+		return policy, err
+	}
+	porDefecto := defaultApprovalPolicy
+	return &porDefecto, nil
+}
+
+// BuildApprovalChain arma la cadena de aprobación de la solicitud al enviarla: toma los pasos de la
+// política cuyo monto mínimo es superado por el importe y resuelve el aprobador de cada uno.
+// Si ningún paso aplica, aprueba el jefe del centro de costo
+func BuildApprovalChain(solicitud *models.Solicitud) ([]models.ApprovalStep, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	policy, err := resolveApprovalPolicy(ctx, solicitud.CC)
+	if err != nil {
+		return nil, err
+	}
+	aplicables, err := applicableSteps(ctx, solicitud, policy.Pasos)
+	if err != nil {
+		return nil, err
+	}
+	if len(aplicables) == 0 {
+		aplicables = defaultApprovalPolicy.Pasos
+	}
+
+	var jefe primitive.ObjectID
+	if hasJefeStep(aplicables) {
+		cc, err := NewCentroCostoService().GetCCByID(solicitud.CC.Hex())
+		if err != nil {
+			return nil, err
+		}
+		if cc != nil {
+			jefe = cc.Jefe
+		}
+	}
+
+	pasos := make([]models.ApprovalStep, 0, len(aplicables))
+	for i, p := range aplicables {
+		paso := models.ApprovalStep{
+			Orden:  i + 1,
+			Nombre: p.Nombre,
+			Tipo:   p.Tipo,
+			Estado: models.ApprovalStepPendiente,
+		}
+		switch p.Tipo {
+		case models.ApproverRol:
+			paso.Rol = p.Rol
+		case models.ApproverUsuario:
+			paso.Aprobador = p.Aprobador
+		case models.ApproverJefeCC:
+			// sin jefe el paso queda sin aprobador y solo lo puede decidir un administrador
+			paso.Aprobador = jefe
+		}
+		pasos = append(pasos, paso)
+	}
+	return pasos, nil
+}
+
+// applicableSteps retorna los pasos cuyo monto mínimo es superado por el importe de la solicitud
+func applicableSteps(ctx context.Context, solicitud *models.Solicitud, pasos []models.ApprovalPolicyStep) ([]models.ApprovalPolicyStep, error) {
+	importes := map[string]float64{}
+	aplicables := []models.ApprovalPolicyStep{}
+	for _, p := range pasos {
+		if p.MontoMinimo > 0 {
+			moneda := p.Moneda
+			if moneda == "" {
+				moneda = ReportingCurrency()
+			}
+			importe, ok := importes[moneda]
+			if !ok {
+				var err error
+				if importe, err = approvedAmount(ctx, solicitud, moneda); err != nil {
+					return nil, err
+				}
+				importes[moneda] = importe
+			}
+			if importe <= p.MontoMinimo {
+				continue
+			}
+		}
+		aplicables = append(aplicables, p)
+	}
+	return aplicables, nil
+}
+
+func hasJefeStep(pasos []models.ApprovalPolicyStep) bool {
+	for _, p := range pasos {
+		if p.Tipo == models.ApproverJefeCC {
+			return true
+		}
+	}
+	return false
+}
+
+// setApprovalChain agrega al update la cadena de aprobación resultante y su aprobador pendiente
+func setApprovalChain(update bson.M, decision transitions.StepDecision) {
+	aprobador, rol := decision.NextApprover()
+	update["aprobaciones"] = decision.Aprobaciones
+	update["paso_actual"] = decision.PasoActual
+	update["aprobador_actual"] = aprobador
+	update["rol_actual"] = rol
+}
+
+// rerouteJefeSteps asigna a nuevo los pasos pendientes de jefe de centro de costo que tenía anterior y
+// retorna el $set para la solicitud, o nil si ningún paso cambió
+func rerouteJefeSteps(solicitud *models.Solicitud, anterior, nuevo primitive.ObjectID) bson.M {
+	pasos := make([]models.ApprovalStep, len(solicitud.Aprobaciones))
+	copy(pasos, solicitud.Aprobaciones)
+	update := bson.M{}
+	for i := range pasos {
+		p := &pasos[i]
+		if p.Tipo != models.ApproverJefeCC || p.Estado != models.ApprovalStepPendiente || p.Aprobador != anterior {
+			continue
+		}
+		p.Aprobador = nuevo
+		update["aprobaciones"] = pasos
+		if i == solicitud.PasoActual {
+			update["aprobador_actual"] = nuevo
+		}
+	}
+	if len(update) == 0 {
+		return nil
+	}
+	return update
+}
+
+// PendingApprovalFilter retorna el filtro de las solicitudes cuyo paso pendiente le corresponde al usuario,
+// directamente o por una delegación vigente. Las solicitudes pendientes sin cadena de aprobación le
+// corresponden al jefe del centro de costo
+func PendingApprovalFilter(principal *models.Principal) bson.M {
+	if principal.IsAdmin() {
+		return bson.M{}
+	}
+	pendientes := []string{string(transitions.PendienteAprobacion), string(transitions.LineaAprobada)}
+	or := []bson.M{
+		{"state": bson.M{"$in": pendientes}, "aprobador_actual": principal.UserID},
+		{"state": bson.M{"$in": pendientes}, "rol_actual": bson.M{"$in": principal.Roles}},
+		{"state": bson.M{"$in": pendientes}, "aprobaciones": bson.M{"$exists": false}, "cc": bson.M{"$in": principal.JefeDe}},
+	}
+	if ParentJefeApproves() && len(principal.SupervisaDe) > 0 {
+		// el paso pendiente es de jefe de centro de costo, de cualquier descendiente que supervisa
+		pasoJefe := bson.M{"$eq": bson.A{bson.M{"$arrayElemAt": bson.A{"$aprobaciones.tipo", "$paso_actual"}}, models.ApproverJefeCC}}
+		or = append(or,
+			bson.M{"state": bson.M{"$in": pendientes}, "cc": bson.M{"$in": principal.SupervisaDe}, "$expr": pasoJefe},
+			bson.M{"state": bson.M{"$in": pendientes}, "aprobaciones": bson.M{"$exists": false}, "cc": bson.M{"$in": principal.SupervisaDe}},
+		)
+	}
+	for _, d := range principal.Delegaciones {
+		or = append(or,
+			d.Scope(bson.M{"state": bson.M{"$in": pendientes}, "aprobador_actual": d.Delegante}),
+			bson.M{"state": bson.M{"$in": pendientes}, "aprobaciones": bson.M{"$exists": false}, "cc": bson.M{"$in": d.JefeDe}},
+		)
+	}
+	return bson.M{"$or": or}
+}
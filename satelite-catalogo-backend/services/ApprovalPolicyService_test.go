@@ -0,0 +1,30 @@
+package services
+
+import (
+	"testing"
+
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// un paso solo conserva el campo de su tipo, así el filtro por rol coincide con quien puede decidirlo
+func TestValidateApprovalPolicyLimpiaCamposDeOtroTipo(t *testing.T) {
+	usuario := primitive.NewObjectID()
+	policy := &models.ApprovalPolicy{
+		Nombre: "global",
+		Pasos: []models.ApprovalPolicyStep{
+			{Nombre: "jefe", Tipo: models.ApproverJefeCC, Aprobador: usuario, Rol: models.ADMIN},
+			{Nombre: "finanzas", Tipo: models.ApproverRol, Aprobador: usuario, Rol: models.JEFE},
+		},
+	}
+	if err := validateApprovalPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	if jefe := policy.Pasos[0]; !jefe.Aprobador.IsZero() || jefe.Rol != "" {
+		t.Errorf("paso jefe_cc con aprobador %v y rol %q", jefe.Aprobador, jefe.Rol)
+	}
+	if rol := policy.Pasos[1]; !rol.Aprobador.IsZero() || rol.Rol != models.JEFE {
+		t.Errorf("paso rol con aprobador %v y rol %q", rol.Aprobador, rol.Rol)
+	}
+}
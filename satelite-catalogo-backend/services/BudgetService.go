@@ -0,0 +1,363 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"catalogo-backend/models"
+	"catalogo-backend/repositories"
+	"catalogo-backend/transitions"
+	"catalogo-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	budgetRepo *repositories.BudgetRepository
+	onceBudget sync.Once
+)
+
+var (
+	ErrPresupuestoNoEncontrado = errors.New("presupuesto no encontrado")
+	ErrPresupuestoExcedido     = errors.New("la aprobación excede el saldo disponible del presupuesto")
+	ErrPresupuestoSuperpuesto  = errors.New("el centro de costo ya tiene un presupuesto para ese período")
+)
+
+func getBudgetRepo() *repositories.BudgetRepository {
+	onceBudget.Do(func() {
+		budgetRepo = repositories.NewBudgetRepository()
+	})
+	return budgetRepo
+}
+
+func validateBudget(budget *models.Budget) error {
+	verr := &ValidationError{}
+	if budget.CC.IsZero() {
+		verr.add("cc", "es obligatorio")
+	} else if cc, err := NewCentroCostoService().GetCCByID(budget.CC.Hex()); err != nil {
+		return err
+	} else if cc == nil {
+		verr.add("cc", "el centro de costo no existe")
+	}
+	if strings.TrimSpace(budget.Periodo) == "" {
+		verr.add("periodo", "es obligatorio")
+	}
+	if budget.FechaInicio.IsZero() || budget.FechaFin.IsZero() {
+		verr.add("fecha_inicio", "fecha_inicio y fecha_fin son obligatorias")
+	} else if budget.FechaFin.Before(budget.FechaInicio) {
+		verr.add("fecha_fin", "debe ser posterior a fecha_inicio")
+	}
+	if budget.Monto <= 0 {
+		verr.add("monto", "debe ser mayor a 0")
+	}
+	budget.Moneda = strings.ToUpper(strings.TrimSpace(budget.Moneda))
+	if budget.Moneda == "" {
+		budget.Moneda = ReportingCurrency()
+	} else if !models.IsSupportedCurrency(budget.Moneda) {
+		verr.add("moneda", "moneda no soportada: %s", budget.Moneda)
+	}
+	switch budget.Politica {
+	case "":
+		budget.Politica = models.BudgetPolicyBlock
+	case models.BudgetPolicyBlock, models.BudgetPolicyFlag:
+	default:
+		verr.add("politica", "debe ser %s o %s", models.BudgetPolicyBlock, models.BudgetPolicyFlag)
+	}
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// CreateBudgetService crea el presupuesto de un centro de costo para un período, sin montos comprometidos
+func CreateBudgetService(budget *models.Budget) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := validateBudget(budget); err != nil {
+		return err
+	}
+	budget.ID = primitive.NewObjectID()
+	budget.Comprometido = 0
+	budget.Consumido = 0
+	budget.CreatedAt = time.Now()
+	budget.UpdatedAt = budget.CreatedAt
+
+	overlap, err := getBudgetRepo().ExistsOverlapping(ctx, budget)
+	if err != nil {
+		return err
+	}
+	if overlap {
+		return ErrPresupuestoSuperpuesto
+	}
+	return getBudgetRepo().InsertOne(ctx, budget)
+}
+
+// UpdateBudgetService modifica período, monto y política; el centro de costo, la moneda y los
+// montos comprometidos y consumidos no se pueden cambiar
+func UpdateBudgetService(id string, cambios models.Budget) (*models.Budget, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	budget, err := GetBudgetService(id)
+	if err != nil {
+		return nil, err
+	}
+	if cambios.Periodo != "" {
+		budget.Periodo = cambios.Periodo
+	}
+	if !cambios.FechaInicio.IsZero() {
+		budget.FechaInicio = cambios.FechaInicio
+	}
+	if !cambios.FechaFin.IsZero() {
+		budget.FechaFin = cambios.FechaFin
+	}
+	if cambios.Monto != 0 {
+		budget.Monto = cambios.Monto
+	}
+	if cambios.Politica != "" {
+		budget.Politica = cambios.Politica
+	}
+	if err := validateBudget(budget); err != nil {
+		return nil, err
+	}
+
+	overlap, err := getBudgetRepo().ExistsOverlapping(ctx, budget)
+	if err != nil {
+		return nil, err
+	}
+	if overlap {
+		return nil, ErrPresupuestoSuperpuesto
+	}
+	if err := getBudgetRepo().UpdateDefinition(ctx, budget); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+func GetBudgetService(id string) (*models.Budget, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("formato de ID inválido: %s", id)
+	}
+	budget, err := getBudgetRepo().FindByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil {
+		return nil, ErrPresupuestoNoEncontrado
+	}
+	return budget, nil
+}
+
+func GetBudgetsService(filter bson.M, page, pageSize int) ([]*models.Budget, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return getBudgetRepo().FindPaginated(ctx, filter, page, pageSize)
+}
+
+// GetBudgetBalanceService retorna el saldo del presupuesto vigente del centro de costo a la fecha
+func GetBudgetBalanceService(ccID primitive.ObjectID, fecha time.Time) (*models.BudgetBalance, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	budget, err := getBudgetRepo().FindActive(ctx, ccID, fecha)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil {
+		return nil, ErrPresupuestoNoEncontrado
+	}
+	return models.NewBudgetBalance(budget), nil
+}
+
+// budgetDate es la fecha con que se busca el presupuesto de la solicitud: la contable si existe
+func budgetDate(s *models.Solicitud) time.Time {
+	switch {
+	case !s.FechaContable.IsZero():
+		return s.FechaContable
+	case !s.FechaSolicitud.IsZero():
+		return s.FechaSolicitud
+	default:
+		return time.Now()
+	}
+}
+
+// approvedAmount retorna el importe aprobado de la solicitud (sin las líneas rechazadas) en la moneda indicada
+func approvedAmount(ctx context.Context, s *models.Solicitud, moneda string) (float64, error) {
+	aprobado := 0.0
+	for _, l := range s.Lines {
+		if l.Estado != models.LineStateRechazada {
+			aprobado += l.Importe
+		}
+	}
+	// se reutiliza la conversión registrada en la solicitud para que el monto sea reproducible
+	if s.MonedaReporte == moneda && s.ImporteTotal != 0 {
+		return roundAmount(s.ImporteReporte * aprobado / s.ImporteTotal), nil
+	}
+	desde := s.Moneda
+	if desde == "" {
+		desde = models.CLP
+	}
+	return newRateConverter(ctx, s.FechaSolicitud).Convert(aprobado, desde, moneda)
+}
+
+// budgetEffect es el movimiento de presupuesto que acompaña un cambio de estado de la solicitud
+type budgetEffect struct {
+	compromiso *models.BudgetCommitment
+	reservado  bool // se sumó a lo comprometido antes de cambiar el estado
+	consumir   bool // se debe pasar de comprometido a consumido después de cambiar el estado
+}
+
+// prepareBudgetEffect calcula el efecto del cambio de estado en el presupuesto del centro de costo
+// y agrega el compromiso resultante al update de la solicitud. Al aprobar se reserva el monto de
+// inmediato con ctx, que debe ser la transacción del cambio de estado; sin transacciones, si el
+// cambio de estado no se concreta hay que llamar a rollback
+func prepareBudgetEffect(ctx context.Context, previa *models.Solicitud, nuevoEstado transitions.State, update bson.M) (*budgetEffect, error) {
+	effect := &budgetEffect{}
+	switch {
+	case nuevoEstado == transitions.Aprobada && previa.Presupuesto == nil:
+		budget, err := getBudgetRepo().FindActive(ctx, previa.CC, budgetDate(previa))
+		if err != nil {
+			return nil, err
+		}
+		if budget == nil {
+			// el centro de costo no tiene presupuesto para el período, no se controla
+			return effect, nil
+		}
+		monto, err := approvedAmount(ctx, previa, budget.Moneda)
+		if err != nil {
+			return nil, err
+		}
+
+		soloConSaldo := budget.Politica != models.BudgetPolicyFlag
+		reservado, err := getBudgetRepo().Reserve(ctx, budget.ID, monto, soloConSaldo)
+		if err != nil {
+			return nil, err
+		}
+		if !reservado {
+			return nil, fmt.Errorf("%w (disponible %.2f %s, requerido %.2f %s)", ErrPresupuestoExcedido, budget.Disponible(), budget.Moneda, monto, budget.Moneda)
+		}
+		effect.reservado = true
+		effect.compromiso = &models.BudgetCommitment{
+			BudgetID: budget.ID,
+			Monto:    monto,
+			Estado:   models.BudgetCommitted,
+			Excedido: budget.Disponible() < monto,
+			Fecha:    time.Now(),
+		}
+		update["presupuesto"] = effect.compromiso
+
+	case nuevoEstado == transitions.Finalizada && previa.Presupuesto != nil && previa.Presupuesto.Estado == models.BudgetCommitted:
+		consumido := *previa.Presupuesto
+		consumido.Estado = models.BudgetConsumed
+		consumido.Fecha = time.Now()
+		effect.compromiso = &consumido
+		effect.consumir = true
+		update["presupuesto"] = effect.compromiso
+	}
+	return effect, nil
+}
+
+// rollback libera el monto reservado cuando el cambio de estado no se concretó. Con transacciones no
+// es necesario, la reserva se descarta junto al cambio de estado
+func (e *budgetEffect) rollback(ctx context.Context) {
+	if !e.reservado {
+		return
+	}
+	if err := getBudgetRepo().Move(ctx, e.compromiso.BudgetID, -e.compromiso.Monto, 0); err != nil {
+		utils.Debug(fmt.Sprintf("No se pudo liberar el presupuesto %s: %v", e.compromiso.BudgetID.Hex(), err))
+	}
+}
+
+// apply completa el movimiento una vez que el cambio de estado se guardó, en la misma transacción
+func (e *budgetEffect) apply(ctx context.Context) error {
+	if !e.consumir {
+		return nil
+	}
+	return getBudgetRepo().Move(ctx, e.compromiso.BudgetID, -e.compromiso.Monto, e.compromiso.Monto)
+}
+
+// releaseBudget libera lo comprometido por la solicitud aprobada que se elimina, en la transacción
+// de la eliminación. El compromiso queda liberado en la solicitud para volver a reservarlo si se restaura
+func releaseBudget(ctx context.Context, s *models.Solicitud) error {
+	if s.Presupuesto == nil || s.Presupuesto.Estado != models.BudgetCommitted {
+		return nil
+	}
+	if err := getBudgetRepo().Move(ctx, s.Presupuesto.BudgetID, -s.Presupuesto.Monto, 0); err != nil {
+		return err
+	}
+	return setBudgetState(ctx, s, models.BudgetReleased)
+}
+
+// recommitBudget vuelve a reservar el compromiso liberado al eliminar la solicitud, con la política
+// del presupuesto: con bloquear la restauración falla si ya no hay saldo
+func recommitBudget(ctx context.Context, s *models.Solicitud) error {
+	if s.Presupuesto == nil || s.Presupuesto.Estado != models.BudgetReleased {
+		return nil
+	}
+	budget, err := getBudgetRepo().FindByID(ctx, s.Presupuesto.BudgetID)
+	if err != nil {
+		return err
+	}
+	if budget == nil {
+		// el presupuesto ya no existe, no hay saldo que controlar
+		return nil
+	}
+	reservado, err := getBudgetRepo().Reserve(ctx, budget.ID, s.Presupuesto.Monto, budget.Politica != models.BudgetPolicyFlag)
+	if err != nil {
+		return err
+	}
+	if !reservado {
+		return fmt.Errorf("%w (disponible %.2f %s, requerido %.2f %s)", ErrPresupuestoExcedido, budget.Disponible(), budget.Moneda, s.Presupuesto.Monto, budget.Moneda)
+	}
+	return setBudgetState(ctx, s, models.BudgetCommitted)
+}
+
+// moveBudgetCommitment traslada lo comprometido por la solicitud aprobada al presupuesto vigente del
+// centro de costo destino, en la transacción del traslado, y retorna el nuevo compromiso. Si el destino
+// no tiene presupuesto para el período, la solicitud queda sin compromiso. Con bloquear el traslado
+// falla si el destino no tiene saldo
+func moveBudgetCommitment(ctx context.Context, s *models.Solicitud, destino primitive.ObjectID) (*models.BudgetCommitment, error) {
+	if err := getBudgetRepo().Move(ctx, s.Presupuesto.BudgetID, -s.Presupuesto.Monto, 0); err != nil {
+		return nil, err
+	}
+	budget, err := getBudgetRepo().FindActive(ctx, destino, budgetDate(s))
+	if err != nil || budget == nil {
+		return nil, err
+	}
+	monto, err := approvedAmount(ctx, s, budget.Moneda)
+	if err != nil {
+		return nil, err
+	}
+	reservado, err := getBudgetRepo().Reserve(ctx, budget.ID, monto, budget.Politica != models.BudgetPolicyFlag)
+	if err != nil {
+		return nil, err
+	}
+	if !reservado {
+		return nil, fmt.Errorf("%w en el centro de costo destino (disponible %.2f %s, requerido %.2f %s)", ErrPresupuestoExcedido, budget.Disponible(), budget.Moneda, monto, budget.Moneda)
+	}
+	return &models.BudgetCommitment{
+		BudgetID: budget.ID,
+		Monto:    monto,
+		Estado:   models.BudgetCommitted,
+		Excedido: budget.Disponible() < monto,
+		Fecha:    time.Now(),
+	}, nil
+}
+
+func setBudgetState(ctx context.Context, s *models.Solicitud, estado string) error {
+	return getSolicitudRepo().UpdateOne(ctx, bson.M{"_id": s.ID}, bson.M{"$set": bson.M{
+		"presupuesto.estado": estado,
+		"presupuesto.fecha":  time.Now(),
+	}})
+}
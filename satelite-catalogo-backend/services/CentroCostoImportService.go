@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"catalogo-backend/models"
+	"catalogo-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// alias de los encabezados de la planilla de centros de costo, ya normalizados con utils.NormalizeHeader
+var ccColumnAliases = map[string]string{
+	"numero":          "numero",
+	"numero_cc":       "numero",
+	"cc":              "numero",
+	"centro_costo":    "numero",
+	"centro_de_costo": "numero",
+	"nombre":          "nombre",
+	"nombre_cc":       "nombre",
+	"jefe_email":      "jefe_email",
+	"email_jefe":      "jefe_email",
+	"correo_jefe":     "jefe_email",
+	"jefe":            "jefe_email",
+	"padre":           "padre",
+	"numero_padre":    "padre",
+	"cc_padre":        "padre",
+}
+
+// alias de los encabezados de la planilla de membresías usuario-centro de costo
+var membershipColumnAliases = map[string]string{
+	"email":           "email",
+	"correo":          "email",
+	"email_usuario":   "email",
+	"usuario":         "email",
+	"cc":              "cc",
+	"numero_cc":       "cc",
+	"numero":          "cc",
+	"centro_costo":    "cc",
+	"centro_de_costo": "cc",
+	"rol":             "rol",
+	"rol_cc":          "rol",
+}
+
+// importColumns ubica las columnas conocidas en los encabezados y exige las obligatorias
+func importColumns(rows [][]string, aliases map[string]string, required []string) (map[string]int, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("el archivo está vacío")
+	}
+	columns := map[string]int{}
+	for i, header := range rows[0] {
+		if field, ok := aliases[utils.NormalizeHeader(header)]; ok {
+			if _, exists := columns[field]; !exists {
+				columns[field] = i
+			}
+		}
+	}
+	var missing []string
+	for _, field := range required {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("faltan columnas obligatorias: %s", strings.Join(missing, ", "))
+	}
+	return columns, nil
+}
+
+func importCell(columns map[string]int, row []string, field string) string {
+	i, ok := columns[field]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// ccImportRow es una fila válida de la planilla de centros de costo
+type ccImportRow struct {
+	fila      int
+	numero    int
+	nombre    string
+	jefeEmail string
+	padre     int // 0 sin padre
+}
+
+// ccImport es el estado de una importación de centros de costo: el árbol se carga una vez y se actualiza
+// con cada fila aplicada, también en dry-run, para resolver los padres definidos en el mismo archivo
+type ccImport struct {
+	ctx      context.Context
+	s        *CentroCostoService
+	dryRun   bool
+	conPadre bool // sin la columna padre no se modifica el padre de los existentes
+	tree     *ccTree
+	numeros  map[int]*models.CC
+	jefes    map[string]primitive.ObjectID
+}
+
+// ImportCentrosCostoService importa centros de costo desde una planilla con columnas numero, nombre,
+// jefe_email y padre (número del centro de costo padre), hace upsert por numero y retorna el detalle por
+// fila. Los jefes se buscan por email; un jefe vacío no cambia el jefe actual y, si la columna padre
+// existe, un padre vacío deja al centro de costo en la raíz. Los padres pueden venir en el mismo archivo
+// en cualquier orden. Con dryRun no se escribe en la base de datos
+func ImportCentrosCostoService(ctx context.Context, rows [][]string, dryRun bool) (*models.ImportReport, error) {
+	columns, err := importColumns(rows, ccColumnAliases, []string{"numero", "nombre"})
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewCentroCostoService()
+	tree, err := s.loadTree()
+	if err != nil {
+		return nil, err
+	}
+	_, conPadre := columns["padre"]
+	imp := &ccImport{
+		ctx:      ctx,
+		s:        s,
+		dryRun:   dryRun,
+		conPadre: conPadre,
+		tree:     tree,
+		numeros:  map[int]*models.CC{},
+		jefes:    map[string]primitive.ObjectID{},
+	}
+	for _, cc := range tree.byID {
+		imp.numeros[cc.Numero] = cc
+	}
+
+	resultados := []models.ImportRowResult{}
+	pendientes := []ccImportRow{}
+	vistos := map[int]int{}
+	for i, row := range rows[1:] {
+		fila := i + 2
+		if isEmptyRow(row) {
+			continue
+		}
+		r, errores := parseCCRow(columns, row, fila)
+		result := models.ImportRowResult{Fila: fila, Clave: importCell(columns, row, "numero")}
+		if previa, ok := vistos[r.numero]; ok && len(errores) == 0 {
+			errores = append(errores, fmt.Sprintf("centro de costo duplicado en el archivo (fila %d)", previa))
+		}
+		if len(errores) > 0 {
+			result.Accion = models.ImportRejected
+			result.Errores = errores
+			resultados = append(resultados, result)
+			continue
+		}
+		vistos[r.numero] = fila
+		pendientes = append(pendientes, r)
+	}
+
+	// cada vuelta aplica las filas cuyo padre ya fue aplicado o no está en el archivo, las que quedan
+	// esperan a su padre; si una vuelta no aplica ninguna, las restantes tienen un padre rechazado o un ciclo
+	aplicados := map[int]bool{}
+	rechazados := map[int]int{}
+	for len(pendientes) > 0 {
+		var resto []ccImportRow
+		for _, r := range pendientes {
+			if fila, ok := vistos[r.padre]; ok && r.padre != 0 && !aplicados[r.padre] {
+				if _, rechazado := rechazados[r.padre]; rechazado {
+					resultados = append(resultados, rejectedRow(r, fmt.Sprintf("el centro de costo padre %d fue rechazado (fila %d)", r.padre, fila)))
+					rechazados[r.numero] = r.fila
+					continue
+				}
+				resto = append(resto, r)
+				continue
+			}
+			result := imp.upsert(r)
+			if result.Accion == models.ImportRejected {
+				rechazados[r.numero] = r.fila
+			} else {
+				aplicados[r.numero] = true
+			}
+			resultados = append(resultados, result)
+		}
+		if len(resto) == len(pendientes) {
+			for _, r := range resto {
+				resultados = append(resultados, rejectedRow(r, fmt.Sprintf("el centro de costo padre %d forma un ciclo en el archivo", r.padre)))
+			}
+			break
+		}
+		pendientes = resto
+	}
+
+	sort.Slice(resultados, func(i, j int) bool { return resultados[i].Fila < resultados[j].Fila })
+	report := &models.ImportReport{DryRun: dryRun, Filas: []models.ImportRowResult{}}
+	for _, result := range resultados {
+		report.Add(result)
+	}
+	return report, nil
+}
+
+func parseCCRow(columns map[string]int, row []string, fila int) (ccImportRow, []string) {
+	r := ccImportRow{
+		fila:      fila,
+		nombre:    importCell(columns, row, "nombre"),
+		jefeEmail: importCell(columns, row, "jefe_email"),
+	}
+	var errores []string
+
+	raw := importCell(columns, row, "numero")
+	numero, err := strconv.Atoi(raw)
+	switch {
+	case raw == "":
+		errores = append(errores, "numero es obligatorio")
+	case err != nil || numero <= 0:
+		errores = append(errores, fmt.Sprintf("numero inválido: %s", raw))
+	}
+	r.numero = numero
+	if r.nombre == "" {
+		errores = append(errores, "nombre es obligatorio")
+	}
+	if raw := importCell(columns, row, "padre"); raw != "" {
+		padre, err := strconv.Atoi(raw)
+		switch {
+		case err != nil || padre <= 0:
+			errores = append(errores, fmt.Sprintf("padre inválido: %s", raw))
+		case padre == numero:
+			errores = append(errores, "un centro de costo no puede ser su propio padre")
+		}
+		r.padre = padre
+	}
+	return r, errores
+}
+
+func rejectedRow(r ccImportRow, errores ...string) models.ImportRowResult {
+	return models.ImportRowResult{
+		Fila:    r.fila,
+		Clave:   strconv.Itoa(r.numero),
+		Accion:  models.ImportRejected,
+		Errores: errores,
+	}
+}
+
+// jefe busca el usuario por email, recordando el resultado para las filas siguientes
+func (imp *ccImport) jefe(email string) (primitive.ObjectID, error) {
+	if id, ok := imp.jefes[email]; ok {
+		return id, nil
+	}
+	user, err := getUserRepo().FindOne(bson.M{"email": email})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	var id primitive.ObjectID
+	if user != nil {
+		id = user.ID
+	}
+	imp.jefes[email] = id
+	return id, nil
+}
+
+// upsert crea o actualiza el centro de costo de la fila y lo deja en el árbol de la importación
+func (imp *ccImport) upsert(r ccImportRow) models.ImportRowResult {
+	result := models.ImportRowResult{Fila: r.fila, Clave: strconv.Itoa(r.numero)}
+	var errores []string
+
+	var jefe primitive.ObjectID
+	if r.jefeEmail != "" {
+		id, err := imp.jefe(r.jefeEmail)
+		if err != nil {
+			return rejectedRow(r, err.Error())
+		}
+		if id.IsZero() {
+			errores = append(errores, fmt.Sprintf("no existe un usuario con email %s", r.jefeEmail))
+		}
+		jefe = id
+	}
+	var padre *models.CC
+	if r.padre != 0 {
+		if padre = imp.numeros[r.padre]; padre == nil {
+			errores = append(errores, fmt.Sprintf("el centro de costo padre %d no existe", r.padre))
+		}
+	}
+	if len(errores) > 0 {
+		return rejectedRow(r, errores...)
+	}
+	padreID := primitive.NilObjectID
+	if padre != nil {
+		padreID = padre.ID
+	}
+
+	existing := imp.numeros[r.numero]
+	if existing == nil {
+		cc := &models.CC{Numero: r.numero, Nombre: r.nombre, Jefe: jefe, Padre: padreID}
+		if imp.dryRun {
+			cc.ID = primitive.NewObjectID()
+		} else if _, err := imp.s.CreateCC(cc); err != nil {
+			return rejectedRow(r, err.Error())
+		}
+		imp.numeros[cc.Numero] = cc
+		imp.tree.byID[cc.ID] = cc
+		if !padreID.IsZero() {
+			imp.tree.hijos[padreID] = append(imp.tree.hijos[padreID], cc)
+		}
+		result.Accion = models.ImportCreated
+		if !imp.dryRun {
+			result.ID = cc.ID.Hex()
+		}
+		return result
+	}
+
+	result.ID = existing.ID.Hex()
+	update := bson.M{}
+	if existing.Nombre != r.nombre {
+		update["nombre"] = r.nombre
+	}
+	cambiaPadre := imp.conPadre && existing.Padre != padreID
+	if cambiaPadre {
+		if imp.tree.createsCycle(existing.ID, padreID) {
+			return rejectedRow(r, ErrPadreCiclico.Error())
+		}
+		// UpdateCC recibe el padre como en el JSON de PUT /cc/{id}
+		update["padre"] = ""
+		if !padreID.IsZero() {
+			update["padre"] = padreID.Hex()
+		}
+	}
+	cambiaJefe := !jefe.IsZero() && jefe != existing.Jefe
+	if len(update) == 0 && !cambiaJefe {
+		result.Accion = models.ImportUnchanged
+		return result
+	}
+
+	if !imp.dryRun {
+		if len(update) > 0 {
+			if err := imp.s.UpdateCC(existing.ID.Hex(), update); err != nil {
+				return rejectedRow(r, err.Error())
+			}
+		}
+		if cambiaJefe {
+			// el cambio de jefe sincroniza los usuarios y traspasa sus aprobaciones pendientes
+			if _, err := imp.s.ReassignJefe(imp.ctx, existing.ID.Hex(), jefe.Hex()); err != nil {
+				return rejectedRow(r, fmt.Sprintf("jefe: %v", err))
+			}
+		}
+	}
+	existing.Nombre = r.nombre
+	if cambiaPadre {
+		imp.tree.setPadre(existing, padreID)
+	}
+	if cambiaJefe {
+		existing.Jefe = jefe
+	}
+	result.Accion = models.ImportUpdated
+	return result
+}
+
+// ImportMembershipsService agrega usuarios a centros de costo desde una planilla con columnas email, cc
+// (número del centro de costo) y rol (solicitante u observador, por defecto solicitante). Las membresías
+// que ya existen con el mismo rol quedan sin cambios y las demás cambian de rol; la importación no quita
+// centros de costo a los usuarios y no cambia jefes. Con dryRun no se escribe en la base de datos
+func ImportMembershipsService(ctx context.Context, rows [][]string, dryRun bool) (*models.ImportReport, error) {
+	columns, err := importColumns(rows, membershipColumnAliases, []string{"email", "cc"})
+	if err != nil {
+		return nil, err
+	}
+
+	centros, err := NewCentroCostoService().GetAllCC()
+	if err != nil {
+		return nil, err
+	}
+	numeros := map[int]*models.CC{}
+	for _, cc := range centros {
+		numeros[cc.Numero] = cc
+	}
+	usuarios := map[string]*models.User{}
+
+	report := &models.ImportReport{DryRun: dryRun, Filas: []models.ImportRowResult{}}
+	vistos := map[string]int{}
+	for i, row := range rows[1:] {
+		fila := i + 2
+		if isEmptyRow(row) {
+			continue
+		}
+
+		email := importCell(columns, row, "email")
+		raw := importCell(columns, row, "cc")
+		rol := models.MembershipRole(strings.ToLower(importCell(columns, row, "rol")))
+		result := models.ImportRowResult{Fila: fila, Clave: email + "/" + raw}
+		var errores []string
+		if email == "" {
+			errores = append(errores, "email es obligatorio")
+		}
+		numero, err := strconv.Atoi(raw)
+		if err != nil || numero <= 0 {
+			errores = append(errores, fmt.Sprintf("cc inválido: %s", raw))
+		}
+		if rol != "" && rol != models.MembershipSolicitante && rol != models.MembershipObservador {
+			errores = append(errores, fmt.Sprintf("rol inválido: %s, debe ser solicitante u observador", rol))
+		}
+		if previa, ok := vistos[result.Clave]; ok && len(errores) == 0 {
+			errores = append(errores, fmt.Sprintf("membresía duplicada en el archivo (fila %d)", previa))
+		}
+		if len(errores) > 0 {
+			result.Accion = models.ImportRejected
+			result.Errores = errores
+			report.Add(result)
+			continue
+		}
+		vistos[result.Clave] = fila
+
+		if err := importMembership(ctx, usuarios, email, numeros[numero], numero, rol, dryRun, &result); err != nil {
+			result.Accion = models.ImportRejected
+			result.Errores = []string{err.Error()}
+		}
+		report.Add(result)
+	}
+	return report, nil
+}
+
+func importMembership(ctx context.Context, usuarios map[string]*models.User, email string, cc *models.CC, numero int, rol models.MembershipRole, dryRun bool, result *models.ImportRowResult) error {
+	if cc == nil {
+		return fmt.Errorf("el centro de costo %d no existe", numero)
+	}
+	user, ok := usuarios[email]
+	if !ok {
+		var err error
+		if user, err = getUserRepo().FindOne(bson.M{"email": email}); err != nil {
+			return err
+		}
+		usuarios[email] = user
+	}
+	if user == nil {
+		return fmt.Errorf("no existe un usuario con email %s", email)
+	}
+	result.ID = user.ID.Hex()
+
+	anterior := membershipRole(user, cc)
+	if anterior == models.MembershipJefe {
+		// el jefe ya pertenece al centro de costo, solo se rechaza si se pide otro rol
+		if rol != "" {
+			return ErrMembresiaJefe
+		}
+		result.Accion = models.ImportUnchanged
+		return nil
+	}
+	if rol == "" {
+		rol = models.MembershipSolicitante
+	}
+	switch anterior {
+	case rol:
+		result.Accion = models.ImportUnchanged
+		return nil
+	case "":
+		result.Accion = models.ImportCreated
+	default:
+		result.Accion = models.ImportUpdated
+	}
+	if !dryRun {
+		if _, err := setMembership(ctx, user, cc, rol, models.MembershipOriginImport); err != nil {
+			return err
+		}
+	}
+	// el usuario en memoria refleja la fila para las siguientes del mismo usuario
+	if anterior == "" {
+		user.CC = append(user.CC, cc.ID)
+	}
+	if rol == models.MembershipObservador {
+		user.CCObservador = append(user.CCObservador, cc.ID)
+	} else {
+		user.CCObservador = removeObjectID(user.CCObservador, cc.ID)
+	}
+	return nil
+}
@@ -1,8 +1,15 @@
 package services
 
 import (
+	"catalogo-backend/database"
 	"catalogo-backend/models"
 	"catalogo-backend/repositories"
+	"catalogo-backend/transitions"
+	"catalogo-backend/utils"
+	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -10,6 +17,13 @@ import (
 
 var centroCostoService *CentroCostoService
 
+var (
+	ErrCentroCostoNoEncontrado = errors.New("centro de costo no encontrado")
+	ErrMismoCentroCosto        = errors.New("el centro de costo de destino debe ser distinto del de origen")
+	ErrMismoJefe               = errors.New("el usuario ya es el jefe del centro de costo")
+	ErrJefeModificado          = errors.New("el jefe del centro de costo cambió durante la operación, intente nuevamente")
+)
+
 type CentroCostoService struct {
 	repo *repositories.CentroCostoRepository
 }
@@ -24,6 +38,11 @@ func NewCentroCostoService() *CentroCostoService {
 }
 func (s *CentroCostoService) CreateCC(cc *models.CC) (primitive.ObjectID, error) {
 	cc.ID = primitive.NewObjectID()
+	if !cc.Padre.IsZero() {
+		if err := s.validatePadre(cc.ID, cc.Padre); err != nil {
+			return primitive.NilObjectID, err
+		}
+	}
 
 	id, err := s.repo.InsertOne(cc)
 	if err != nil {
@@ -74,25 +93,323 @@ func (s *CentroCostoService) UpdateCC(id string, update bson.M) error {
 		return err
 	}
 	filter := bson.M{"_id": objID}
-	err = s.repo.UpdateOne(filter, update)
+	cambios := bson.M{"$set": update}
+	// padre llega como string desde el JSON, vacío o null lo quita
+	if raw, ok := update["padre"]; ok {
+		delete(update, "padre")
+		hex, _ := raw.(string)
+		if hex == "" {
+			cambios["$unset"] = bson.M{"padre": ""}
+		} else {
+			padre, err := primitive.ObjectIDFromHex(hex)
+			if err != nil {
+				return ErrPadreNoEncontrado
+			}
+			if err := s.validatePadre(objID, padre); err != nil {
+				return err
+			}
+			update["padre"] = padre
+		}
+	}
+	if len(update) == 0 {
+		delete(cambios, "$set")
+	}
+	err = s.repo.UpdateOne(filter, cambios)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *CentroCostoService) DeleteCC(id string) error {
+// CCDependents son los registros que referencian un centro de costo
+type CCDependents struct {
+	SolicitudesAbiertas int64 `json:"solicitudes_abiertas"`
+	Usuarios            int64 `json:"usuarios"`
+	Hijos               int64 `json:"hijos"` // centros de costo que lo tienen como padre
+}
+
+// CCDependentsError se retorna al eliminar un centro de costo con solicitudes abiertas
+type CCDependentsError struct {
+	Dependientes CCDependents
+}
+
+func (e *CCDependentsError) Error() string {
+	if e.Dependientes.Hijos > 0 {
+		return fmt.Sprintf("el centro de costo tiene %d centros de costo hijos, elimínelos o cámbielos de padre antes de eliminarlo", e.Dependientes.Hijos)
+	}
+	return fmt.Sprintf("el centro de costo tiene %d solicitudes abiertas, reasígnelas a otro centro de costo antes de eliminarlo", e.Dependientes.SolicitudesAbiertas)
+}
+
+// GetDependents cuenta las solicitudes abiertas y los usuarios del centro de costo
+func (s *CentroCostoService) GetDependents(ctx context.Context, id string) (*CCDependents, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := s.repo.FindByID(objID)
+	if err != nil {
+		return nil, err
+	}
+	if cc == nil {
+		return nil, ErrCentroCostoNoEncontrado
+	}
+	return s.dependents(ctx, objID)
+}
+
+func (s *CentroCostoService) dependents(ctx context.Context, id primitive.ObjectID) (*CCDependents, error) {
+	abiertas, err := getSolicitudRepo().CountOpenByCC(ctx, id, closedStates())
+	if err != nil {
+		return nil, err
+	}
+	usuarios, err := getUserRepo().FindIDsByCC(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	hijos, err := s.repo.CountChildren(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &CCDependents{SolicitudesAbiertas: abiertas, Usuarios: int64(len(usuarios)), Hijos: hijos}, nil
+}
+
+// DeleteCC elimina lógicamente el centro de costo, se puede restaurar hasta la purga. Si tiene
+// solicitudes abiertas o centros de costo hijos retorna un *CCDependentsError; los usuarios conservan el centro de costo
+// hasta la purga para que la restauración no pierda la membresía
+func (s *CentroCostoService) DeleteCC(ctx context.Context, id string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
-	filter := bson.M{"_id": objID}
-	err = s.repo.DeleteOne(filter)
+	return database.RunTransaction(ctx, func(tx context.Context) error {
+		dependientes, err := s.dependents(tx, objID)
+		if err != nil {
+			return err
+		}
+		if dependientes.SolicitudesAbiertas > 0 || dependientes.Hijos > 0 {
+			return &CCDependentsError{Dependientes: *dependientes}
+		}
+		ok, err := s.repo.SoftDelete(tx, objID, utils.GetRequestInfo(tx).UserID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrCentroCostoNoEncontrado
+		}
+		return nil
+	})
+}
+
+// CCReassignReport informa lo que se trasladó de un centro de costo a otro
+type CCReassignReport struct {
+	Origen      primitive.ObjectID   `json:"origen"`
+	Destino     primitive.ObjectID   `json:"destino"`
+	Usuarios    []primitive.ObjectID `json:"usuarios"`
+	Solicitudes []primitive.ObjectID `json:"solicitudes"`
+}
+
+// ReassignCC traslada los usuarios y las solicitudes abiertas del centro de costo origen al destino
+// en una transacción, registrando el cambio en el log de cada solicitud. Los pasos de jefe de centro de
+// costo pendientes del jefe del origen pasan al jefe del destino y lo comprometido por las solicitudes
+// aprobadas pasa al presupuesto del destino. Las solicitudes cerradas quedan en el origen
+func (s *CentroCostoService) ReassignCC(ctx context.Context, origen, destino string) (*CCReassignReport, error) {
+	origenID, err := primitive.ObjectIDFromHex(origen)
+	if err != nil {
+		return nil, err
+	}
+	destinoID, err := primitive.ObjectIDFromHex(destino)
+	if err != nil {
+		return nil, err
+	}
+	if origenID == destinoID {
+		return nil, ErrMismoCentroCosto
+	}
+	jefes := make([]primitive.ObjectID, 2)
+	for i, id := range []primitive.ObjectID{origenID, destinoID} {
+		cc, err := s.repo.FindByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if cc == nil {
+			return nil, fmt.Errorf("%w: %s", ErrCentroCostoNoEncontrado, id.Hex())
+		}
+		jefes[i] = cc.Jefe
+	}
+	jefeOrigen, jefeDestino := jefes[0], jefes[1]
+
+	var report *CCReassignReport
+	err = database.RunTransaction(ctx, func(tx context.Context) error {
+		// el reporte se arma de nuevo si la transacción se reintenta
+		report = &CCReassignReport{Origen: origenID, Destino: destinoID}
+
+		if report.Usuarios, err = getUserRepo().FindIDsByCC(tx, origenID); err != nil {
+			return err
+		}
+		if err := getUserRepo().MoveCC(tx, origenID, destinoID); err != nil {
+			return err
+		}
+
+		solicitudes, err := getSolicitudRepo().FindOpenByCC(tx, origenID, closedStates())
+		if err != nil {
+			return err
+		}
+		report.Solicitudes = make([]primitive.ObjectID, 0, len(solicitudes))
+		for _, previa := range solicitudes {
+			update := bson.M{}
+			if jefeOrigen != jefeDestino {
+				if reroute := rerouteJefeSteps(previa, jefeOrigen, jefeDestino); reroute != nil {
+					update = reroute
+				}
+			}
+			update["cc"] = destinoID
+			if previa.Presupuesto != nil && previa.Presupuesto.Estado == models.BudgetCommitted {
+				compromiso, err := moveBudgetCommitment(tx, previa, destinoID)
+				if err != nil {
+					return err
+				}
+				update["presupuesto"] = compromiso
+			}
+			// el filtro exige el estado leído para no pisar una decisión concurrente sobre los pasos
+			if err := getSolicitudRepo().UpdateOne(tx, unchangedStateFilter(previa), bson.M{"$set": update}); err != nil {
+				return err
+			}
+			posterior, err := getSolicitudRepo().FindByID(tx, previa.ID)
+			if err != nil {
+				return err
+			}
+			if _, err := CreateLogFromCCReassign(tx, posterior, previa); err != nil {
+				return fmt.Errorf("error al crear log de reasignación: %w", err)
+			}
+			report.Solicitudes = append(report.Solicitudes, previa.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// JefeHandoverReport informa el cambio de jefe de un centro de costo
+type JefeHandoverReport struct {
+	CC           primitive.ObjectID   `json:"cc"`
+	JefeAnterior primitive.ObjectID   `json:"jefe_anterior,omitempty"`
+	JefeNuevo    primitive.ObjectID   `json:"jefe_nuevo"`
+	Solicitudes  []primitive.ObjectID `json:"solicitudes"` // solicitudes con pasos traspasados al nuevo jefe
+}
+
+// ReassignJefe cambia el jefe del centro de costo en una transacción: actualiza centros_costo.jefe, agrega
+// el centro de costo a los del nuevo jefe y lo quita de los del anterior (registrado en membership_logs), y traspasa al nuevo jefe los pasos
+// de jefe de centro de costo pendientes del anterior, registrando el traspaso en el log de cada solicitud
+func (s *CentroCostoService) ReassignJefe(ctx context.Context, id, jefe string) (*JefeHandoverReport, error) {
+	ccID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	nuevo, err := primitive.ObjectIDFromHex(jefe)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := s.repo.FindByID(ccID)
+	if err != nil {
+		return nil, err
+	}
+	if cc == nil {
+		return nil, ErrCentroCostoNoEncontrado
+	}
+	if cc.Jefe == nuevo {
+		return nil, ErrMismoJefe
+	}
+	usuario, err := getUserRepo().FindOne(bson.M{"_id": nuevo})
+	if err != nil {
+		return nil, err
+	}
+	if usuario == nil {
+		return nil, ErrUsuarioNoEncontrado
+	}
+	anterior := cc.Jefe
+	rolPrevio := membershipRole(usuario, cc)
+
+	var report *JefeHandoverReport
+	err = database.RunTransaction(ctx, func(tx context.Context) error {
+		report = &JefeHandoverReport{CC: ccID, JefeAnterior: anterior, JefeNuevo: nuevo, Solicitudes: []primitive.ObjectID{}}
+
+		// el filtro exige el jefe leído para no pisar un cambio concurrente
+		ok, err := s.repo.SetJefe(tx, ccID, anterior, nuevo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrJefeModificado
+		}
+		if err := getUserRepo().AddCC(tx, nuevo, ccID); err != nil {
+			return err
+		}
+		if err := logMembership(tx, nuevo, ccID, rolPrevio, models.MembershipJefe, models.MembershipOriginHandover); err != nil {
+			return err
+		}
+		if !anterior.IsZero() {
+			if err := getUserRepo().RemoveCC(tx, anterior, ccID); err != nil {
+				return err
+			}
+			if err := logMembership(tx, anterior, ccID, models.MembershipJefe, "", models.MembershipOriginHandover); err != nil {
+				return err
+			}
+		}
+
+		pendientes := []string{string(transitions.PendienteAprobacion), string(transitions.LineaAprobada)}
+		solicitudes, err := getSolicitudRepo().FindPendingStepsOf(tx, ccID, pendientes, models.ApproverJefeCC, anterior)
+		if err != nil {
+			return err
+		}
+		for _, previa := range solicitudes {
+			update := rerouteJefeSteps(previa, anterior, nuevo)
+			if update == nil {
+				continue
+			}
+			if err := getSolicitudRepo().UpdateOne(tx, unchangedStateFilter(previa), bson.M{"$set": update}); err != nil {
+				return err
+			}
+			posterior, err := getSolicitudRepo().FindByID(tx, previa.ID)
+			if err != nil {
+				return err
+			}
+			if _, err := CreateLogFromJefeHandover(tx, posterior, previa); err != nil {
+				return fmt.Errorf("error al crear log de traspaso: %w", err)
+			}
+			report.Solicitudes = append(report.Solicitudes, previa.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func closedStates() []string {
+	estados := make([]string, 0, len(transitions.Cerrados))
+	for _, e := range transitions.Cerrados {
+		estados = append(estados, string(e))
+	}
+	return estados
+}
+
+// RestoreCC restaura un centro de costo eliminado
+func (s *CentroCostoService) RestoreCC(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return err
 	}
+	ok, err := s.repo.Restore(ctx, objID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoEliminado
+	}
 	return nil
 }
+
 func (s *CentroCostoService) GetAllCC() ([]*models.CC, error) {
 	centrosCosto, err := s.repo.FindAll()
 	if err != nil {
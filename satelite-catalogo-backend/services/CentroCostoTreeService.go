@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"catalogo-backend/models"
+	"catalogo-backend/transitions"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrPadreNoEncontrado = errors.New("centro de costo padre no encontrado")
+	ErrPadreCiclico      = errors.New("el centro de costo padre no puede ser el mismo centro de costo ni uno de sus descendientes")
+)
+
+// ParentJefeApproves indica si los jefes de un centro de costo pueden aprobar los pasos de jefe de sus
+// centros de costo descendientes (CC_PARENT_JEFE_APPROVES=true). Sin esta opción solo los ven
+func ParentJefeApproves() bool {
+	return os.Getenv("CC_PARENT_JEFE_APPROVES") == "true"
+}
+
+// ccTree es la estructura de centros de costo no eliminados, cargada completa porque es pequeña
+type ccTree struct {
+	byID  map[primitive.ObjectID]*models.CC
+	hijos map[primitive.ObjectID][]*models.CC
+}
+
+func (s *CentroCostoService) loadTree() (*ccTree, error) {
+	centros, err := s.repo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+	tree := &ccTree{byID: map[primitive.ObjectID]*models.CC{}, hijos: map[primitive.ObjectID][]*models.CC{}}
+	for _, cc := range centros {
+		tree.byID[cc.ID] = cc
+		if !cc.Padre.IsZero() {
+			tree.hijos[cc.Padre] = append(tree.hijos[cc.Padre], cc)
+		}
+	}
+	return tree, nil
+}
+
+// descendants retorna los descendientes de las raíces, sin las raíces
+func (t *ccTree) descendants(roots []primitive.ObjectID) []primitive.ObjectID {
+	visitados := map[primitive.ObjectID]bool{}
+	for _, id := range roots {
+		visitados[id] = true
+	}
+	result := []primitive.ObjectID{}
+	pendientes := append([]primitive.ObjectID{}, roots...)
+	for len(pendientes) > 0 {
+		id := pendientes[0]
+		pendientes = pendientes[1:]
+		for _, hijo := range t.hijos[id] {
+			// se ignoran los ciclos que pudieran haber quedado en los datos
+			if visitados[hijo.ID] {
+				continue
+			}
+			visitados[hijo.ID] = true
+			result = append(result, hijo.ID)
+			pendientes = append(pendientes, hijo.ID)
+		}
+	}
+	return result
+}
+
+// setPadre cambia el padre de un centro de costo del árbol cargado, sin escribir en la base de datos
+func (t *ccTree) setPadre(cc *models.CC, padre primitive.ObjectID) {
+	if !cc.Padre.IsZero() {
+		hermanos := t.hijos[cc.Padre]
+		for i, h := range hermanos {
+			if h.ID == cc.ID {
+				t.hijos[cc.Padre] = append(hermanos[:i:i], hermanos[i+1:]...)
+				break
+			}
+		}
+	}
+	cc.Padre = padre
+	if !padre.IsZero() {
+		t.hijos[padre] = append(t.hijos[padre], cc)
+	}
+}
+
+// createsCycle indica si asignar padre a id formaría un ciclo en el árbol
+func (t *ccTree) createsCycle(id, padre primitive.ObjectID) bool {
+	if padre == id {
+		return true
+	}
+	for _, d := range t.descendants([]primitive.ObjectID{id}) {
+		if d == padre {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *ccTree) node(cc *models.CC, visitados map[primitive.ObjectID]bool) *models.CCNode {
+	visitados[cc.ID] = true
+	node := &models.CCNode{CC: cc, Hijos: []*models.CCNode{}}
+	for _, hijo := range t.hijos[cc.ID] {
+		if !visitados[hijo.ID] {
+			node.Hijos = append(node.Hijos, t.node(hijo, visitados))
+		}
+	}
+	return node
+}
+
+// GetDescendantIDs retorna los centros de costo descendientes de los indicados, sin incluirlos
+func (s *CentroCostoService) GetDescendantIDs(roots []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	if len(roots) == 0 {
+		return nil, nil
+	}
+	tree, err := s.loadTree()
+	if err != nil {
+		return nil, err
+	}
+	return tree.descendants(roots), nil
+}
+
+// GetSubtree retorna el centro de costo con todos sus descendientes
+func (s *CentroCostoService) GetSubtree(id string) (*models.CCNode, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := s.loadTree()
+	if err != nil {
+		return nil, err
+	}
+	cc, ok := tree.byID[objID]
+	if !ok {
+		return nil, ErrCentroCostoNoEncontrado
+	}
+	return tree.node(cc, map[primitive.ObjectID]bool{}), nil
+}
+
+// validatePadre verifica que el padre exista y que asignarlo a id no forme un ciclo
+func (s *CentroCostoService) validatePadre(id, padre primitive.ObjectID) error {
+	if padre == id {
+		return ErrPadreCiclico
+	}
+	tree, err := s.loadTree()
+	if err != nil {
+		return err
+	}
+	if _, ok := tree.byID[padre]; !ok {
+		return ErrPadreNoEncontrado
+	}
+	if tree.createsCycle(id, padre) {
+		return ErrPadreCiclico
+	}
+	return nil
+}
+
+// GetSpendTree suma el gasto de las solicitudes del centro de costo y de cada descendiente en la
+// moneda de reporte, con fecha_solicitud en [desde, hasta). Cada nodo tiene su gasto propio y el total
+// con sus descendientes
+func (s *CentroCostoService) GetSpendTree(id string, desde, hasta time.Time) (*models.CCSpendNode, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	raiz, err := s.GetSubtree(id)
+	if err != nil {
+		return nil, err
+	}
+	ids := []primitive.ObjectID{}
+	var collect func(n *models.CCNode)
+	collect = func(n *models.CCNode) {
+		ids = append(ids, n.CC.ID)
+		for _, h := range n.Hijos {
+			collect(h)
+		}
+	}
+	collect(raiz)
+
+	estados := []string{
+		string(transitions.PendienteAprobacion), string(transitions.LineaAprobada),
+		string(transitions.Aprobada), string(transitions.Finalizada),
+	}
+	totales, err := getSolicitudRepo().SumByCCAndState(ctx, ids, estados, ReportingCurrency(), desde, hasta)
+	if err != nil {
+		return nil, err
+	}
+	propios := map[primitive.ObjectID]*models.CCSpend{}
+	for _, t := range totales {
+		gasto, ok := propios[t.CC]
+		if !ok {
+			gasto = &models.CCSpend{}
+			propios[t.CC] = gasto
+		}
+		switch transitions.State(t.State) {
+		case transitions.PendienteAprobacion, transitions.LineaAprobada:
+			gasto.Pendiente += t.Total
+		case transitions.Aprobada:
+			gasto.Aprobado += t.Total
+		case transitions.Finalizada:
+			gasto.Finalizado += t.Total
+		}
+		gasto.Solicitudes += t.Cantidad
+	}
+
+	var rollUp func(n *models.CCNode) *models.CCSpendNode
+	rollUp = func(n *models.CCNode) *models.CCSpendNode {
+		node := &models.CCSpendNode{CC: n.CC, Hijos: []*models.CCSpendNode{}}
+		if propio, ok := propios[n.CC.ID]; ok {
+			node.Propio = roundSpend(*propio)
+		}
+		node.Total = node.Propio
+		for _, h := range n.Hijos {
+			hijo := rollUp(h)
+			node.Total.Add(hijo.Total)
+			node.Hijos = append(node.Hijos, hijo)
+		}
+		node.Total = roundSpend(node.Total)
+		return node
+	}
+	return rollUp(raiz), nil
+}
+
+func roundSpend(g models.CCSpend) models.CCSpend {
+	g.Pendiente = roundAmount(g.Pendiente)
+	g.Aprobado = roundAmount(g.Aprobado)
+	g.Finalizado = roundAmount(g.Finalizado)
+	return g
+}
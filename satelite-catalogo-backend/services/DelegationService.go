@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"catalogo-backend/models"
+	"catalogo-backend/repositories"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	delegationRepo *repositories.DelegationRepository
+	onceDelegation sync.Once
+)
+
+var (
+	ErrDelegacionNoEncontrada = errors.New("delegación no encontrada")
+	ErrDelegacionNoPermitida  = errors.New("solo el delegante o un administrador pueden administrar la delegación")
+)
+
+func getDelegationRepo() *repositories.DelegationRepository {
+	onceDelegation.Do(func() {
+		delegationRepo = repositories.NewDelegationRepository()
+	})
+	return delegationRepo
+}
+
+func validateDelegation(delegation *models.Delegation) error {
+	verr := &ValidationError{}
+	if delegation.Delegado.IsZero() {
+		verr.add("delegado", "es obligatorio")
+	} else if delegation.Delegado == delegation.Delegante {
+		verr.add("delegado", "no puede delegarse a sí mismo")
+	} else if user, err := getUserRepo().FindOne(bson.M{"_id": delegation.Delegado}); err != nil {
+		return err
+	} else if user == nil {
+		verr.add("delegado", "el usuario no existe")
+	}
+	if delegation.FechaInicio.IsZero() || delegation.FechaFin.IsZero() {
+		verr.add("fecha_inicio", "fecha_inicio y fecha_fin son obligatorias")
+	} else if !delegation.FechaFin.After(delegation.FechaInicio) {
+		verr.add("fecha_fin", "debe ser posterior a fecha_inicio")
+	} else if delegation.FechaFin.Before(time.Now()) {
+		verr.add("fecha_fin", "la delegación ya terminó")
+	}
+	for i, ccID := range delegation.CCs {
+		cc, err := NewCentroCostoService().GetCCByID(ccID.Hex())
+		if err != nil {
+			return err
+		}
+		if cc == nil {
+			verr.add(fmt.Sprintf("ccs[%d]", i), "el centro de costo no existe")
+		}
+	}
+	delegation.Motivo = strings.TrimSpace(delegation.Motivo)
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// CreateDelegationService crea una delegación de la autoridad de aprobación. Un usuario solo puede
+// delegar su propia autoridad; un administrador puede indicar el delegante
+func CreateDelegationService(delegation *models.Delegation, principal *models.Principal) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if delegation.Delegante.IsZero() {
+		delegation.Delegante = principal.UserID
+	}
+	if delegation.Delegante != principal.UserID && !principal.IsAdmin() {
+		return ErrDelegacionNoPermitida
+	}
+	if err := validateDelegation(delegation); err != nil {
+		return err
+	}
+
+	delegation.ID = primitive.NewObjectID()
+	delegation.CreatedBy = principal.UserID
+	delegation.CreatedAt = time.Now()
+	delegation.RevokedAt = nil
+	delegation.RevokedBy = primitive.NilObjectID
+	return getDelegationRepo().InsertOne(ctx, delegation)
+}
+
+// RevokeDelegationService termina la delegación antes de su fecha de fin
+func RevokeDelegationService(id string, principal *models.Principal) (*models.Delegation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, fmt.Errorf("formato de ID inválido: %s", id)
+	}
+	delegation, err := getDelegationRepo().FindByID(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	if delegation == nil {
+		return nil, ErrDelegacionNoEncontrada
+	}
+	if delegation.Delegante != principal.UserID && !principal.IsAdmin() {
+		return nil, ErrDelegacionNoPermitida
+	}
+
+	ahora := time.Now()
+	revoked, err := getDelegationRepo().Revoke(ctx, objID, principal.UserID, ahora)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		delegation.RevokedAt = &ahora
+		delegation.RevokedBy = principal.UserID
+	}
+	return delegation, nil
+}
+
+// GetDelegationsService lista las delegaciones dadas o recibidas por el usuario, todas para un administrador
+func GetDelegationsService(principal *models.Principal, soloVigentes bool, page, pageSize int) ([]*models.Delegation, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if !principal.IsAdmin() {
+		filter["$or"] = []bson.M{{"delegante": principal.UserID}, {"delegado": principal.UserID}}
+	}
+	if soloVigentes {
+		ahora := time.Now()
+		filter["fecha_inicio"] = bson.M{"$lte": ahora}
+		filter["fecha_fin"] = bson.M{"$gte": ahora}
+		filter["revoked_at"] = bson.M{"$exists": false}
+	}
+	return getDelegationRepo().FindPaginated(ctx, filter, page, pageSize)
+}
+
+// getActiveDelegations retorna las delegaciones vigentes recibidas por el usuario con las
+// jefaturas del delegante que cubren
+func getActiveDelegations(userID primitive.ObjectID) ([]models.ActiveDelegation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	delegations, err := getDelegationRepo().FindActiveByDelegado(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	activas := make([]models.ActiveDelegation, 0, len(delegations))
+	for _, d := range delegations {
+		activa := models.ActiveDelegation{ID: d.ID, Delegante: d.Delegante, CCs: d.CCs, JefeDe: []primitive.ObjectID{}}
+		jefeDe, err := NewCentroCostoService().GetCCIDsByJefe(d.Delegante)
+		if err != nil {
+			return nil, err
+		}
+		for _, cc := range jefeDe {
+			if activa.Covers(cc) {
+				activa.JefeDe = append(activa.JefeDe, cc)
+			}
+		}
+		activas = append(activas, activa)
+	}
+	return activas, nil
+}
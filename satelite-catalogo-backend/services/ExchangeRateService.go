@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"catalogo-backend/models"
+	"catalogo-backend/repositories"
+	"catalogo-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	exchangeRateRepo *repositories.ExchangeRateRepository
+	onceExchangeRate sync.Once
+)
+
+var (
+	ErrTipoCambioNoDisponible = errors.New("no hay tipo de cambio disponible")
+	ErrMonedaNoSoportada      = errors.New("moneda no soportada")
+)
+
+func getExchangeRateRepo() *repositories.ExchangeRateRepository {
+	onceExchangeRate.Do(func() {
+		exchangeRateRepo = repositories.NewExchangeRateRepository()
+	})
+	return exchangeRateRepo
+}
+
+// ReportingCurrency retorna la moneda en que se consolidan los totales (REPORTING_CURRENCY, por defecto CLP)
+func ReportingCurrency() string {
+	if moneda := os.Getenv("REPORTING_CURRENCY"); moneda != "" {
+		return moneda
+	}
+	return models.CLP
+}
+
+// los tipos de cambio son diarios, se guardan con la fecha a medianoche UTC
+func rateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func validateExchangeRate(rate *models.ExchangeRate) []string {
+	var errores []string
+	rate.Moneda = strings.ToUpper(strings.TrimSpace(rate.Moneda))
+	switch {
+	case rate.Moneda == models.CLP:
+		errores = append(errores, "CLP es la moneda base, no requiere tipo de cambio")
+	case !models.IsSupportedCurrency(rate.Moneda):
+		errores = append(errores, fmt.Sprintf("moneda no soportada: %s", rate.Moneda))
+	}
+	if rate.Fecha.IsZero() {
+		errores = append(errores, "fecha es obligatoria")
+	}
+	if rate.Valor <= 0 {
+		errores = append(errores, "valor debe ser mayor a 0")
+	}
+	rate.Fecha = rateDay(rate.Fecha)
+	return errores
+}
+
+// SaveExchangeRatesService guarda los tipos de cambio recibidos, un valor por moneda y día
+func SaveExchangeRatesService(rates []models.ExchangeRate, dryRun bool) (*models.ImportReport, error) {
+	report := &models.ImportReport{DryRun: dryRun, Filas: []models.ImportRowResult{}}
+	for i := range rates {
+		report.Add(saveExchangeRate(&rates[i], i+1, dryRun))
+	}
+	return report, nil
+}
+
+func saveExchangeRate(rate *models.ExchangeRate, fila int, dryRun bool) models.ImportRowResult {
+	errores := validateExchangeRate(rate)
+	result := models.ImportRowResult{Fila: fila, Clave: rate.Moneda + "/" + rate.Fecha.Format("2006-01-02")}
+	if len(errores) > 0 {
+		result.Accion = models.ImportRejected
+		result.Errores = errores
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if dryRun {
+		existing, err := getExchangeRateRepo().FindLatest(ctx, rate.Moneda, rate.Fecha)
+		if err != nil {
+			result.Accion = models.ImportRejected
+			result.Errores = []string{err.Error()}
+		} else if existing != nil && existing.Fecha.Equal(rate.Fecha) {
+			result.Accion = models.ImportUpdated
+		} else {
+			result.Accion = models.ImportCreated
+		}
+		return result
+	}
+
+	created, err := getExchangeRateRepo().Upsert(ctx, rate)
+	switch {
+	case err != nil:
+		result.Accion = models.ImportRejected
+		result.Errores = []string{err.Error()}
+	case created:
+		result.Accion = models.ImportCreated
+	default:
+		result.Accion = models.ImportUpdated
+	}
+	return result
+}
+
+// ImportExchangeRatesService importa tipos de cambio desde una planilla con columnas moneda, fecha, valor y fuente (opcional)
+func ImportExchangeRatesService(rows [][]string, dryRun bool) (*models.ImportReport, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("el archivo está vacío")
+	}
+	columns := map[string]int{}
+	for i, header := range rows[0] {
+		columns[utils.NormalizeHeader(header)] = i
+	}
+	for _, required := range []string{"moneda", "fecha", "valor"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("falta la columna obligatoria: %s", required)
+		}
+	}
+
+	report := &models.ImportReport{DryRun: dryRun, Filas: []models.ImportRowResult{}}
+	for i, row := range rows[1:] {
+		if isEmptyRow(row) {
+			continue
+		}
+		get := func(field string) string {
+			idx, ok := columns[field]
+			if !ok || idx >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[idx])
+		}
+
+		fila := i + 2
+		rate := models.ExchangeRate{Moneda: get("moneda"), Fuente: get("fuente")}
+		var errores []string
+		fecha, err := utils.ParseDate(get("fecha"))
+		if err != nil {
+			errores = append(errores, fmt.Sprintf("fecha inválida: %s", get("fecha")))
+		}
+		rate.Fecha = fecha
+		valor, err := ParsePrecio(get("valor"))
+		if err != nil {
+			errores = append(errores, fmt.Sprintf("valor inválido: %s", get("valor")))
+		}
+		rate.Valor = valor
+		if len(errores) > 0 {
+			report.Add(models.ImportRowResult{Fila: fila, Accion: models.ImportRejected, Errores: errores})
+			continue
+		}
+		report.Add(saveExchangeRate(&rate, fila, dryRun))
+	}
+	return report, nil
+}
+
+// GetExchangeRatesService lista los tipos de cambio, opcionalmente filtrados por moneda
+func GetExchangeRatesService(moneda string, page, pageSize int) ([]*models.ExchangeRate, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if moneda != "" {
+		filter["moneda"] = strings.ToUpper(moneda)
+	}
+	return getExchangeRateRepo().FindPaginated(ctx, filter, page, pageSize)
+}
+
+// rateConverter convierte montos entre monedas usando los tipos de cambio vigentes a una fecha
+// y recuerda los valores usados
+type rateConverter struct {
+	ctx   context.Context
+	fecha time.Time
+	rates map[string]models.AppliedRate
+}
+
+func newRateConverter(ctx context.Context, fecha time.Time) *rateConverter {
+	if fecha.IsZero() {
+		fecha = time.Now()
+	}
+	return &rateConverter{ctx: ctx, fecha: fecha, rates: map[string]models.AppliedRate{}}
+}
+
+func (c *rateConverter) rate(moneda string) (models.AppliedRate, error) {
+	if moneda == models.CLP {
+		return models.AppliedRate{Moneda: models.CLP, Fecha: rateDay(c.fecha), Valor: 1}, nil
+	}
+	if rate, ok := c.rates[moneda]; ok {
+		return rate, nil
+	}
+	found, err := getExchangeRateRepo().FindLatest(c.ctx, moneda, c.fecha)
+	if err != nil {
+		return models.AppliedRate{}, err
+	}
+	if found == nil {
+		return models.AppliedRate{}, fmt.Errorf("%w para %s al %s", ErrTipoCambioNoDisponible, moneda, c.fecha.Format("2006-01-02"))
+	}
+	rate := models.AppliedRate{Moneda: moneda, Fecha: found.Fecha, Valor: found.Valor}
+	c.rates[moneda] = rate
+	return rate, nil
+}
+
+// Convert convierte el monto de una moneda a otra pasando por pesos
+func (c *rateConverter) Convert(amount float64, from, to string) (float64, error) {
+	if from == to {
+		return amount, nil
+	}
+	fromRate, err := c.rate(from)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := c.rate(to)
+	if err != nil {
+		return 0, err
+	}
+	return roundAmount(amount * fromRate.Valor / toRate.Valor), nil
+}
+
+// Applied retorna los tipos de cambio usados en las conversiones, ordenados por moneda
+func (c *rateConverter) Applied() []models.AppliedRate {
+	applied := make([]models.AppliedRate, 0, len(c.rates))
+	for _, rate := range c.rates {
+		applied = append(applied, rate)
+	}
+	sort.Slice(applied, func(i, j int) bool { return applied[i].Moneda < applied[j].Moneda })
+	return applied
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// intentos para tomar el siguiente lugar de la cadena cuando otro log de la misma solicitud lo ocupó
+const maxChainRetries = 5
+
+// appendChained inserta el log a continuación del último log encadenado de la solicitud. El hash se
+// calcula sobre los bytes BSON del log, que incluyen prev_hash, y el documento se inserta con esos
+// mismos bytes para poder recalcularlo al verificar. Dentro de una transacción no se reintenta: el
+// conflicto aborta la transacción y la reintenta database.RunTransaction
+func (s *LogService) appendChained(ctx context.Context, logEntry *models.RequestLog) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	reintentar := mongo.SessionFromContext(ctx) == nil
+
+	for intento := 1; ; intento++ {
+		last, err := s.repo.FindLastChained(ctx, logEntry.RequestID)
+		if err != nil {
+			return "", err
+		}
+		logEntry.Secuencia = 1
+		logEntry.PrevHash = ""
+		if last != nil {
+			logEntry.Secuencia = last.Secuencia + 1
+			logEntry.PrevHash = last.Hash
+		}
+		// el _id va primero para que MongoDB no reordene el documento
+		logEntry.ID = primitive.NewObjectID()
+		logEntry.Hash = ""
+
+		doc, hash, err := sealLog(logEntry)
+		if err != nil {
+			return "", err
+		}
+		err = s.repo.InsertRaw(ctx, doc)
+		if mongo.IsDuplicateKeyError(err) && reintentar && intento < maxChainRetries {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		// la solicitud guarda el último eslabón, así se detecta si se eliminan los logs del final
+		if err := getSolicitudRepo().SetLogAnchor(ctx, logEntry.RequestID, logEntry.Secuencia, hash); err != nil {
+			return "", err
+		}
+		logEntry.Hash = hash
+		return logEntry.ID.Hex(), nil
+	}
+}
+
+// sealLog serializa el log y le agrega el hash de su contenido
+func sealLog(logEntry *models.RequestLog) (bson.Raw, string, error) {
+	raw, err := bson.Marshal(logEntry)
+	if err != nil {
+		return nil, "", err
+	}
+	elementos := raw[4 : len(raw)-1]
+	hash := hashElements(elementos)
+
+	elementos = bsoncore.AppendStringElement(append([]byte{}, elementos...), "hash", hash)
+	return bson.Raw(bsoncore.BuildDocument(nil, elementos)), hash, nil
+}
+
+// logHash recalcula el hash de un log guardado: los elementos del documento en el orden guardado, sin hash
+func logHash(doc bson.Raw) (string, error) {
+	elems, err := doc.Elements()
+	if err != nil {
+		return "", err
+	}
+	contenido := []byte{}
+	for _, e := range elems {
+		if e.Key() == "hash" {
+			continue
+		}
+		contenido = append(contenido, e...)
+	}
+	return hashElements(contenido), nil
+}
+
+// hashElements calcula el HMAC-SHA256 con LOG_CHAIN_KEY, que no se guarda en la base de datos: quien
+// solo tiene acceso a la base de datos no puede recalcular los hashes de un log modificado
+func hashElements(b []byte) string {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("LOG_CHAIN_KEY")))
+	mac.Write(b)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ChainIssue es un problema encontrado al verificar la cadena de logs de una solicitud
+type ChainIssue struct {
+	LogID     primitive.ObjectID `json:"log_id"`
+	Secuencia int64              `json:"secuencia,omitempty"`
+	Problema  string             `json:"problema"`
+}
+
+// ChainReport es el resultado de verificar la cadena de logs de una solicitud
+type ChainReport struct {
+	RequestID    primitive.ObjectID `json:"request_id"`
+	Total        int                `json:"total"`
+	Encadenados  int                `json:"encadenados"`
+	SinEncadenar int                `json:"sin_encadenar"` // logs anteriores a la cadena, no se pueden verificar
+	Valida       bool               `json:"valida"`
+	Problemas    []ChainIssue       `json:"problemas"`
+}
+
+// VerifyLogChainService recorre los logs de la solicitud y detecta logs modificados (el hash no
+// coincide), eliminados (falta una secuencia, el prev_hash no es el hash del anterior o la cadena
+// termina antes del último log registrado en la solicitud) o sin hash dentro de la cadena
+func VerifyLogChainService(requestID primitive.ObjectID) (*ChainReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// la solicitud puede no existir si la purga la borró, sus logs se verifican igual
+	solicitud, err := getSolicitudRepo().FindByID(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+	return verifyLogChain(requestID, solicitud, func(fn func(bson.Raw) error) error {
+		return getLogService().repo.EachRawByRequest(ctx, requestID, fn)
+	})
+}
+
+// verifyLogChain verifica los logs que entrega each, en orden de secuencia, contra el último log
+// registrado en la solicitud
+func verifyLogChain(requestID primitive.ObjectID, solicitud *models.Solicitud, each func(func(bson.Raw) error) error) (*ChainReport, error) {
+	report := &ChainReport{RequestID: requestID, Problemas: []ChainIssue{}}
+	var anterior *models.RequestLog
+
+	err := each(func(doc bson.Raw) error {
+		report.Total++
+		var entry models.RequestLog
+		if err := bson.Unmarshal(doc, &entry); err != nil {
+			report.Problemas = append(report.Problemas, ChainIssue{Problema: "log ilegible: " + err.Error()})
+			return nil
+		}
+		problema := func(format string, args ...interface{}) {
+			report.Problemas = append(report.Problemas, ChainIssue{LogID: entry.ID, Secuencia: entry.Secuencia, Problema: fmt.Sprintf(format, args...)})
+		}
+
+		if entry.Hash == "" {
+			if entry.Secuencia != 0 || entry.PrevHash != "" || anterior != nil {
+				problema("log sin hash dentro de la cadena")
+				return nil
+			}
+			report.SinEncadenar++
+			return nil
+		}
+		report.Encadenados++
+
+		if hash, err := logHash(doc); err != nil || hash != entry.Hash {
+			problema("el contenido no coincide con el hash, el log fue modificado")
+		}
+		esperada, prevHash := int64(1), ""
+		if anterior != nil {
+			esperada, prevHash = anterior.Secuencia+1, anterior.Hash
+		}
+		switch {
+		case entry.Secuencia > esperada:
+			problema("faltan los logs %d a %d", esperada, entry.Secuencia-1)
+		case entry.Secuencia < esperada:
+			problema("secuencia repetida, se esperaba %d", esperada)
+		case entry.PrevHash != prevHash:
+			problema("prev_hash no coincide con el hash del log anterior")
+		}
+		anterior = &entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if solicitud != nil && solicitud.LogSecuencia > 0 {
+		ultima := int64(0)
+		if anterior != nil {
+			ultima = anterior.Secuencia
+		}
+		problema := func(format string, args ...interface{}) {
+			report.Problemas = append(report.Problemas, ChainIssue{Secuencia: solicitud.LogSecuencia, Problema: fmt.Sprintf(format, args...)})
+		}
+		switch {
+		case ultima < solicitud.LogSecuencia:
+			problema("faltan los logs %d a %d, la solicitud registra %d logs", ultima+1, solicitud.LogSecuencia, solicitud.LogSecuencia)
+		case ultima > solicitud.LogSecuencia:
+			problema("la solicitud registra %d logs y la cadena tiene %d", solicitud.LogSecuencia, ultima)
+		case anterior.Hash != solicitud.LogHash:
+			problema("el último log no coincide con el hash registrado en la solicitud")
+		}
+	}
+	report.Valida = len(report.Problemas) == 0
+	return report, nil
+}
+
+// VerifyAllLogChainsService verifica las cadenas de todas las solicitudes con logs
+func VerifyAllLogChainsService() ([]*ChainReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	ids, err := getLogService().repo.DistinctRequestIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]*ChainReport, 0, len(ids))
+	for _, id := range ids {
+		report, err := VerifyLogChainService(id)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
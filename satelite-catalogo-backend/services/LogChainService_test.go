@@ -0,0 +1,80 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// cadena arma los logs sellados de una solicitud y la solicitud con el último registrado, como appendChained
+func cadena(t *testing.T, n int) ([]bson.Raw, *models.Solicitud) {
+	t.Helper()
+	t.Setenv("LOG_CHAIN_KEY", "clave-de-prueba")
+	solicitud := &models.Solicitud{ID: primitive.NewObjectID()}
+	docs := make([]bson.Raw, 0, n)
+	prevHash := ""
+	for i := 1; i <= n; i++ {
+		entry := &models.RequestLog{
+			ID:        primitive.NewObjectID(),
+			RequestID: solicitud.ID,
+			Timestamp: time.Date(2026, 1, 1, 0, i, 0, 0, time.UTC),
+			EventType: models.LogEventUpdate,
+			Secuencia: int64(i),
+			PrevHash:  prevHash,
+		}
+		doc, hash, err := sealLog(entry)
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs = append(docs, doc)
+		prevHash = hash
+		solicitud.LogSecuencia, solicitud.LogHash = int64(i), hash
+	}
+	return docs, solicitud
+}
+
+func verificar(t *testing.T, docs []bson.Raw, solicitud *models.Solicitud) *ChainReport {
+	t.Helper()
+	report, err := verifyLogChain(solicitud.ID, solicitud, func(fn func(bson.Raw) error) error {
+		for _, doc := range docs {
+			if err := fn(doc); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return report
+}
+
+func TestVerifyLogChainValida(t *testing.T) {
+	docs, solicitud := cadena(t, 3)
+	if report := verificar(t, docs, solicitud); !report.Valida || report.Encadenados != 3 {
+		t.Errorf("cadena íntegra reportada como %+v", report)
+	}
+}
+
+// eliminar los últimos logs deja una cadena consistente, solo la solicitud sabe cuántos había
+func TestVerifyLogChainDetectaTruncamientoFinal(t *testing.T) {
+	docs, solicitud := cadena(t, 3)
+	for _, n := range []int{2, 0} {
+		if report := verificar(t, docs[:n], solicitud); report.Valida {
+			t.Errorf("cadena truncada a %d logs reportada como válida", n)
+		}
+	}
+}
+
+// sin la clave no se puede recalcular el hash de un log modificado
+func TestVerifyLogChainRequiereLaClave(t *testing.T) {
+	docs, solicitud := cadena(t, 2)
+	t.Setenv("LOG_CHAIN_KEY", "otra-clave")
+	if report := verificar(t, docs, solicitud); report.Valida {
+		t.Error("cadena sellada con otra clave reportada como válida")
+	}
+}
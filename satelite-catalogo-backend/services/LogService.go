@@ -1,10 +1,19 @@
 package services
 
 import (
+	"catalogo-backend/diff"
 	"catalogo-backend/models"
 	"catalogo-backend/repositories"
+	"catalogo-backend/transitions"
+	"catalogo-backend/utils"
+	"context"
+	"fmt"
+	"log"
 	"sync"
 	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var (
@@ -25,61 +34,325 @@ func getLogService() *LogService {
 	return logService
 }
 
-// createLogFromSolicitud crea un log a partir de una solicitud
-func CreateLogFromSolicitud(solicitud *models.Solicitud) (string, error) {
+// CreateLogFromSolicitud crea un log a partir de una solicitud
+func CreateLogFromSolicitud(ctx context.Context, solicitud *models.Solicitud) (string, error) {
 	logEntry := &models.RequestLog{
 		RequestID:     solicitud.ID,
 		Timestamp:     time.Now(),
-		EventType:     "create",
+		EventType:     models.LogEventCreate,
 		Description:   "creación de solicitud",
 		PreviousState: nil,       // No hay estado previo al crear una solicitud
 		NewState:      solicitud, // El nuevo estado es la solicitud actual
-		UserID:        solicitud.Solicitante,
+		CC:            solicitud.CC,
 	}
+	setLogRequest(ctx, logEntry)
 
-	id, err := getLogService().CreateLog(logEntry)
+	id, err := getLogService().CreateLog(ctx, logEntry)
 	if err != nil {
 		return "error al crear el log de la solicitud", err
 	}
 	return id, nil
 }
 
-// createLogFromUpdate crea un log a partir de una actualización de solicitud
-func CreateLogFromUpdate(solicitud *models.Solicitud, previousState *models.Solicitud) (string, error) {
+// CreateLogFromUpdate crea un log a partir de una actualización de solicitud
+func CreateLogFromUpdate(ctx context.Context, solicitud *models.Solicitud, previousState *models.Solicitud) (string, error) {
 	logEntry := &models.RequestLog{
-		RequestID:     solicitud.ID,
-		Timestamp:     time.Now(),
-		EventType:     "update",
-		Description:   "Actualización de la solicitud",
-		PreviousState: previousState,       // Estado previo antes de la actualización
-		NewState:      solicitud,           // El nuevo estado es la solicitud actualizada
-		UserID:        solicitud.Aprobador, // se asocia al aprobador que realizó la actualización
+		RequestID:   solicitud.ID,
+		Timestamp:   time.Now(),
+		EventType:   models.LogEventUpdate,
+		Description: "Actualización de la solicitud",
+		CC:          solicitud.CC,
 	}
+	setLogRequest(ctx, logEntry)
+	setLogChanges(logEntry, previousState, solicitud)
 
-	id, err := getLogService().CreateLog(logEntry)
+	id, err := getLogService().CreateLog(ctx, logEntry)
 	if err != nil {
 		return "error al crear el log de actualización de la solicitud", err
 	}
 	return id, nil
 }
 
-// Métodos CRUD clásicos
-func (s *LogService) CreateLog(log *models.RequestLog) (string, error) {
-	return s.repo.InsertOne(log)
+// CreateLogFromTransition crea un log a partir de un cambio de estado
+func CreateLogFromTransition(ctx context.Context, solicitud *models.Solicitud, previousState *models.Solicitud, transition transitions.Name, delegacion *transitions.Delegacion, comentario string) (string, error) {
+	logEntry := &models.RequestLog{
+		RequestID:   solicitud.ID,
+		Timestamp:   time.Now(),
+		EventType:   models.LogEventTransition,
+		Description: fmt.Sprintf("Transición %s: %s -> %s", transition, previousState.State, solicitud.State),
+		CC:          solicitud.CC,
+		Transition:  string(transition),
+		FromState:   previousState.State,
+		ToState:     solicitud.State,
+		Comentario:  comentario,
+	}
+	// al aprobar o rechazar se registra el paso de la cadena de aprobación que se decidió
+	if step := transitions.CurrentStep(previousState); step != nil && (transition == transitions.Approve || transition == transitions.Reject) {
+		logEntry.OrdenPaso = step.Orden
+		logEntry.PasoNombre = step.Nombre
+		logEntry.Description = fmt.Sprintf("Transición %s, paso %d (%s): %s -> %s", transition, step.Orden, step.Nombre, previousState.State, solicitud.State)
+	}
+
+	setLogRequest(ctx, logEntry)
+	setLogChanges(logEntry, previousState, solicitud)
+	setLogDelegation(logEntry, delegacion)
+
+	id, err := getLogService().CreateLog(ctx, logEntry)
+	if err != nil {
+		return "error al crear el log de transición de la solicitud", err
+	}
+	return id, nil
 }
 
-func (s *LogService) GetAllLogs() ([]*models.RequestLog, error) {
-	return s.repo.FindAll()
+// CreateLogFromLineDecision crea un log a partir de la aprobación o rechazo de una línea
+func CreateLogFromLineDecision(ctx context.Context, solicitud *models.Solicitud, previousState *models.Solicitud, numeroLinea int, decision models.LineState, delegacion *transitions.Delegacion, motivo string) (string, error) {
+	accion := "aprobada"
+	if decision == models.LineStateRechazada {
+		accion = "rechazada"
+	}
+	logEntry := &models.RequestLog{
+		RequestID:   solicitud.ID,
+		Timestamp:   time.Now(),
+		EventType:   models.LogEventLineDecision,
+		Description: fmt.Sprintf("Línea %d %s", numeroLinea, accion),
+		CC:          solicitud.CC,
+		FromState:   previousState.State,
+		ToState:     solicitud.State,
+		Comentario:  motivo,
+		NumeroLinea: numeroLinea,
+	}
+	// si con la línea quedaron todas decididas también se decidió el paso de aprobación
+	if step := transitions.CurrentStep(previousState); step != nil && previousState.PasoActual < len(solicitud.Aprobaciones) &&
+		solicitud.Aprobaciones[previousState.PasoActual].Estado != models.ApprovalStepPendiente {
+		logEntry.OrdenPaso = step.Orden
+		logEntry.PasoNombre = step.Nombre
+	}
+
+	setLogRequest(ctx, logEntry)
+	setLogChanges(logEntry, previousState, solicitud)
+	setLogDelegation(logEntry, delegacion)
+
+	id, err := getLogService().CreateLog(ctx, logEntry)
+	if err != nil {
+		return "error al crear el log de decisión de línea", err
+	}
+	return id, nil
 }
 
-func (s *LogService) GetLogByID(id string) (*models.RequestLog, error) {
-	return s.repo.FindByID(id)
+// CreateLogFromCCReassign crea un log del traslado de la solicitud a otro centro de costo
+func CreateLogFromCCReassign(ctx context.Context, solicitud *models.Solicitud, previousState *models.Solicitud) (string, error) {
+	return createLogFromChange(ctx, solicitud, previousState, models.LogEventUpdate, "Reasignación del centro de costo")
+}
+
+// CreateLogFromJefeHandover crea un log del traspaso de los pasos pendientes de la solicitud al nuevo jefe
+// del centro de costo
+func CreateLogFromJefeHandover(ctx context.Context, solicitud *models.Solicitud, previousState *models.Solicitud) (string, error) {
+	return createLogFromChange(ctx, solicitud, previousState, models.LogEventHandover, "Traspaso de la aprobación al nuevo jefe del centro de costo")
+}
+
+// createLogFromChange crea un log con los cambios de la solicitud hechos por una operación administrativa
+func createLogFromChange(ctx context.Context, solicitud *models.Solicitud, previousState *models.Solicitud, evento models.LogEventType, description string) (string, error) {
+	logEntry := &models.RequestLog{
+		RequestID:   solicitud.ID,
+		Timestamp:   time.Now(),
+		EventType:   evento,
+		Description: description,
+		CC:          solicitud.CC,
+	}
+	setLogRequest(ctx, logEntry)
+	setLogChanges(logEntry, previousState, solicitud)
+
+	id, err := getLogService().CreateLog(ctx, logEntry)
+	if err != nil {
+		return "error al crear el log de la solicitud", err
+	}
+	return id, nil
+}
+
+// CreateLogFromSoftDelete crea un log de eliminación o restauración de la solicitud
+func CreateLogFromSoftDelete(ctx context.Context, solicitud *models.Solicitud, previousState *models.Solicitud, evento models.LogEventType) (string, error) {
+	description := "Eliminación de la solicitud"
+	if evento == models.LogEventRestore {
+		description = "Restauración de la solicitud"
+	}
+	logEntry := &models.RequestLog{
+		RequestID:   solicitud.ID,
+		Timestamp:   time.Now(),
+		EventType:   evento,
+		Description: description,
+		CC:          solicitud.CC,
+	}
+	setLogRequest(ctx, logEntry)
+	setLogChanges(logEntry, previousState, solicitud)
+
+	id, err := getLogService().CreateLog(ctx, logEntry)
+	if err != nil {
+		return "error al crear el log de eliminación de la solicitud", err
+	}
+	return id, nil
+}
+
+// setLogRequest registra en el log el usuario autenticado y los datos de la llamada que originó el cambio
+func setLogRequest(ctx context.Context, logEntry *models.RequestLog) {
+	info := utils.GetRequestInfo(ctx)
+	logEntry.UserID = info.UserID
+	logEntry.IP = info.IP
+	logEntry.UserAgent = info.UserAgent
+	logEntry.CorrelationID = info.CorrelationID
+}
+
+// setLogChanges registra en el log los campos que cambiaron en vez de los estados completos. Si no se
+// puede calcular la diferencia se guardan ambos estados para no perder información
+func setLogChanges(logEntry *models.RequestLog, previousState, solicitud *models.Solicitud) {
+	cambios, err := diff.Solicitudes(previousState, solicitud)
+	if err != nil {
+		log.Println("Error al calcular los cambios de la solicitud:", err)
+		logEntry.PreviousState = previousState
+		logEntry.NewState = solicitud
+		return
+	}
+	logEntry.Cambios = cambios
+}
+
+// setLogDelegation registra en el log el usuario en cuyo nombre se actuó, junto al que ejecutó la acción
+func setLogDelegation(logEntry *models.RequestLog, delegacion *transitions.Delegacion) {
+	if delegacion == nil {
+		return
+	}
+	logEntry.EnNombreDe = delegacion.Delegante
+	logEntry.DelegacionID = delegacion.ID
+	logEntry.Description += " (en nombre de " + delegacion.Delegante.Hex() + ")"
+}
+
+// LogQuery son los filtros de la línea de tiempo de una solicitud y de la búsqueda de auditoría,
+// los campos vacíos no filtran
+type LogQuery struct {
+	RequestID primitive.ObjectID
+	UserID    primitive.ObjectID
+	CC        primitive.ObjectID
+	// ID de correlación de la llamada, para encontrar todos los eventos que originó
+	CorrelationID string
+	EventTypes    []models.LogEventType
+	Desde         time.Time
+	Hasta         time.Time // exclusiva
+	Snapshots     bool      // incluir previous_state y new_state
+}
+
+func (q LogQuery) filter() bson.M {
+	filter := bson.M{}
+	if !q.RequestID.IsZero() {
+		filter["request_id"] = q.RequestID
+	}
+	if !q.UserID.IsZero() {
+		// también aparecen los eventos hechos por un delegado en nombre del usuario
+		filter["$or"] = []bson.M{{"user_id": q.UserID}, {"en_nombre_de": q.UserID}}
+	}
+	if !q.CC.IsZero() {
+		filter["cc"] = q.CC
+	}
+	if q.CorrelationID != "" {
+		filter["correlation_id"] = q.CorrelationID
+	}
+	if len(q.EventTypes) > 0 {
+		filter["event_type"] = bson.M{"$in": q.EventTypes}
+	}
+	rango := bson.M{}
+	if !q.Desde.IsZero() {
+		rango["$gte"] = q.Desde
+	}
+	if !q.Hasta.IsZero() {
+		rango["$lt"] = q.Hasta
+	}
+	if len(rango) > 0 {
+		filter["timestamp"] = rango
+	}
+	return filter
 }
 
-func (s *LogService) UpdateLog(id string, log *models.RequestLog) error {
-	return s.repo.UpdateOne(id, log)
+// GetSolicitudHistoryService retorna la línea de tiempo de la solicitud, del evento más antiguo al más reciente
+func GetSolicitudHistoryService(requestID primitive.ObjectID, query LogQuery, page, pageSize int) ([]*models.RequestLog, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query.RequestID = requestID
+	logs, total, err := getLogService().repo.FindPaginated(ctx, query.filter(), page, pageSize, true, query.Snapshots)
+	if err != nil {
+		return nil, 0, err
+	}
+	describeChanges(logs)
+	return logs, total, nil
 }
 
-func (s *LogService) DeleteByID(id string) error {
-	return s.repo.DeleteByID(id)
+// SearchLogsService busca en los logs de todas las solicitudes, del evento más reciente al más antiguo
+func SearchLogsService(query LogQuery, page, pageSize int) ([]*models.RequestLog, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	logs, total, err := getLogService().repo.FindPaginated(ctx, query.filter(), page, pageSize, false, query.Snapshots)
+	if err != nil {
+		return nil, 0, err
+	}
+	describeChanges(logs)
+	return logs, total, nil
+}
+
+// describeChanges prepara los cambios de los logs para mostrarlos: los valores leídos de la base de
+// datos se convierten en objetos JSON y cada cambio se describe en texto
+func describeChanges(logs []*models.RequestLog) {
+	for _, logEntry := range logs {
+		for i := range logEntry.Cambios {
+			c := &logEntry.Cambios[i]
+			c.Anterior = diff.Normalize(c.Anterior)
+			c.Nuevo = diff.Normalize(c.Nuevo)
+			c.Texto = diff.Describe(*c)
+		}
+	}
+}
+
+// LogBackfillReport es el resultado de calcular los cambios de los logs existentes
+type LogBackfillReport struct {
+	DryRun       bool     `json:"dry_run"`
+	Revisados    int      `json:"revisados"`
+	Actualizados int      `json:"actualizados"`
+	Errores      []string `json:"errores,omitempty"`
+}
+
+// BackfillLogChangesService calcula los cambios de los logs guardados con los estados completos.
+// Con dropSnapshots además elimina previous_state y new_state de esos logs
+func BackfillLogChangesService(dropSnapshots, dryRun bool) (*LogBackfillReport, error) {
+	ctx := context.Background()
+	report := &LogBackfillReport{DryRun: dryRun}
+	repo := getLogService().repo
+
+	err := repo.EachPendingChanges(ctx, func(logEntry *models.RequestLog) error {
+		report.Revisados++
+		cambios, err := diff.Solicitudes(logEntry.PreviousState, logEntry.NewState)
+		if err != nil {
+			report.Errores = append(report.Errores, fmt.Sprintf("log %s: %v", logEntry.ID.Hex(), err))
+			return nil
+		}
+		if dryRun {
+			report.Actualizados++
+			return nil
+		}
+		if err := repo.SetChanges(ctx, logEntry.ID, cambios, dropSnapshots); err != nil {
+			return err
+		}
+		report.Actualizados++
+		return nil
+	})
+	return report, err
+}
+
+// Los logs son de solo inserción, cada uno queda encadenado con el anterior de la solicitud (ver LogChainService.go)
+func (s *LogService) CreateLog(ctx context.Context, log *models.RequestLog) (string, error) {
+	return s.appendChained(ctx, log)
+}
+
+func (s *LogService) GetAllLogs() ([]*models.RequestLog, error) {
+	return s.repo.FindAll()
+}
+
+func (s *LogService) GetLogByID(id string) (*models.RequestLog, error) {
+	return s.repo.FindByID(id)
 }
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"catalogo-backend/database"
+	"catalogo-backend/models"
+	"catalogo-backend/repositories"
+	"catalogo-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	membershipLogRepo *repositories.MembershipLogRepository
+	onceMembershipLog sync.Once
+)
+
+var (
+	ErrRolMembresiaInvalido  = errors.New("rol inválido, debe ser solicitante, jefe u observador")
+	ErrMembresiaNoEncontrada = errors.New("el usuario no pertenece al centro de costo")
+	ErrMembresiaJefe         = errors.New("el usuario es el jefe del centro de costo, cambie el jefe con PUT /cc/{id}/jefe")
+)
+
+func getMembershipLogRepo() *repositories.MembershipLogRepository {
+	onceMembershipLog.Do(func() {
+		membershipLogRepo = repositories.NewMembershipLogRepository()
+	})
+	return membershipLogRepo
+}
+
+// membershipRole retorna el rol del usuario en el centro de costo, vacío si no pertenece a él
+func membershipRole(user *models.User, cc *models.CC) models.MembershipRole {
+	switch {
+	case cc.Jefe == user.ID:
+		return models.MembershipJefe
+	case !containsObjectID(user.CC, cc.ID):
+		return ""
+	case containsObjectID(user.CCObservador, cc.ID):
+		return models.MembershipObservador
+	}
+	return models.MembershipSolicitante
+}
+
+// logMembership registra el cambio de membresía con los datos de la llamada del ctx
+func logMembership(ctx context.Context, usuario, cc primitive.ObjectID, anterior, nuevo models.MembershipRole, origen string) error {
+	info := utils.GetRequestInfo(ctx)
+	return getMembershipLogRepo().InsertOne(ctx, &models.MembershipLog{
+		Timestamp:     time.Now(),
+		Usuario:       usuario,
+		CC:            cc,
+		RolAnterior:   anterior,
+		RolNuevo:      nuevo,
+		Origen:        origen,
+		UserID:        info.UserID,
+		IP:            info.IP,
+		UserAgent:     info.UserAgent,
+		CorrelationID: info.CorrelationID,
+	})
+}
+
+// setMembership fija el rol solicitante u observador del usuario en el centro de costo y registra el
+// cambio en una transacción. Retorna el rol anterior
+func setMembership(ctx context.Context, user *models.User, cc *models.CC, rol models.MembershipRole, origen string) (models.MembershipRole, error) {
+	anterior := membershipRole(user, cc)
+	if anterior == models.MembershipJefe {
+		return anterior, ErrMembresiaJefe
+	}
+	if anterior == rol {
+		return anterior, nil
+	}
+	err := database.RunTransaction(ctx, func(tx context.Context) error {
+		if err := getUserRepo().SetMembership(tx, user.ID, cc.ID, rol == models.MembershipObservador); err != nil {
+			return err
+		}
+		return logMembership(tx, user.ID, cc.ID, anterior, rol, origen)
+	})
+	return anterior, err
+}
+
+// loadMembership busca el usuario y el centro de costo de una membresía
+func loadMembership(userID, ccID string) (*models.User, *models.CC, error) {
+	userObjID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := getUserRepo().FindOne(bson.M{"_id": userObjID})
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, ErrUsuarioNoEncontrado
+	}
+	cc, err := NewCentroCostoService().GetCCByID(ccID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cc == nil {
+		return nil, nil, ErrCentroCostoNoEncontrado
+	}
+	return user, cc, nil
+}
+
+// SetMembershipService agrega el centro de costo al usuario o cambia su rol en él. El rol jefe cambia
+// el jefe del centro de costo como PUT /cc/{id}/jefe; el jefe actual no puede pasar a otro rol
+func SetMembershipService(ctx context.Context, userID, ccID string, rol models.MembershipRole) (*models.Membership, error) {
+	if !models.IsMembershipRole(rol) {
+		return nil, ErrRolMembresiaInvalido
+	}
+	user, cc, err := loadMembership(userID, ccID)
+	if err != nil {
+		return nil, err
+	}
+
+	membership := &models.Membership{CC: cc.ID, Rol: rol}
+	if rol == models.MembershipJefe {
+		if cc.Jefe == user.ID {
+			return membership, nil
+		}
+		_, err := NewCentroCostoService().ReassignJefe(ctx, cc.ID.Hex(), user.ID.Hex())
+		return membership, err
+	}
+	if _, err := setMembership(ctx, user, cc, rol, models.MembershipOriginAPI); err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+// RemoveMembershipService quita el centro de costo al usuario y registra la baja. Al jefe no se le puede
+// quitar, primero hay que cambiar el jefe del centro de costo
+func RemoveMembershipService(ctx context.Context, userID, ccID string) error {
+	user, cc, err := loadMembership(userID, ccID)
+	if err != nil {
+		return err
+	}
+	anterior := membershipRole(user, cc)
+	switch anterior {
+	case "":
+		return ErrMembresiaNoEncontrada
+	case models.MembershipJefe:
+		return ErrMembresiaJefe
+	}
+	return database.RunTransaction(ctx, func(tx context.Context) error {
+		if err := getUserRepo().RemoveCC(tx, user.ID, cc.ID); err != nil {
+			return err
+		}
+		return logMembership(tx, user.ID, cc.ID, anterior, "", models.MembershipOriginAPI)
+	})
+}
+
+// GetUserMembershipsService lista los centros de costo del usuario con su rol en cada uno
+func GetUserMembershipsService(userID string) ([]models.Membership, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := getUserRepo().FindOne(bson.M{"_id": objID})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUsuarioNoEncontrado
+	}
+	jefeDe, err := NewCentroCostoService().GetCCIDsByJefe(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	memberships := []models.Membership{}
+	for _, id := range jefeDe {
+		memberships = append(memberships, models.Membership{CC: id, Rol: models.MembershipJefe})
+	}
+	for _, id := range user.CC {
+		if containsObjectID(jefeDe, id) {
+			continue
+		}
+		rol := models.MembershipSolicitante
+		if containsObjectID(user.CCObservador, id) {
+			rol = models.MembershipObservador
+		}
+		memberships = append(memberships, models.Membership{CC: id, Rol: rol})
+	}
+	return memberships, nil
+}
+
+// GetCCMembersService lista los usuarios del centro de costo con su rol
+func GetCCMembersService(ccID string, page, pageSize int) ([]models.CCMember, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cc, err := NewCentroCostoService().GetCCByID(ccID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if cc == nil {
+		return nil, 0, ErrCentroCostoNoEncontrado
+	}
+	users, total, err := getUserRepo().FindMembersPaginated(ctx, cc.ID, cc.Jefe, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	members := make([]models.CCMember, 0, len(users))
+	for _, user := range users {
+		members = append(members, models.CCMember{
+			UserID:   user.ID,
+			Username: user.Username,
+			Email:    user.Email,
+			Rol:      membershipRole(user, cc),
+		})
+	}
+	return members, total, nil
+}
+
+// MembershipLogQuery son los filtros de la auditoría de membresías
+type MembershipLogQuery struct {
+	Usuario primitive.ObjectID
+	CC      primitive.ObjectID
+	Desde   time.Time
+	Hasta   time.Time
+}
+
+// SearchMembershipLogsService busca los cambios de membresía, el más reciente primero
+func SearchMembershipLogsService(q MembershipLogQuery, page, pageSize int) ([]*models.MembershipLog, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if !q.Usuario.IsZero() {
+		filter["usuario"] = q.Usuario
+	}
+	if !q.CC.IsZero() {
+		filter["cc"] = q.CC
+	}
+	rango := bson.M{}
+	if !q.Desde.IsZero() {
+		rango["$gte"] = q.Desde
+	}
+	if !q.Hasta.IsZero() {
+		rango["$lt"] = q.Hasta
+	}
+	if len(rango) > 0 {
+		filter["timestamp"] = rango
+	}
+	return getMembershipLogRepo().FindPaginated(ctx, filter, page, pageSize)
+}
+
+func containsObjectID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func removeObjectID(ids []primitive.ObjectID, id primitive.ObjectID) []primitive.ObjectID {
+	result := make([]primitive.ObjectID, 0, len(ids))
+	for _, i := range ids {
+		if i != id {
+			result = append(result, i)
+		}
+	}
+	return result
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// diferencia máxima aceptada entre los importes enviados y los calculados
+const toleranciaImporte = 0.01
+
+// FieldError es un error de validación asociado a un campo de la solicitud, ej: "lines[0].cantidad"
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError agrupa los errores de validación de una solicitud
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	mensajes := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		mensajes = append(mensajes, fe.Field+": "+fe.Message)
+	}
+	return "solicitud inválida: " + strings.Join(mensajes, "; ")
+}
+
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// PriceSolicitud valida la solicitud, resuelve el producto de cada línea y recalcula los importes
+// de las líneas y el total. Las líneas que ya existían con el mismo producto conservan el precio
+// congelado originalmente. Los precios se convierten a la moneda de la solicitud y el total a la
+// moneda de reporte con los tipos de cambio vigentes a la fecha de la solicitud, que quedan
+// registrados en la solicitud. Si hay inconsistencias retorna un *ValidationError
+func PriceSolicitud(solicitud *models.Solicitud, previas []models.Line) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	verr := &ValidationError{}
+	converter := newRateConverter(ctx, solicitud.FechaSolicitud)
+
+	solicitud.Moneda = strings.ToUpper(strings.TrimSpace(solicitud.Moneda))
+	monedaValida := false
+	if solicitud.Moneda == "" {
+		verr.add("moneda", "es obligatoria")
+	} else if !models.IsSupportedCurrency(solicitud.Moneda) {
+		verr.add("moneda", "moneda no soportada: %s", solicitud.Moneda)
+	} else {
+		monedaValida = true
+	}
+	if len(solicitud.Lines) == 0 {
+		verr.add("lines", "la solicitud debe tener al menos una línea")
+	}
+
+	numeros := map[int]bool{}
+	total := 0.0
+	for i := range solicitud.Lines {
+		line := &solicitud.Lines[i]
+		campo := fmt.Sprintf("lines[%d]", i)
+
+		if line.NumeroLinea <= 0 {
+			verr.add(campo+".numero_linea", "debe ser mayor a 0")
+		} else if numeros[line.NumeroLinea] {
+			verr.add(campo+".numero_linea", "número de línea duplicado: %d", line.NumeroLinea)
+		}
+		numeros[line.NumeroLinea] = true
+
+		if line.Cantidad <= 0 {
+			verr.add(campo+".cantidad", "debe ser mayor a 0")
+		}
+
+		if err := freezeLinePrice(ctx, line, previas); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				verr.add(campo+".product_id", "el producto no existe")
+				continue
+			}
+			return err
+		}
+		if !monedaValida {
+			continue
+		}
+		precio, err := converter.Convert(line.Producto.Precio, productCurrency(line.Producto), solicitud.Moneda)
+		if err != nil {
+			if errors.Is(err, ErrTipoCambioNoDisponible) {
+				verr.add(campo+".precio_unitario", "%s", err.Error())
+				continue
+			}
+			return err
+		}
+		line.PrecioUnitario = precio
+
+		importe := roundAmount(line.PrecioUnitario * float64(line.Cantidad))
+		if line.Importe != 0 && math.Abs(line.Importe-importe) > toleranciaImporte {
+			verr.add(campo+".importe_linea", "el importe enviado (%.2f) no coincide con cantidad × precio (%.2f)", line.Importe, importe)
+		}
+		line.Importe = importe
+		total += importe
+	}
+
+	total = roundAmount(total)
+	if solicitud.ImporteTotal != 0 && math.Abs(solicitud.ImporteTotal-total) > toleranciaImporte {
+		verr.add("importe_total", "el total enviado (%.2f) no coincide con la suma de las líneas (%.2f)", solicitud.ImporteTotal, total)
+	}
+	solicitud.ImporteTotal = total
+
+	if monedaValida {
+		solicitud.MonedaReporte = ReportingCurrency()
+		importeReporte, err := converter.Convert(total, solicitud.Moneda, solicitud.MonedaReporte)
+		if err != nil {
+			if !errors.Is(err, ErrTipoCambioNoDisponible) {
+				return err
+			}
+			verr.add("moneda_reporte", "%s", err.Error())
+		}
+		solicitud.ImporteReporte = importeReporte
+		solicitud.TiposCambio = converter.Applied()
+	}
+
+	if len(verr.Errors) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// freezeLinePrice congela en la línea una copia del producto del catálogo con su precio y moneda
+func freezeLinePrice(ctx context.Context, line *models.Line, previas []models.Line) error {
+	if previa := findFrozenLine(previas, line); previa != nil {
+		line.Producto = previa.Producto
+		return nil
+	}
+	if line.ProductID.IsZero() {
+		return mongo.ErrNoDocuments
+	}
+	product, err := getProductRepo().FindByID(ctx, line.ProductID.Hex())
+	if err != nil {
+		return err
+	}
+	line.Producto = models.NewProductSnapshot(product)
+	return nil
+}
+
+// productCurrency retorna la moneda del precio congelado, las copias antiguas no la tienen y están en pesos
+func productCurrency(p *models.ProductSnapshot) string {
+	if p.Moneda == "" {
+		return models.CLP
+	}
+	return p.Moneda
+}
+
+func findFrozenLine(previas []models.Line, line *models.Line) *models.Line {
+	for i := range previas {
+		if previas[i].NumeroLinea == line.NumeroLinea && previas[i].ProductID == line.ProductID && previas[i].Producto != nil {
+			return &previas[i]
+		}
+	}
+	return nil
+}
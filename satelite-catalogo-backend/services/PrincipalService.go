@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrUsuarioNoEncontrado = errors.New("usuario no encontrado")
+
+// GetPrincipalService resuelve la identidad del usuario autenticado con sus centros de costo
+// y jefaturas actuales, se consulta en cada request para reflejar cambios sin volver a iniciar sesión
+func GetPrincipalService(userID primitive.ObjectID) (*models.Principal, error) {
+	user, err := getUserRepo().FindOne(bson.M{"_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUsuarioNoEncontrado
+	}
+
+	jefeDe, err := NewCentroCostoService().GetCCIDsByJefe(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	supervisaDe, err := NewCentroCostoService().GetDescendantIDs(jefeDe)
+	if err != nil {
+		return nil, err
+	}
+
+	delegaciones, err := getActiveDelegations(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := append([]models.Role{}, user.Role...)
+	if len(jefeDe) > 0 && !hasRole(roles, models.JEFE) {
+		roles = append(roles, models.JEFE)
+	}
+
+	return &models.Principal{
+		UserID:   user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Roles:    roles,
+		CC:       user.CC,
+		JefeDe:   jefeDe,
+		Observa:  user.CCObservador,
+
+		SupervisaDe:  supervisaDe,
+		Delegaciones: delegaciones,
+	}, nil
+}
+
+// ScopeSolicitudFilter restringe el filtro a las solicitudes visibles para el usuario
+func ScopeSolicitudFilter(principal *models.Principal, filter bson.M) bson.M {
+	scope := principal.SolicitudScope()
+	if len(scope) == 0 {
+		return filter
+	}
+	if len(filter) == 0 {
+		return scope
+	}
+	return bson.M{"$and": []bson.M{filter, scope}}
+}
+
+func hasRole(roles []models.Role, role models.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"catalogo-backend/models"
+	"catalogo-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// alias de los encabezados de las planillas de convenio marco, ya normalizados con utils.NormalizeHeader
+var productColumnAliases = map[string]string{
+	"number":           "number",
+	"numero":           "number",
+	"descripcion":      "descripcion",
+	"nombre_producto":  "descripcion",
+	"producto":         "descripcion",
+	"licitacion":       "licitacion",
+	"id_licitacion":    "licitacion",
+	"id_convenio":      "id_convenio",
+	"convenio_id":      "id_convenio",
+	"nombre_proveedor": "nombre_proveedor",
+	"proveedor":        "nombre_proveedor",
+	"razon_social":     "nombre_proveedor",
+	"rut_proveedor":    "rut_proveedor",
+	"rut":              "rut_proveedor",
+	"id_product":       "id_product",
+	"id_producto":      "id_product",
+	"idproducto":       "id_product",
+	"region":           "region",
+	"marca":            "marca",
+	"modelo":           "modelo",
+	"precio":           "precio",
+	"precio_unitario":  "precio",
+	"moneda":           "moneda",
+	"divisa":           "moneda",
+	"convenio_marco":   "convenio_marco",
+	"nombre_convenio":  "convenio_marco",
+	"convenio":         "convenio_marco",
+	"um":               "UM",
+	"unidad":           "UM",
+	"unidad_medida":    "UM",
+	"unidad_de_medida": "UM",
+	"categoria":        "categoria",
+	"id_categoria":     "id_categoria",
+	"categoria_id":     "id_categoria",
+	"nombre_categoria": "categoria",
+}
+
+// columnas obligatorias para poder identificar y mostrar un producto
+var productRequiredColumns = []string{"id_convenio", "id_product", "region", "descripcion", "precio"}
+
+// ImportProductsService importa productos desde las filas de una planilla de convenio marco,
+// hace upsert por (id_convenio, id_product, region) y retorna el detalle por fila.
+// Con dryRun no se escribe en la base de datos
+func ImportProductsService(rows [][]string, dryRun bool) (*models.ImportReport, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("el archivo está vacío")
+	}
+
+	columns := map[string]int{}
+	for i, header := range rows[0] {
+		if field, ok := productColumnAliases[utils.NormalizeHeader(header)]; ok {
+			if _, exists := columns[field]; !exists {
+				columns[field] = i
+			}
+		}
+	}
+	var missing []string
+	for _, field := range productRequiredColumns {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("faltan columnas obligatorias: %s", strings.Join(missing, ", "))
+	}
+
+	report := &models.ImportReport{DryRun: dryRun, Filas: []models.ImportRowResult{}}
+	vistos := map[string]int{}
+
+	for i, row := range rows[1:] {
+		fila := i + 2
+		if isEmptyRow(row) {
+			continue
+		}
+
+		product, errores := parseProductRow(columns, row)
+		result := models.ImportRowResult{Fila: fila, Clave: productKey(product)}
+		if previa, ok := vistos[result.Clave]; ok && len(errores) == 0 {
+			errores = append(errores, fmt.Sprintf("producto duplicado en el archivo (fila %d)", previa))
+		}
+		if len(errores) > 0 {
+			result.Accion = models.ImportRejected
+			result.Errores = errores
+			report.Add(result)
+			continue
+		}
+		vistos[result.Clave] = fila
+
+		if err := upsertImportedProduct(product, dryRun, &result); err != nil {
+			result.Accion = models.ImportRejected
+			result.Errores = []string{err.Error()}
+		}
+		report.Add(result)
+	}
+
+	return report, nil
+}
+
+func upsertImportedProduct(product models.Product, dryRun bool, result *models.ImportRowResult) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	existing, err := getProductRepo().FindByConvenioKey(ctx, product.IDConvenio, product.IDProduct, product.Region)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		result.Accion = models.ImportCreated
+		if dryRun {
+			return nil
+		}
+		product.ID = primitive.NewObjectID()
+		result.ID = product.ID.Hex()
+		return saveNewProduct(ctx, &product, models.PriceOriginImport)
+	}
+
+	result.Accion = models.ImportUpdated
+	result.ID = existing.ID.Hex()
+	if dryRun {
+		return nil
+	}
+	return saveProductUpdate(ctx, existing, product, models.PriceOriginImport)
+}
+
+func parseProductRow(columns map[string]int, row []string) (models.Product, []string) {
+	get := func(field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	product := models.Product{
+		Number:          get("number"),
+		Descripcion:     get("descripcion"),
+		Licitacion:      get("licitacion"),
+		IDConvenio:      get("id_convenio"),
+		NombreProveedor: get("nombre_proveedor"),
+		RutProveedor:    get("rut_proveedor"),
+		IDProduct:       get("id_product"),
+		Region:          get("region"),
+		Marca:           get("marca"),
+		Modelo:          get("modelo"),
+		ConvenioMarco:   get("convenio_marco"),
+		UM:              get("UM"),
+		Categoria:       get("categoria"),
+		IDCategoria:     get("id_categoria"),
+		Moneda:          get("moneda"),
+	}
+
+	var errores []string
+	for _, field := range productRequiredColumns {
+		if get(field) == "" {
+			errores = append(errores, fmt.Sprintf("%s es obligatorio", field))
+		}
+	}
+	if raw := get("precio"); raw != "" {
+		precio, err := ParsePrecio(raw)
+		if err != nil {
+			errores = append(errores, fmt.Sprintf("precio inválido: %s", raw))
+		} else if precio <= 0 {
+			errores = append(errores, "precio debe ser mayor a 0")
+		}
+		product.Precio = precio
+	}
+	// sin columna moneda el precio se asume en pesos
+	if err := normalizeProductCurrency(&product, true); err != nil {
+		errores = append(errores, err.Error())
+	}
+	if product.RutProveedor != "" {
+		if !utils.ValidateRut(product.RutProveedor) {
+			errores = append(errores, fmt.Sprintf("rut_proveedor inválido: %s", product.RutProveedor))
+		} else {
+			product.RutProveedor = utils.NormalizeRut(product.RutProveedor)
+		}
+	}
+
+	return product, errores
+}
+
+// ParsePrecio interpreta precios en formato chileno ("$ 1.234.567", "1.234,5") o decimal ("1234.5")
+func ParsePrecio(raw string) (float64, error) {
+	s := strings.NewReplacer("$", "", " ", "", " ", "").Replace(raw)
+	switch {
+	case strings.Contains(s, ",") && strings.Contains(s, "."):
+		// 1.234,56: punto como separador de miles y coma decimal
+		s = strings.ReplaceAll(s, ".", "")
+		s = strings.Replace(s, ",", ".", 1)
+	case strings.Contains(s, ","):
+		s = strings.Replace(s, ",", ".", 1)
+	case strings.Count(s, ".") > 1 || (strings.Contains(s, ".") && len(s)-strings.LastIndex(s, ".") == 4):
+		// 1.234 o 1.234.567: punto como separador de miles
+		s = strings.ReplaceAll(s, ".", "")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func productKey(p models.Product) string {
+	return p.IDConvenio + "/" + p.IDProduct + "/" + p.Region
+}
+
+func isEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -3,16 +3,23 @@ package services
 import (
 	"catalogo-backend/models"
 	"catalogo-backend/repositories"
+	"catalogo-backend/utils"
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var (
-	productRepo *repositories.ProductRepository
-	onceProduct sync.Once
+	productRepo      *repositories.ProductRepository
+	onceProduct      sync.Once
+	priceHistoryRepo *repositories.PriceHistoryRepository
+	oncePriceHistory sync.Once
 )
 
 func getProductRepo() *repositories.ProductRepository {
@@ -22,12 +29,78 @@ func getProductRepo() *repositories.ProductRepository {
 	return productRepo
 }
 
+func getPriceHistoryRepo() *repositories.PriceHistoryRepository {
+	oncePriceHistory.Do(func() {
+		priceHistoryRepo = repositories.NewPriceHistoryRepository()
+	})
+	return priceHistoryRepo
+}
+
 // Create - Crear un nuevo producto
 func CreateProduct(product models.Product) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	return getProductRepo().Create(ctx, product)
+	if err := normalizeProductCurrency(&product, true); err != nil {
+		return err
+	}
+	return saveNewProduct(ctx, &product, models.PriceOriginCreate)
+}
+
+// normalizeProductCurrency valida la moneda del precio, al crear un producto sin moneda se asume CLP
+func normalizeProductCurrency(product *models.Product, isNew bool) error {
+	product.Moneda = strings.ToUpper(strings.TrimSpace(product.Moneda))
+	if product.Moneda == "" {
+		if isNew {
+			product.Moneda = models.CLP
+		}
+		return nil
+	}
+	if !models.IsSupportedCurrency(product.Moneda) {
+		return fmt.Errorf("%w: %s", ErrMonedaNoSoportada, product.Moneda)
+	}
+	return nil
+}
+
+// saveNewProduct inserta el producto y registra su precio inicial en el historial
+func saveNewProduct(ctx context.Context, product *models.Product, origen string) error {
+	if product.ID.IsZero() {
+		product.ID = primitive.NewObjectID()
+	}
+	if err := getProductRepo().Create(ctx, *product); err != nil {
+		return err
+	}
+	return recordPriceChange(ctx, product.ID, 0, product.Precio, product.PriceCurrency(), origen)
+}
+
+// saveProductUpdate actualiza el producto y registra el cambio de precio si lo hubo
+func saveProductUpdate(ctx context.Context, existing *models.Product, product models.Product, origen string) error {
+	if err := getProductRepo().Update(ctx, existing.ID.Hex(), product); err != nil {
+		return err
+	}
+	// el $set omite precio y moneda vacíos, en ese caso no cambian
+	precio, moneda := existing.Precio, existing.PriceCurrency()
+	if product.Precio != 0 {
+		precio = product.Precio
+	}
+	if product.Moneda != "" {
+		moneda = product.Moneda
+	}
+	if precio == existing.Precio && moneda == existing.PriceCurrency() {
+		return nil
+	}
+	return recordPriceChange(ctx, existing.ID, existing.Precio, precio, moneda, origen)
+}
+
+func recordPriceChange(ctx context.Context, productID primitive.ObjectID, anterior, nuevo float64, moneda, origen string) error {
+	return getPriceHistoryRepo().InsertOne(ctx, &models.PriceHistory{
+		ProductID:      productID,
+		PrecioAnterior: anterior,
+		PrecioNuevo:    nuevo,
+		Moneda:         moneda,
+		Fecha:          time.Now(),
+		Origen:         origen,
+	})
 }
 
 // GetAll - Obtener todos los productos
@@ -51,15 +124,66 @@ func UpdateProduct(id string, product models.Product) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	return getProductRepo().Update(ctx, id, product)
+	if err := normalizeProductCurrency(&product, false); err != nil {
+		return err
+	}
+	existing, err := getProductRepo().FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return saveProductUpdate(ctx, existing, product, models.PriceOriginUpdate)
+}
+
+// GetProductPriceHistory - Obtener el historial de precios de un producto
+func GetProductPriceHistory(id string, page, pageSize int) ([]*models.PriceHistory, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return getPriceHistoryRepo().FindByProductPaginated(ctx, objID, page, pageSize)
+}
+
+var ErrProductoNoEncontrado = errors.New("producto no encontrado")
+
+// Delete - Eliminar lógicamente un producto, se puede restaurar hasta la purga
+func DeleteProduct(ctx context.Context, id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	ok, err := getProductRepo().SoftDelete(ctx, objID, utils.GetRequestInfo(ctx).UserID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrProductoNoEncontrado
+	}
+	return nil
 }
 
-// Delete - Eliminar un producto
-func DeleteProduct(id string) error {
+// RestoreProduct restaura un producto eliminado
+func RestoreProduct(id string) error {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	return getProductRepo().Delete(ctx, id)
+	ok, err := getProductRepo().Restore(ctx, objID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNoEliminado
+	}
+	return nil
 }
 
 // Search - Buscar productos
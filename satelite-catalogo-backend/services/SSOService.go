@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"catalogo-backend/auth"
+	"catalogo-backend/models"
+	"catalogo-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrUsuarioSSONoRegistrado es el usuario autenticado por el IdP que no está registrado, con
+// SSO_AUTO_PROVISION desactivado
+var ErrUsuarioSSONoRegistrado = errors.New("el usuario no está registrado en el catálogo")
+
+// ResolveSSOUserService busca el usuario autenticado por el IdP de inicio de sesión único: por email
+// con GetUserByEmailService y, si no está, por rut. Si no existe y auth.AutoProvision está activo lo
+// crea con el rol Usuario
+func ResolveSSOUserService(identity *auth.Identity) (models.User, error) {
+	if identity.Email != "" {
+		user, err := GetUserByEmailService(identity.Email)
+		if err == nil {
+			return user, nil
+		}
+		// solo se busca por rut o se crea el usuario si no existe, no ante un error de la base de datos
+		if !errors.Is(err, ErrUsuarioNoEncontrado) {
+			return models.User{}, err
+		}
+	}
+
+	var rut string
+	if identity.Rut != "" {
+		rut = utils.NormalizeRut(identity.Rut)
+		// los ruts de los usuarios se guardan como se ingresaron, se busca también el valor del IdP
+		user, err := getUserRepo().FindOne(bson.M{"rut": bson.M{"$in": []string{rut, identity.Rut}}})
+		if err != nil {
+			return models.User{}, err
+		}
+		if user != nil {
+			return *user, nil
+		}
+	}
+
+	if !auth.AutoProvision() {
+		return models.User{}, ErrUsuarioSSONoRegistrado
+	}
+	if identity.Email == "" {
+		return models.User{}, fmt.Errorf("%w: el IdP no entregó el email para crearlo", ErrUsuarioSSONoRegistrado)
+	}
+	username := identity.Username
+	if username == "" {
+		username, _, _ = strings.Cut(identity.Email, "@")
+	}
+	created, err := CreateUserService(&models.User{
+		Username:  username,
+		Email:     identity.Email,
+		Rut:       rut,
+		Role:      []models.Role{models.USER},
+		CC:        []primitive.ObjectID{},
+		CreatedAt: primitive.NewDateTimeFromTime(time.Now()),
+	})
+	if err != nil {
+		return models.User{}, err
+	}
+	return *created, nil
+}
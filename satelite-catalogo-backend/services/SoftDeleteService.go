@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"catalogo-backend/models"
+	"catalogo-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrNoEliminado           = errors.New("no se encontró un registro eliminado con ese ID")
+	ErrTipoEliminadoInvalido = errors.New("tipo inválido, debe ser solicitud, product o cc")
+)
+
+// días que se conservan los registros eliminados antes de que la purga los borre definitivamente
+const defaultSoftDeleteRetentionDays = 90
+
+// SoftDeleteRetentionDays retorna los días de retención de los registros eliminados
+// (SOFT_DELETE_RETENTION_DAYS, por defecto 90)
+func SoftDeleteRetentionDays() int {
+	if dias, err := strconv.Atoi(os.Getenv("SOFT_DELETE_RETENTION_DAYS")); err == nil && dias > 0 {
+		return dias
+	}
+	return defaultSoftDeleteRetentionDays
+}
+
+// ListDeletedService lista los registros eliminados del tipo indicado, el más reciente primero
+func ListDeletedService(kind models.SoftDeleteKind, page, pageSize int) (interface{}, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	switch kind {
+	case models.SoftDeleteSolicitud:
+		return getSolicitudRepo().FindDeletedPaginated(ctx, page, pageSize)
+	case models.SoftDeleteProduct:
+		return getProductRepo().FindDeletedPaginated(ctx, page, pageSize)
+	case models.SoftDeleteCentroCosto:
+		return NewCentroCostoService().repo.FindDeletedPaginated(ctx, page, pageSize)
+	}
+	return nil, 0, ErrTipoEliminadoInvalido
+}
+
+// PurgeReport resume una purga de registros eliminados
+type PurgeReport struct {
+	Antes        time.Time            `json:"antes"` // se purgan los eliminados antes de esta fecha
+	DryRun       bool                 `json:"dry_run"`
+	Solicitudes  []primitive.ObjectID `json:"solicitudes"`
+	Productos    []primitive.ObjectID `json:"productos"`
+	CentrosCosto []primitive.ObjectID `json:"centros_costo"`
+	// centros de costo eliminados que no se purgan porque todavía los referencian solicitudes
+	CentrosConservados []primitive.ObjectID `json:"centros_costo_conservados"`
+	Errores            []string             `json:"errores"`
+}
+
+// PurgeDeletedService borra definitivamente los registros eliminados hace más de retentionDays días:
+// las solicitudes con su carpeta de archivos, los productos con su historial de precios y los centros
+// de costo que ya no referencia ninguna solicitud. Los logs de las solicitudes se conservan. Con dryRun
+// solo informa lo que se borraría
+func PurgeDeletedService(retentionDays int, dryRun bool) (*PurgeReport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	report := &PurgeReport{
+		Antes:              time.Now().AddDate(0, 0, -retentionDays),
+		DryRun:             dryRun,
+		Solicitudes:        []primitive.ObjectID{},
+		Productos:          []primitive.ObjectID{},
+		CentrosCosto:       []primitive.ObjectID{},
+		CentrosConservados: []primitive.ObjectID{},
+		Errores:            []string{},
+	}
+	fallo := func(format string, args ...interface{}) {
+		report.Errores = append(report.Errores, fmt.Sprintf(format, args...))
+	}
+
+	solicitudes, err := getSolicitudRepo().FindDeletedBefore(ctx, report.Antes)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range solicitudes {
+		if !dryRun {
+			// primero el documento: si falla el borrado de archivos quedan archivos huérfanos y no
+			// una solicitud que apunta a archivos inexistentes
+			if err := getSolicitudRepo().DeleteOneByID(ctx, s.ID); err != nil {
+				fallo("solicitud %s: %v", s.ID.Hex(), err)
+				continue
+			}
+			if err := os.RemoveAll(filepath.Join(utils.UploadRoot(), s.ID.Hex())); err != nil {
+				fallo("archivos de la solicitud %s: %v", s.ID.Hex(), err)
+			}
+		}
+		report.Solicitudes = append(report.Solicitudes, s.ID)
+	}
+
+	productos, err := getProductRepo().DeleteDeletedBefore(ctx, report.Antes, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	report.Productos = append(report.Productos, productos...)
+	if !dryRun && len(productos) > 0 {
+		if err := getPriceHistoryRepo().DeleteByProducts(ctx, productos); err != nil {
+			fallo("historial de precios: %v", err)
+		}
+	}
+
+	ccRepo := NewCentroCostoService().repo
+	centros, err := ccRepo.FindDeletedBeforeIDs(ctx, report.Antes)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range centros {
+		// en dry-run la cuenta incluye las solicitudes que se purgarían, así que puede conservar de más
+		referencias, err := getSolicitudRepo().CountByCC(ctx, id)
+		if err != nil {
+			fallo("centro de costo %s: %v", id.Hex(), err)
+			continue
+		}
+		if referencias > 0 {
+			report.CentrosConservados = append(report.CentrosConservados, id)
+			continue
+		}
+		if !dryRun {
+			if err := ccRepo.DeleteOneByID(ctx, id); err != nil {
+				fallo("centro de costo %s: %v", id.Hex(), err)
+				continue
+			}
+			if err := getUserRepo().PullCC(ctx, id); err != nil {
+				fallo("usuarios del centro de costo %s: %v", id.Hex(), err)
+			}
+		}
+		report.CentrosCosto = append(report.CentrosCosto, id)
+	}
+	return report, nil
+}
@@ -1,11 +1,18 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
 
+	"catalogo-backend/database"
 	"catalogo-backend/models"
 	"catalogo-backend/repositories"
+	"catalogo-backend/transitions"
 	"catalogo-backend/utils"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -17,6 +24,17 @@ var (
 	onceSolicitud sync.Once
 )
 
+// runTransaction ejecuta las transacciones de las solicitudes con archivos, las pruebas lo reemplazan
+// para simular fallas de la base de datos
+var runTransaction = database.RunTransaction
+
+var (
+	ErrSolicitudNoEncontrada = errors.New("solicitud no encontrada")
+	ErrSinAcceso             = errors.New("no tiene acceso a esta solicitud")
+	ErrSinPermisoModificar   = errors.New("solo el solicitante, el jefe del centro de costo o un administrador pueden modificar la solicitud")
+	ErrCamposBloqueados      = errors.New("las líneas, la moneda, la fecha y el centro de costo no pueden modificarse en el estado actual de la solicitud")
+)
+
 func getSolicitudRepo() *repositories.SolicitudRepository {
 	onceSolicitud.Do(func() {
 		solicitudRepo = repositories.NewSolicitudRepository()
@@ -24,15 +42,49 @@ func getSolicitudRepo() *repositories.SolicitudRepository {
 	return solicitudRepo
 }
 
-func CreateSolicitudService(newSolicitud *models.Solicitud) (*models.Solicitud, error) {
+// ErrArchivosNoPromovidos indica que la solicitud se guardó pero sus archivos quedaron en staging
+var ErrArchivosNoPromovidos = errors.New("la solicitud se guardó pero no se pudieron mover sus archivos")
+
+// CreateSolicitudService guarda la solicitud y su log de creación en una transacción. Los archivos en
+// staging se mueven a su carpeta definitiva solo si la transacción se confirma; si falla se descartan.
+// Retorna el ID del log creado
+func CreateSolicitudService(ctx context.Context, newSolicitud *models.Solicitud, archivos *utils.ArchivosPendientes) (string, error) {
 	utils.Debug(fmt.Sprintf("Creando solicitud con ID %s", newSolicitud.ID.Hex()))
 
-	err := getSolicitudRepo().InsertOne(newSolicitud)
-	if err != nil {
-		return nil, err
+	if err := PriceSolicitud(newSolicitud, nil); err != nil {
+		archivos.Descartar()
+		return "", err
+	}
+	var logID string
+	err := commitWithFiles(ctx, archivos, func(tx context.Context) error {
+		if err := getSolicitudRepo().InsertOne(tx, newSolicitud); err != nil {
+			return err
+		}
+		id, err := CreateLogFromSolicitud(tx, newSolicitud)
+		if err != nil {
+			return fmt.Errorf("error al crear log de solicitud: %w", err)
+		}
+		logID = id
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrArchivosNoPromovidos) {
+		return "", err
 	}
+	return logID, err
+}
 
-	return newSolicitud, nil
+// commitWithFiles ejecuta fn en una transacción y mueve los archivos en staging a su carpeta definitiva
+// solo si se confirma. Si fn o la confirmación fallan los archivos se descartan; si fallan al moverse la
+// transacción ya está confirmada, retorna ErrArchivosNoPromovidos y los archivos quedan en staging
+func commitWithFiles(ctx context.Context, archivos *utils.ArchivosPendientes, fn func(tx context.Context) error) error {
+	if err := runTransaction(ctx, fn); err != nil {
+		archivos.Descartar()
+		return err
+	}
+	if err := archivos.Promover(); err != nil {
+		return fmt.Errorf("%w: %v", ErrArchivosNoPromovidos, err)
+	}
+	return nil
 }
 
 func GetSolicitudByIDService(id string) (*models.Solicitud, error) {
@@ -51,24 +103,189 @@ func GetAllSolicitudesService() ([]*models.Solicitud, error) {
 	return getSolicitudRepo().FindAll()
 }
 
-func UpdateSolicitudService(id string, update bson.M) error {
+// UpdateSolicitudService aplica los cambios sobre la solicitud previa y registra el log de actualización
+// en una transacción. Retorna la solicitud actualizada
+func UpdateSolicitudService(ctx context.Context, previa *models.Solicitud, update bson.M) (*models.Solicitud, error) {
 	utils.Debug("Actualizar solicitud")
 
-	objID, err := primitive.ObjectIDFromHex(id)
+	// una clave anidada o un operador pasaría por el $set sin validarse ni recalcularse
+	for key := range update {
+		if strings.Contains(key, ".") || strings.HasPrefix(key, "$") {
+			return nil, &ValidationError{Errors: []FieldError{{Field: key, Message: "no se puede actualizar un campo anidado"}}}
+		}
+	}
+	// una vez enviada, el compromiso de presupuesto y la cadena de aprobación dependen de los importes
+	// y del centro de costo, que ya no se pueden cambiar
+	if !transitions.Editable(transitions.State(previa.State)) {
+		for key := range update {
+			if esCampoPrecio(key) || key == "cc" {
+				return nil, ErrCamposBloqueados
+			}
+		}
+	}
+	// si cambian líneas, moneda, fecha o importes se recalculan sobre la solicitud resultante
+	if touchesPricing(update) {
+		merged, err := mergeSolicitudUpdate(previa, update)
+		if err != nil {
+			return nil, err
+		}
+		// las líneas recibidas quedan pendientes, las decisiones solo se registran mediante
+		// /solicitud/:id/lines y las líneas solo se editan antes de enviarse a aprobación
+		if _, ok := update["lines"]; ok {
+			for i := range merged.Lines {
+				merged.Lines[i].ResetDecision()
+			}
+		}
+		// los campos de precio recibidos se reemplazan por los calculados
+		for key := range update {
+			if esCampoPrecio(key) {
+				delete(update, key)
+			}
+		}
+		if err := PriceSolicitud(merged, previa.Lines); err != nil {
+			return nil, err
+		}
+		update["lines"] = merged.Lines
+		update["moneda"] = merged.Moneda
+		update["fecha_solicitud"] = merged.FechaSolicitud
+		update["importe_total"] = merged.ImporteTotal
+		update["moneda_reporte"] = merged.MonedaReporte
+		update["importe_reporte"] = merged.ImporteReporte
+		update["tipos_cambio"] = merged.TiposCambio
+	}
+
+	var posterior *models.Solicitud
+	err := database.RunTransaction(ctx, func(tx context.Context) error {
+		// el filtro exige el estado leído, con el que se decidió qué campos se pueden modificar
+		matched, err := getSolicitudRepo().UpdateOneIfMatch(tx, unchangedStateFilter(previa), update)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			return ErrEstadoModificado
+		}
+		if posterior, err = getSolicitudRepo().FindByID(tx, previa.ID); err != nil {
+			return err
+		}
+		if posterior == nil {
+			return ErrSolicitudNoEncontrada
+		}
+		if _, err := CreateLogFromUpdate(tx, posterior, previa); err != nil {
+			return fmt.Errorf("error al crear log de actualización: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("formato de ID inválido: %s", id)
+		return nil, err
+	}
+	return posterior, nil
+}
+
+func touchesPricing(update bson.M) bool {
+	for key := range update {
+		if esCampoPrecio(key) {
+			return true
+		}
 	}
-	return getSolicitudRepo().UpdateOne(bson.M{"_id": objID}, bson.M{"$set": update})
+	return false
 }
 
-func DeleteSolicitudService(id string) error {
+// esCampoPrecio indica si la clave, o el campo de primer nivel de una clave anidada, afecta los
+// importes de la solicitud
+func esCampoPrecio(key string) bool {
+	campo, _, _ := strings.Cut(key, ".")
+	return campo == "lines" || campo == "fecha_solicitud" || campo == "tipos_cambio" ||
+		strings.HasPrefix(campo, "importe_") || strings.HasPrefix(campo, "moneda")
+}
+
+// mergeSolicitudUpdate aplica los campos recibidos como JSON genérico sobre una copia de la solicitud
+func mergeSolicitudUpdate(previa *models.Solicitud, update bson.M) (*models.Solicitud, error) {
+	data, err := json.Marshal(previa)
+	if err != nil {
+		return nil, err
+	}
+	var campos map[string]interface{}
+	if err := json.Unmarshal(data, &campos); err != nil {
+		return nil, err
+	}
+	for k, v := range update {
+		campos[k] = v
+	}
+	if data, err = json.Marshal(campos); err != nil {
+		return nil, err
+	}
+	var merged models.Solicitud
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return nil, &ValidationError{Errors: []FieldError{{Field: "solicitud", Message: err.Error()}}}
+	}
+	return &merged, nil
+}
+
+// DeleteSolicitudService elimina lógicamente la solicitud y registra el log de eliminación en una
+// transacción. La solicitud se puede restaurar hasta que la purga la borre definitivamente
+func DeleteSolicitudService(ctx context.Context, id string) error {
 	utils.Debug("Eliminar solicitud")
 
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return fmt.Errorf("formato de ID inválido: %s", id)
 	}
-	return getSolicitudRepo().DeleteOneByID(objID)
+	return softDeleteSolicitud(ctx, objID, models.LogEventDelete)
+}
+
+// RestoreSolicitudService restaura una solicitud eliminada y registra el log de restauración
+func RestoreSolicitudService(ctx context.Context, id string) error {
+	utils.Debug("Restaurar solicitud")
+
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("formato de ID inválido: %s: %w", id, err)
+	}
+	return softDeleteSolicitud(ctx, objID, models.LogEventRestore)
+}
+
+// softDeleteSolicitud marca o desmarca la solicitud como eliminada según el evento, junto a su log
+func softDeleteSolicitud(ctx context.Context, id primitive.ObjectID, evento models.LogEventType) error {
+	return database.RunTransaction(ctx, func(tx context.Context) error {
+		previa, err := getSolicitudRepo().FindByID(tx, id)
+		if err != nil {
+			return err
+		}
+		// lo comprometido por una solicitud aprobada se libera al eliminarla y se reserva al restaurarla
+		var ok bool
+		switch {
+		case previa == nil:
+			return ErrSolicitudNoEncontrada
+		case evento == models.LogEventDelete && !previa.IsDeleted():
+			if err := releaseBudget(tx, previa); err != nil {
+				return err
+			}
+			ok, err = getSolicitudRepo().SoftDelete(tx, id, utils.GetRequestInfo(tx).UserID, time.Now())
+		case evento == models.LogEventRestore && previa.IsDeleted():
+			if err := recommitBudget(tx, previa); err != nil {
+				return err
+			}
+			ok, err = getSolicitudRepo().Restore(tx, id)
+		}
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if evento == models.LogEventRestore {
+				return ErrNoEliminado
+			}
+			return ErrSolicitudNoEncontrada
+		}
+
+		posterior, err := getSolicitudRepo().FindByID(tx, id)
+		if err != nil {
+			return err
+		}
+		if _, err := CreateLogFromSoftDelete(tx, posterior, previa, evento); err != nil {
+			return fmt.Errorf("error al crear log de %s: %w", evento, err)
+		}
+		return nil
+	})
 }
 
 // esta se usa en el index
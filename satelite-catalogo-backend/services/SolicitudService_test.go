@@ -0,0 +1,218 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"catalogo-backend/models"
+	"catalogo-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const carpetaPrueba = "000000000000000000000001"
+
+// stageArchivo sube a staging un archivo de la línea 1 en un UPLOAD_DIR temporal
+func stageArchivo(t *testing.T) *utils.ArchivosPendientes {
+	t.Helper()
+	t.Setenv("UPLOAD_DIR", t.TempDir())
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("archivos", "linea_1_cotizacion.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte("cotización"))
+	writer.Close()
+	form, err := multipart.NewReader(&body, writer.Boundary()).ReadForm(1 << 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { form.RemoveAll() })
+
+	archivos, err := utils.StageArchivos(form.File["archivos"], carpetaPrueba)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return archivos
+}
+
+// fakeTransaction reemplaza runTransaction, commit es el error con que termina la confirmación
+func fakeTransaction(t *testing.T, commit error) {
+	t.Helper()
+	anterior := runTransaction
+	runTransaction = func(ctx context.Context, fn func(ctx context.Context) error) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return commit
+	}
+	t.Cleanup(func() { runTransaction = anterior })
+}
+
+func archivoDefinitivo() string {
+	return filepath.Join(utils.UploadRoot(), carpetaPrueba, "linea_1", "cotizacion.pdf")
+}
+
+func archivosEnStaging(t *testing.T) int {
+	t.Helper()
+	n := 0
+	filepath.WalkDir(filepath.Join(utils.UploadRoot(), utils.StagingDirName), func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return err
+	})
+	return n
+}
+
+func TestCommitWithFilesPromueveAlConfirmar(t *testing.T) {
+	archivos := stageArchivo(t)
+	fakeTransaction(t, nil)
+
+	if err := commitWithFiles(context.Background(), archivos, func(ctx context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(archivoDefinitivo()); err != nil {
+		t.Errorf("el archivo no se movió a su carpeta definitiva: %v", err)
+	}
+	if n := archivosEnStaging(t); n != 0 {
+		t.Errorf("quedaron %d archivos en staging", n)
+	}
+}
+
+func TestCommitWithFilesDescartaSiFallaElLog(t *testing.T) {
+	archivos := stageArchivo(t)
+	fakeTransaction(t, nil)
+	errLog := errors.New("logs no disponible")
+
+	insertada := false
+	err := commitWithFiles(context.Background(), archivos, func(ctx context.Context) error {
+		insertada = true
+		return errLog
+	})
+	if !errors.Is(err, errLog) {
+		t.Fatalf("error %v, se esperaba el del log", err)
+	}
+	if !insertada {
+		t.Fatal("no se ejecutó la transacción")
+	}
+	if _, err := os.Stat(archivoDefinitivo()); !os.IsNotExist(err) {
+		t.Errorf("el archivo se movió a su carpeta definitiva sin confirmarse la transacción")
+	}
+	if n := archivosEnStaging(t); n != 0 {
+		t.Errorf("quedaron %d archivos en staging", n)
+	}
+}
+
+// la confirmación puede fallar después de que fn terminó bien, los archivos no deben promoverse
+func TestCommitWithFilesDescartaSiFallaLaConfirmacion(t *testing.T) {
+	archivos := stageArchivo(t)
+	errCommit := errors.New("commit abortado")
+	fakeTransaction(t, errCommit)
+
+	err := commitWithFiles(context.Background(), archivos, func(ctx context.Context) error { return nil })
+	if !errors.Is(err, errCommit) {
+		t.Fatalf("error %v, se esperaba el de la confirmación", err)
+	}
+	if _, err := os.Stat(archivoDefinitivo()); !os.IsNotExist(err) {
+		t.Errorf("el archivo se movió a su carpeta definitiva sin confirmarse la transacción")
+	}
+	if n := archivosEnStaging(t); n != 0 {
+		t.Errorf("quedaron %d archivos en staging", n)
+	}
+}
+
+// si los archivos no se pueden mover la solicitud ya está guardada, los archivos quedan en staging
+func TestCommitWithFilesPromocionFallida(t *testing.T) {
+	archivos := stageArchivo(t)
+	fakeTransaction(t, nil)
+	// un archivo en lugar de la carpeta de la solicitud impide moverlos
+	if err := os.WriteFile(filepath.Join(utils.UploadRoot(), carpetaPrueba), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := commitWithFiles(context.Background(), archivos, func(ctx context.Context) error { return nil })
+	if !errors.Is(err, ErrArchivosNoPromovidos) {
+		t.Fatalf("error %v, se esperaba ErrArchivosNoPromovidos", err)
+	}
+	if n := archivosEnStaging(t); n != 1 {
+		t.Errorf("quedaron %d archivos en staging, se esperaba 1", n)
+	}
+}
+
+// la transacción puede reintentarse, fn no debe mover los archivos
+func TestCommitWithFilesReintentoPromueveUnaVez(t *testing.T) {
+	archivos := stageArchivo(t)
+	anterior := runTransaction
+	runTransaction = func(ctx context.Context, fn func(ctx context.Context) error) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		return fn(ctx)
+	}
+	t.Cleanup(func() { runTransaction = anterior })
+
+	intentos := 0
+	err := commitWithFiles(context.Background(), archivos, func(ctx context.Context) error {
+		intentos++
+		if _, err := os.Stat(archivoDefinitivo()); !os.IsNotExist(err) {
+			t.Errorf("intento %d: el archivo se movió antes de confirmar", intentos)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intentos != 2 {
+		t.Fatalf("%d intentos, se esperaban 2", intentos)
+	}
+	if _, err := os.Stat(archivoDefinitivo()); err != nil {
+		t.Errorf("el archivo no se movió a su carpeta definitiva: %v", err)
+	}
+}
+
+func TestTouchesPricingIncluyeCamposDePrecio(t *testing.T) {
+	for _, key := range []string{"lines", "lines.0.cantidad", "moneda", "moneda_reporte", "importe_total", "importe_otro", "tipos_cambio", "tipos_cambio.0.valor", "fecha_solicitud"} {
+		if !touchesPricing(bson.M{key: 1}) {
+			t.Errorf("%s debería recalcular los importes", key)
+		}
+	}
+	for _, key := range []string{"description", "nombre_solicitud", "documents", "cc"} {
+		if touchesPricing(bson.M{key: 1}) {
+			t.Errorf("%s no debería recalcular los importes", key)
+		}
+	}
+}
+
+// las claves anidadas se rechazan antes de llegar a la base de datos
+func TestUpdateSolicitudRechazaClavesAnidadas(t *testing.T) {
+	previa := &models.Solicitud{ID: primitive.NewObjectID(), State: "I"}
+	for _, key := range []string{"lines.0.estado", "importe_total.x", "presupuesto.estado", "$set"} {
+		_, err := UpdateSolicitudService(context.Background(), previa, bson.M{key: "A"})
+		var ve *ValidationError
+		if !errors.As(err, &ve) {
+			t.Errorf("%s: error %v, se esperaba un ValidationError", key, err)
+		}
+	}
+}
+
+// después de enviarla, los campos que determinan el presupuesto comprometido no se pueden cambiar
+func TestUpdateSolicitudBloqueaPreciosYCentroCostoTrasEnvio(t *testing.T) {
+	for _, state := range []string{"P", "LA", "A", "C", "D"} {
+		previa := &models.Solicitud{ID: primitive.NewObjectID(), State: state}
+		for _, key := range []string{"lines", "cc", "moneda", "fecha_solicitud", "importe_total", "tipos_cambio"} {
+			_, err := UpdateSolicitudService(context.Background(), previa, bson.M{key: "x"})
+			if !errors.Is(err, ErrCamposBloqueados) {
+				t.Errorf("%s en estado %s: error %v, se esperaba ErrCamposBloqueados", key, state, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"catalogo-backend/database"
+	"catalogo-backend/models"
+	"catalogo-backend/transitions"
+	"catalogo-backend/utils"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrEstadoModificado = errors.New("la solicitud cambió de estado durante la operación, intente nuevamente")
+
+// buildActor arma el actor de la transición a partir del principal y el centro de costo de la solicitud,
+// con las delegaciones vigentes que cubren ese centro de costo
+func buildActor(principal *models.Principal, solicitud *models.Solicitud) transitions.Actor {
+	actor := transitions.Actor{
+		UserID: principal.UserID,
+		Roles:  principal.Roles,
+		EsJefe: !solicitud.CC.IsZero() && principal.LeadsCC(solicitud.CC),
+		// los jefes de centros de costo superiores solo aprueban si la configuración lo permite
+		EsJefeSuperior: ParentJefeApproves() && !solicitud.CC.IsZero() && principal.SupervisesCC(solicitud.CC),
+	}
+	for _, d := range principal.Delegaciones {
+		if !d.Covers(solicitud.CC) {
+			continue
+		}
+		actor.Delegaciones = append(actor.Delegaciones, transitions.Delegacion{
+			ID:        d.ID,
+			Delegante: d.Delegante,
+			EsJefe:    !solicitud.CC.IsZero() && d.LeadsCC(solicitud.CC),
+		})
+	}
+	return actor
+}
+
+func getSolicitudForTransition(id string, principal *models.Principal) (*models.Solicitud, error) {
+	solicitud, err := GetSolicitudByIDService(id)
+	if err != nil {
+		return nil, err
+	}
+	if solicitud == nil {
+		return nil, ErrSolicitudNoEncontrada
+	}
+	if !principal.CanAccessSolicitud(solicitud) {
+		return nil, ErrSinAcceso
+	}
+	return solicitud, nil
+}
+
+// GetAvailableTransitionsService retorna las transiciones que el usuario puede ejecutar sobre la solicitud
+func GetAvailableTransitionsService(id string, principal *models.Principal) ([]transitions.Name, error) {
+	solicitud, err := getSolicitudForTransition(id, principal)
+	if err != nil {
+		return nil, err
+	}
+	actor := buildActor(principal, solicitud)
+	return transitions.Available(&transitions.Request{Solicitud: solicitud, Actor: actor}), nil
+}
+
+// ApplyTransitionService valida y ejecuta una transición de estado, registrando el log correspondiente
+func ApplyTransitionService(ctx context.Context, id string, name transitions.Name, principal *models.Principal, comentario string) (*models.Solicitud, error) {
+	utils.Debug(fmt.Sprintf("Transición %s sobre solicitud %s", name, id))
+
+	transition, err := transitions.Find(name)
+	if err != nil {
+		return nil, err
+	}
+	solicitudPrevia, err := getSolicitudForTransition(id, principal)
+	if err != nil {
+		return nil, err
+	}
+	actor := buildActor(principal, solicitudPrevia)
+
+	req := &transitions.Request{Solicitud: solicitudPrevia, Actor: actor, Comentario: comentario}
+	if err := transition.Check(req); err != nil {
+		return nil, err
+	}
+	// al aprobar o rechazar con autoridad delegada se registran ambas identidades
+	var delegacion *transitions.Delegacion
+	if name == transitions.Approve || name == transitions.Reject {
+		delegacion = transitions.ActingFor(actor, solicitudPrevia)
+	}
+
+	destino := transition.Destination(solicitudPrevia)
+	update := bson.M{"state": string(destino)}
+	switch name {
+	case transitions.Submit:
+		// la cadena de aprobación se arma al enviar, según la política vigente y el importe
+		pasos, err := BuildApprovalChain(solicitudPrevia)
+		if err != nil {
+			return nil, err
+		}
+		setApprovalChain(update, transitions.StepDecision{Aprobaciones: pasos, Completa: len(pasos) == 0})
+	case transitions.Approve, transitions.Reject:
+		estadoPaso := models.ApprovalStepAprobado
+		if name == transitions.Reject {
+			estadoPaso = models.ApprovalStepRechazado
+		}
+		if transitions.CurrentStep(solicitudPrevia) != nil {
+			setApprovalChain(update, transitions.DecideStep(solicitudPrevia, actor, estadoPaso, comentario, time.Now()))
+		}
+		// se registra quien tomó la decisión
+		update["aprobador"] = actor.UserID
+	}
+	// el filtro incluye el estado y el paso de aprobación previos para no pisar una decisión concurrente
+	return saveTransition(ctx, solicitudPrevia, destino, unchangedStateFilter(solicitudPrevia), update,
+		func(tx context.Context, posterior *models.Solicitud) error {
+			_, err := CreateLogFromTransition(tx, posterior, solicitudPrevia, name, delegacion, comentario)
+			return err
+		})
+}
+
+// DecideLineService aprueba o rechaza una línea de la solicitud y deriva el estado de la solicitud
+func DecideLineService(ctx context.Context, id string, numeroLinea int, decision models.LineState, principal *models.Principal, motivo string) (*models.Solicitud, error) {
+	utils.Debug(fmt.Sprintf("Decisión %s sobre línea %d de solicitud %s", decision, numeroLinea, id))
+
+	solicitudPrevia, err := getSolicitudForTransition(id, principal)
+	if err != nil {
+		return nil, err
+	}
+	actor := buildActor(principal, solicitudPrevia)
+
+	req := &transitions.Request{Solicitud: solicitudPrevia, Actor: actor, Comentario: motivo}
+	if err := transitions.CheckLineDecision(req, numeroLinea, decision); err != nil {
+		return nil, err
+	}
+	delegacion := transitions.ActingFor(actor, solicitudPrevia)
+
+	ahora := time.Now()
+	lines := make([]models.Line, len(solicitudPrevia.Lines))
+	copy(lines, solicitudPrevia.Lines)
+	line := transitions.FindLine(lines, numeroLinea)
+	line.Estado = decision
+	line.FechaDecision = &ahora
+	line.DecididoPor = actor.UserID
+	if delegacion != nil {
+		line.EnNombreDe = delegacion.Delegante
+	}
+	line.MotivoDecision = motivo
+
+	nuevoEstado := transitions.DeriveState(transitions.State(solicitudPrevia.State), lines)
+	update := bson.M{"lines": lines, "state": string(nuevoEstado)}
+	if nuevoEstado == transitions.Aprobada || nuevoEstado == transitions.Rechazada {
+		update["aprobador"] = actor.UserID
+		// con todas las líneas decididas se decide el paso actual de la cadena de aprobación;
+		// si quedan pasos la solicitud sigue en aprobación para el siguiente aprobador
+		if transitions.CurrentStep(solicitudPrevia) != nil {
+			estadoPaso := models.ApprovalStepAprobado
+			if nuevoEstado == transitions.Rechazada {
+				estadoPaso = models.ApprovalStepRechazado
+			}
+			decision := transitions.DecideStep(solicitudPrevia, actor, estadoPaso, motivo, ahora)
+			setApprovalChain(update, decision)
+			if !decision.Completa {
+				nuevoEstado = transitions.LineaAprobada
+				update["state"] = string(nuevoEstado)
+			}
+		}
+	}
+	// el presupuesto se calcula con la decisión ya aplicada a las líneas
+	decidida := *solicitudPrevia
+	decidida.Lines = lines
+	// se exige que ninguna línea haya cambiado de decisión para no perder decisiones concurrentes
+	return saveTransition(ctx, &decidida, nuevoEstado, linesUnchangedFilter(solicitudPrevia), update,
+		func(tx context.Context, posterior *models.Solicitud) error {
+			_, err := CreateLogFromLineDecision(tx, posterior, solicitudPrevia, numeroLinea, decision, delegacion, motivo)
+			return err
+		})
+}
+
+// saveTransition guarda el cambio de estado de la solicitud, su movimiento de presupuesto y su log en
+// una transacción, así el log encadenado siempre acompaña al cambio. El presupuesto se calcula sobre
+// base, la solicitud con las decisiones ya aplicadas. Si la solicitud no coincide con filter, otro
+// usuario la cambió y se retorna ErrEstadoModificado
+func saveTransition(ctx context.Context, base *models.Solicitud, destino transitions.State, filter, update bson.M, crearLog func(tx context.Context, posterior *models.Solicitud) error) (*models.Solicitud, error) {
+	var posterior *models.Solicitud
+	err := database.RunTransaction(ctx, func(tx context.Context) error {
+		presupuesto, err := prepareBudgetEffect(tx, base, destino, update)
+		if err != nil {
+			return err
+		}
+		posterior, err = applyTransitionUpdate(tx, presupuesto, base.ID, filter, update, crearLog)
+		if err != nil && !database.TransactionsEnabled() {
+			presupuesto.rollback(tx)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return posterior, nil
+}
+
+// applyTransitionUpdate es el cuerpo de la transacción de saveTransition
+func applyTransitionUpdate(tx context.Context, presupuesto *budgetEffect, id primitive.ObjectID, filter, update bson.M, crearLog func(tx context.Context, posterior *models.Solicitud) error) (*models.Solicitud, error) {
+	matched, err := getSolicitudRepo().UpdateOneIfMatch(tx, filter, update)
+	if err != nil {
+		return nil, err
+	}
+	if !matched {
+		return nil, ErrEstadoModificado
+	}
+	if err := presupuesto.apply(tx); err != nil {
+		return nil, err
+	}
+	posterior, err := getSolicitudRepo().FindByID(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if posterior == nil {
+		return nil, ErrSolicitudNoEncontrada
+	}
+	if err := crearLog(tx, posterior); err != nil {
+		return nil, fmt.Errorf("error al crear log de la transición: %w", err)
+	}
+	return posterior, nil
+}
+
+// linesUnchangedFilter arma un filtro que coincide solo si la solicitud mantiene su estado
+// y la decisión de cada una de sus líneas
+func linesUnchangedFilter(solicitud *models.Solicitud) bson.M {
+	conditions := []bson.M{{"lines": bson.M{"$size": len(solicitud.Lines)}}}
+	decididas := []models.LineState{models.LineStateAprobada, models.LineStateRechazada}
+	for _, l := range solicitud.Lines {
+		estado := bson.M{"$nin": decididas}
+		if l.IsDecided() {
+			estado = bson.M{"$eq": l.Estado}
+		}
+		conditions = append(conditions, bson.M{"lines": bson.M{"$elemMatch": bson.M{"numero_linea": l.NumeroLinea, "estado": estado}}})
+	}
+	filter := unchangedStateFilter(solicitud)
+	filter["$and"] = conditions
+	return filter
+}
+
+// unchangedStateFilter arma un filtro que coincide solo si la solicitud mantiene su estado
+// y su paso de aprobación pendiente
+func unchangedStateFilter(solicitud *models.Solicitud) bson.M {
+	filter := bson.M{"_id": solicitud.ID, "state": solicitud.State}
+	if len(solicitud.Aprobaciones) > 0 {
+		filter["paso_actual"] = solicitud.PasoActual
+	}
+	return filter
+}
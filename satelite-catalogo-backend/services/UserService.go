@@ -81,7 +81,7 @@ func GetUserByEmailService(email string) (models.User, error) {
 	}
 
 	if user == nil {
-		return models.User{}, fmt.Errorf("usuario no encontrado con el email: %s", email)
+		return models.User{}, fmt.Errorf("%w con el email: %s", ErrUsuarioNoEncontrado, email)
 	}
 
 	return *user, nil
@@ -101,7 +101,22 @@ func GetAllUsersService() ([]*models.User, error) {
 func UpdateUserService(updatedUser models.User, userEmail string) (models.User, error) {
 	utils.Debug("Update user")
 
-	err := getUserRepo().UpdateOne(bson.M{"email": userEmail}, bson.M{"$set": updatedUser})
+	raw, err := bson.Marshal(updatedUser)
+	if err != nil {
+		return models.User{}, err
+	}
+	var update bson.M
+	if err := bson.Unmarshal(raw, &update); err != nil {
+		return models.User{}, err
+	}
+	// los centros de costo se administran con /user/{id}/cc para que cada cambio quede auditado
+	delete(update, "cc")
+	delete(update, "cc_observador")
+	if len(update) == 0 {
+		return updatedUser, nil
+	}
+
+	err = getUserRepo().UpdateOne(bson.M{"email": userEmail}, bson.M{"$set": update})
 	if err != nil {
 		return models.User{}, err
 	}
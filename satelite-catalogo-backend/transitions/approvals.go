@@ -0,0 +1,121 @@
+package transitions
+
+import (
+	"time"
+
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CurrentStep retorna el paso de aprobación pendiente, nil si la solicitud no tiene cadena
+// de aprobación o ya se completó
+func CurrentStep(s *models.Solicitud) *models.ApprovalStep {
+	if s.PasoActual < 0 || s.PasoActual >= len(s.Aprobaciones) {
+		return nil
+	}
+	return &s.Aprobaciones[s.PasoActual]
+}
+
+// CanDecideStep indica si el actor es el aprobador del paso
+func CanDecideStep(actor Actor, step *models.ApprovalStep) bool {
+	switch step.Tipo {
+	case models.ApproverRol:
+		return actor.HasRole(step.Rol)
+	case models.ApproverJefeCC:
+		return actor.EsJefeSuperior || (!step.Aprobador.IsZero() && actor.UserID == step.Aprobador)
+	case models.ApproverUsuario:
+		return !step.Aprobador.IsZero() && actor.UserID == step.Aprobador
+	}
+	return false
+}
+
+// ApprovalAuthority indica si el actor puede aprobar el paso pendiente de la solicitud y, cuando lo
+// hace por una delegación, retorna la delegación usada. La autoridad propia tiene prioridad sobre
+// las delegaciones. Las solicitudes sin cadena de aprobación las aprueba el jefe del centro de costo
+func ApprovalAuthority(actor Actor, solicitud *models.Solicitud) (*Delegacion, bool) {
+	step := CurrentStep(solicitud)
+	if step == nil {
+		if actor.EsJefe || actor.EsJefeSuperior {
+			return nil, true
+		}
+		for i := range actor.Delegaciones {
+			if actor.Delegaciones[i].EsJefe {
+				return &actor.Delegaciones[i], true
+			}
+		}
+		return nil, false
+	}
+
+	if CanDecideStep(actor, step) {
+		return nil, true
+	}
+	if step.Aprobador.IsZero() {
+		return nil, false
+	}
+	for i := range actor.Delegaciones {
+		if actor.Delegaciones[i].Delegante == step.Aprobador {
+			return &actor.Delegaciones[i], true
+		}
+	}
+	return nil, false
+}
+
+// ActingFor retorna la delegación con que el actor aprueba la solicitud, nil si lo hace con
+// autoridad propia o como administrador
+func ActingFor(actor Actor, solicitud *models.Solicitud) *Delegacion {
+	if actor.IsAdmin() {
+		return nil
+	}
+	delegacion, _ := ApprovalAuthority(actor, solicitud)
+	return delegacion
+}
+
+// quedanPasos indica si después del paso actual quedan pasos por aprobar
+func quedanPasos(s *models.Solicitud) bool {
+	return s.PasoActual+1 < len(s.Aprobaciones)
+}
+
+// StepDecision es el resultado de decidir el paso actual de la cadena de aprobación
+type StepDecision struct {
+	Aprobaciones []models.ApprovalStep
+	PasoActual   int
+	// Completa indica que no quedan pasos pendientes, ya sea porque se aprobó el último o porque se rechazó
+	Completa bool
+}
+
+// DecideStep registra la decisión del actor sobre el paso actual y avanza la cadena.
+// Trabaja sobre una copia de los pasos; sin cadena de aprobación retorna Completa
+func DecideStep(s *models.Solicitud, actor Actor, estado models.ApprovalStepState, comentario string, fecha time.Time) StepDecision {
+	if CurrentStep(s) == nil {
+		return StepDecision{Aprobaciones: s.Aprobaciones, PasoActual: s.PasoActual, Completa: true}
+	}
+	pasos := make([]models.ApprovalStep, len(s.Aprobaciones))
+	copy(pasos, s.Aprobaciones)
+
+	paso := &pasos[s.PasoActual]
+	paso.Estado = estado
+	paso.DecididoPor = actor.UserID
+	if delegacion := ActingFor(actor, s); delegacion != nil {
+		paso.EnNombreDe = delegacion.Delegante
+	}
+	paso.Fecha = &fecha
+	paso.Comentario = comentario
+
+	decision := StepDecision{Aprobaciones: pasos, PasoActual: s.PasoActual}
+	if estado == models.ApprovalStepAprobado && quedanPasos(s) {
+		decision.PasoActual++
+		return decision
+	}
+	decision.Completa = true
+	return decision
+}
+
+// NextApprover retorna el aprobador y el rol del paso pendiente para guardarlos en la solicitud
+func (d StepDecision) NextApprover() (primitive.ObjectID, models.Role) {
+	if d.Completa || d.PasoActual >= len(d.Aprobaciones) {
+		return primitive.NilObjectID, ""
+	}
+	paso := d.Aprobaciones[d.PasoActual]
+	return paso.Aprobador, paso.Rol
+}
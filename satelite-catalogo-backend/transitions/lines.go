@@ -0,0 +1,84 @@
+package transitions
+
+import (
+	"strings"
+
+	"catalogo-backend/models"
+)
+
+// estados en los que se pueden decidir líneas individuales
+var lineDecisionStates = []State{PendienteAprobacion, LineaAprobada}
+
+// CheckLineDecision valida que el actor pueda aprobar o rechazar la línea indicada
+func CheckLineDecision(req *Request, numeroLinea int, decision models.LineState) error {
+	allowed := false
+	for _, s := range lineDecisionStates {
+		if State(req.Solicitud.State) == s {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return ErrTransicionInvalida
+	}
+	// decidir una línea requiere los mismos permisos que aprobar la solicitud
+	if !graph[Approve].Allowed(req.Actor, req.Solicitud) {
+		return ErrNoAutorizado
+	}
+
+	line := FindLine(req.Solicitud.Lines, numeroLinea)
+	if line == nil {
+		return &GuardError{Message: "la línea no existe en la solicitud"}
+	}
+	if line.IsDecided() {
+		return &GuardError{Message: "la línea ya fue decidida"}
+	}
+	if decision == models.LineStateRechazada && strings.TrimSpace(req.Comentario) == "" {
+		return &GuardError{Message: "se requiere un motivo para rechazar la línea"}
+	}
+	return nil
+}
+
+// FindLine busca una línea por su numero_linea
+func FindLine(lines []models.Line, numeroLinea int) *models.Line {
+	for i := range lines {
+		if lines[i].NumeroLinea == numeroLinea {
+			return &lines[i]
+		}
+	}
+	return nil
+}
+
+// DeriveState calcula el estado de la solicitud a partir de sus líneas:
+// con todas las líneas decididas queda Aprobada si alguna fue aprobada o
+// Rechazada si todas fueron rechazadas; con decisiones parciales queda en
+// Línea Aprobada si ya hay alguna aprobada. En otro caso mantiene el actual.
+func DeriveState(current State, lines []models.Line) State {
+	if len(lines) == 0 {
+		return current
+	}
+	decididas, aprobadas := 0, 0
+	for _, l := range lines {
+		if l.IsDecided() {
+			decididas++
+		}
+		if l.Estado == models.LineStateAprobada {
+			aprobadas++
+		}
+	}
+	switch {
+	case decididas == len(lines) && aprobadas > 0:
+		return Aprobada
+	case decididas == len(lines):
+		return Rechazada
+	case aprobadas > 0:
+		return LineaAprobada
+	default:
+		return current
+	}
+}
+
+// Editable indica si las líneas de una solicitud en este estado pueden modificarse libremente
+func Editable(state State) bool {
+	return state == Inicial || state == RevisionPreliminar
+}
@@ -0,0 +1,266 @@
+// Package transitions define la máquina de estados de una solicitud: los
+// estados válidos, las transiciones permitidas entre ellos, quién puede
+// ejecutarlas y las condiciones (guards) que deben cumplirse.
+package transitions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"catalogo-backend/models"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// State corresponde al código de estado guardado en Solicitud.State
+type State string
+
+// Estados de una solicitud, los mismos códigos que usa el frontend
+const (
+	Inicial             State = "I"
+	RevisionPreliminar  State = "V"
+	PendienteAprobacion State = "P"
+	Aprobada            State = "A"
+	LineaAprobada       State = "LA"
+	Finalizada          State = "C"
+	Rechazada           State = "D"
+)
+
+// Cerrados son los estados de los que no sale ninguna transición
+var Cerrados = []State{Finalizada, Rechazada}
+
+// IsClosed indica si la solicitud ya no puede cambiar de estado
+func IsClosed(state State) bool {
+	for _, s := range Cerrados {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Name identifica una transición, se usa como parámetro en la ruta
+type Name string
+
+const (
+	Submit  Name = "submit"
+	Approve Name = "approve"
+	Reject  Name = "reject"
+	Close   Name = "close"
+)
+
+var (
+	ErrTransicionDesconocida = errors.New("transición desconocida")
+	ErrTransicionInvalida    = errors.New("transición no permitida desde el estado actual")
+	ErrNoAutorizado          = errors.New("el usuario no puede ejecutar esta transición")
+)
+
+// GuardError se retorna cuando la solicitud no cumple una condición de la transición
+type GuardError struct {
+	Message string
+}
+
+func (e *GuardError) Error() string {
+	return e.Message
+}
+
+// Actor representa al usuario que intenta ejecutar la transición
+type Actor struct {
+	UserID primitive.ObjectID
+	Roles  []models.Role
+	// EsJefe indica si el usuario es jefe del centro de costo de la solicitud
+	EsJefe bool
+	// EsJefeSuperior indica si el usuario dirige un centro de costo ancestro del de la solicitud y
+	// la configuración le permite aprobar en lugar del jefe directo
+	EsJefeSuperior bool
+	// Delegaciones vigentes que cubren el centro de costo de la solicitud
+	Delegaciones []Delegacion
+}
+
+// Delegacion es la autoridad de aprobación que el actor ejerce en nombre de otro usuario
+type Delegacion struct {
+	ID        primitive.ObjectID
+	Delegante primitive.ObjectID
+	// EsJefe indica si el delegante es jefe del centro de costo de la solicitud
+	EsJefe bool
+}
+
+func (a Actor) HasRole(role models.Role) bool {
+	for _, r := range a.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (a Actor) IsAdmin() bool {
+	return a.HasRole(models.ADMIN)
+}
+
+// Request agrupa los datos necesarios para evaluar una transición
+type Request struct {
+	Solicitud  *models.Solicitud
+	Actor      Actor
+	Comentario string
+}
+
+// Guard valida una condición de la transición, retorna un *GuardError si no se cumple
+type Guard func(req *Request) error
+
+// Transition describe un movimiento del grafo de estados
+type Transition struct {
+	Name Name
+	From []State
+	To   State
+	// Allowed decide si el actor puede ejecutar la transición sobre la solicitud
+	Allowed func(actor Actor, solicitud *models.Solicitud) bool
+	Guards  []Guard
+	// Target permite que el estado de destino dependa de la solicitud, si es nil se usa To
+	Target func(solicitud *models.Solicitud) State
+}
+
+// grafo de transiciones legales, para agregar una transición hacerlo aca
+var graph = map[Name]*Transition{
+	Submit: {
+		Name:    Submit,
+		From:    []State{Inicial, RevisionPreliminar},
+		To:      PendienteAprobacion,
+		Allowed: solicitanteOAdmin,
+		Guards:  []Guard{tieneLineas, tieneCentroCosto},
+	},
+	Approve: {
+		Name:    Approve,
+		From:    []State{PendienteAprobacion, LineaAprobada},
+		To:      Aprobada,
+		Allowed: aprobadorOAdmin,
+		Guards:  []Guard{tieneLineas},
+		Target:  siguientePaso,
+	},
+	Reject: {
+		Name:    Reject,
+		From:    []State{PendienteAprobacion, LineaAprobada},
+		To:      Rechazada,
+		Allowed: aprobadorOAdmin,
+		Guards:  []Guard{requiereComentario},
+	},
+	Close: {
+		Name:    Close,
+		From:    []State{Aprobada},
+		To:      Finalizada,
+		Allowed: soloAdmin,
+	},
+}
+
+// Find retorna la transición registrada con el nombre indicado
+func Find(name Name) (*Transition, error) {
+	t, ok := graph[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTransicionDesconocida, name)
+	}
+	return t, nil
+}
+
+// CanLeave indica si la transición puede ejecutarse desde el estado indicado
+func (t *Transition) CanLeave(state State) bool {
+	for _, from := range t.From {
+		if from == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Destination retorna el estado al que lleva la transición sobre la solicitud
+func (t *Transition) Destination(solicitud *models.Solicitud) State {
+	if t.Target != nil {
+		return t.Target(solicitud)
+	}
+	return t.To
+}
+
+// Check valida estado de origen, permisos y guards, en ese orden
+func (t *Transition) Check(req *Request) error {
+	if !t.CanLeave(State(req.Solicitud.State)) {
+		return fmt.Errorf("%w: %s -> %s", ErrTransicionInvalida, req.Solicitud.State, t.To)
+	}
+	if t.Allowed != nil && !t.Allowed(req.Actor, req.Solicitud) {
+		return ErrNoAutorizado
+	}
+	for _, guard := range t.Guards {
+		if err := guard(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Available retorna las transiciones que el actor puede ejecutar sobre la solicitud
+func Available(req *Request) []Name {
+	names := []Name{}
+	for _, name := range []Name{Submit, Approve, Reject, Close} {
+		t := graph[name]
+		if !t.CanLeave(State(req.Solicitud.State)) {
+			continue
+		}
+		if t.Allowed != nil && !t.Allowed(req.Actor, req.Solicitud) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Quién puede ejecutar cada transición
+
+func solicitanteOAdmin(actor Actor, solicitud *models.Solicitud) bool {
+	return actor.IsAdmin() || actor.UserID == solicitud.Solicitante
+}
+
+// aprobadorOAdmin permite al aprobador del paso pendiente de la cadena de aprobación. Las
+// solicitudes sin cadena (enviadas antes de las políticas de aprobación) las aprueba el jefe del CC
+func aprobadorOAdmin(actor Actor, solicitud *models.Solicitud) bool {
+	if actor.IsAdmin() {
+		return true
+	}
+	_, ok := ApprovalAuthority(actor, solicitud)
+	return ok
+}
+
+func soloAdmin(actor Actor, solicitud *models.Solicitud) bool {
+	return actor.IsAdmin()
+}
+
+// Destinos
+
+// siguientePaso mantiene el estado mientras queden pasos de aprobación después del actual
+func siguientePaso(solicitud *models.Solicitud) State {
+	if CurrentStep(solicitud) != nil && quedanPasos(solicitud) {
+		return State(solicitud.State)
+	}
+	return Aprobada
+}
+
+// Guards
+
+func tieneLineas(req *Request) error {
+	if len(req.Solicitud.Lines) == 0 {
+		return &GuardError{Message: "la solicitud no tiene líneas"}
+	}
+	return nil
+}
+
+func tieneCentroCosto(req *Request) error {
+	if req.Solicitud.CC.IsZero() {
+		return &GuardError{Message: "la solicitud no tiene centro de costo"}
+	}
+	return nil
+}
+
+func requiereComentario(req *Request) error {
+	if strings.TrimSpace(req.Comentario) == "" {
+		return &GuardError{Message: "se requiere un comentario para rechazar la solicitud"}
+	}
+	return nil
+}
@@ -9,7 +9,7 @@ import (
 )
 
 func checkVars() []string {
-	vars := []string{"GO_REST_ENV", "ADDR", "JWT_KEY", "MONGO_URI", "DB_NAME"}
+	vars := []string{"GO_REST_ENV", "ADDR", "JWT_KEY", "LOG_CHAIN_KEY", "MONGO_URI", "DB_NAME"}
 	missing := []string{}
 	for _, v := range vars {
 		_, set := os.LookupEnv(v)
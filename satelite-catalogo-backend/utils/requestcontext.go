@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RequestInfo identifica quién hizo la llamada que originó una operación, se registra en los logs
+type RequestInfo struct {
+	UserID        primitive.ObjectID
+	IP            string
+	UserAgent     string
+	CorrelationID string
+}
+
+type requestInfoKey struct{}
+
+// WithRequestInfo agrega los datos de la llamada al contexto que se pasa a los servicios
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// GetRequestInfo retorna los datos de la llamada, vacíos si la operación no viene de una llamada
+// a la API (ej: la CLI)
+func GetRequestInfo(ctx context.Context) RequestInfo {
+	if ctx == nil {
+		return RequestInfo{}
+	}
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NormalizeRut deja un RUT en el formato 12345678-K, sin puntos y con dígito verificador en mayúscula
+func NormalizeRut(rut string) string {
+	rut = strings.ToUpper(strings.TrimSpace(rut))
+	rut = strings.NewReplacer(".", "", " ", "").Replace(rut)
+	if !strings.Contains(rut, "-") && len(rut) > 1 {
+		rut = rut[:len(rut)-1] + "-" + rut[len(rut)-1:]
+	}
+	return rut
+}
+
+// ValidateRut verifica el dígito verificador de un RUT chileno (módulo 11)
+func ValidateRut(rut string) bool {
+	partes := strings.Split(NormalizeRut(rut), "-")
+	if len(partes) != 2 || partes[0] == "" || len(partes[1]) != 1 {
+		return false
+	}
+	numero, err := strconv.Atoi(partes[0])
+	if err != nil || numero <= 0 {
+		return false
+	}
+
+	suma, multiplicador := 0, 2
+	for ; numero > 0; numero /= 10 {
+		suma += (numero % 10) * multiplicador
+		multiplicador++
+		if multiplicador > 7 {
+			multiplicador = 2
+		}
+	}
+	var dv string
+	switch resto := 11 - suma%11; resto {
+	case 11:
+		dv = "0"
+	case 10:
+		dv = "K"
+	default:
+		dv = strconv.Itoa(resto)
+	}
+	return partes[1] == dv
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ReadSpreadsheet lee un archivo CSV o XLSX (según su extensión) y retorna sus filas,
+// la primera fila corresponde a los encabezados. De los XLSX se lee la primera hoja
+func ReadSpreadsheet(filename string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv", ".txt":
+		return readCSV(r)
+	case ".xlsx":
+		return readXLSX(r)
+	default:
+		return nil, fmt.Errorf("formato de archivo no soportado: %s", filepath.Ext(filename))
+	}
+}
+
+func readCSV(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	// Los CSV exportados desde Excel suelen venir con BOM
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = detectDelimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return reader.ReadAll()
+}
+
+// detectDelimiter elige entre ';' y ',' según cuál aparece más en la primera línea,
+// las planillas de Mercado Público en configuración regional chilena usan ';'
+func detectDelimiter(data []byte) rune {
+	firstLine, _ := bufio.NewReader(bytes.NewReader(data)).ReadString('\n')
+	if strings.Count(firstLine, ";") > strings.Count(firstLine, ",") {
+		return ';'
+	}
+	return ','
+}
+
+func readXLSX(r io.Reader) ([][]string, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, fmt.Errorf("el archivo no tiene hojas")
+	}
+	return f.GetRows(sheets[0])
+}
+
+// NormalizeHeader deja un encabezado en minúsculas, sin tildes y con "_" en lugar de espacios,
+// ej: "Región " -> "region", "ID Convenio" -> "id_convenio"
+func NormalizeHeader(header string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.TrimSpace(header)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// se descartan las marcas diacríticas
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
+
+// formatos de fecha aceptados en las planillas
+var dateLayouts = []string{"2006-01-02", "02-01-2006", "02/01/2006", "2/1/2006", "01-02-06"}
+
+// ParseDate interpreta una fecha de planilla en formato ISO (2006-01-02) o chileno (02-01-2006, 02/01/2006)
+func ParseDate(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("fecha inválida: %s", raw)
+}
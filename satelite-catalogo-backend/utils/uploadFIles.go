@@ -10,16 +10,89 @@ import (
 	"strings"
 )
 
-// funcion que se encarga de guardar los archivos subidos en una carpeta según su prefijo
-// de que linea pertenecen, a si misma todos  los archivos que tengan el prefijo "linea_X" se guardan en una subcarpeta
-// segun el id de la solicitud
-func GuardarArchivos(archivos []*multipart.FileHeader, carpetaID string) ([]string, error) {
+// StagingDirName es la carpeta dentro de UPLOAD_DIR donde quedan los archivos subidos hasta que se
+// confirma la operación que los usa, no se expone en /archivos
+const StagingDirName = ".staging"
+
+// UploadRoot retorna la carpeta raíz de los archivos subidos
+func UploadRoot() string {
 	uploadRoot := os.Getenv("UPLOAD_DIR")
 	if uploadRoot == "" {
 		uploadRoot = "./uploads"
 	}
-	destinoRaiz := filepath.Join(uploadRoot, carpetaID)
+	return uploadRoot
+}
+
+// ArchivosPendientes son archivos subidos que están en staging. Se mueven a su carpeta definitiva con
+// Promover una vez confirmada la operación, o se eliminan con Descartar si la operación falla
+type ArchivosPendientes struct {
+	Rutas     []string // rutas relativas definitivas, para guardar en la base de datos
+	staging   string
+	destino   string
+	promovido bool
+}
+
+// StageArchivos guarda los archivos subidos en staging, con las mismas subcarpetas que tendrán en
+// la carpeta definitiva de la solicitud
+func StageArchivos(archivos []*multipart.FileHeader, carpetaID string) (*ArchivosPendientes, error) {
+	staging, err := os.MkdirTemp(filepath.Join(UploadRoot(), StagingDirName), carpetaID+"-")
+	if os.IsNotExist(err) {
+		if err = os.MkdirAll(filepath.Join(UploadRoot(), StagingDirName), os.ModePerm); err == nil {
+			staging, err = os.MkdirTemp(filepath.Join(UploadRoot(), StagingDirName), carpetaID+"-")
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	pendientes := &ArchivosPendientes{staging: staging, destino: filepath.Join(UploadRoot(), carpetaID)}
+	pendientes.Rutas, err = guardarArchivos(archivos, staging, carpetaID)
+	if err != nil {
+		pendientes.Descartar()
+		return nil, err
+	}
+	return pendientes, nil
+}
+
+// Promover mueve los archivos de staging a la carpeta definitiva
+func (p *ArchivosPendientes) Promover() error {
+	if p == nil || p.promovido {
+		return nil
+	}
+	err := filepath.WalkDir(p.staging, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(p.staging, path)
+		if err != nil {
+			return err
+		}
+		destino := filepath.Join(p.destino, rel)
+		if err := os.MkdirAll(filepath.Dir(destino), os.ModePerm); err != nil {
+			return err
+		}
+		return os.Rename(path, destino)
+	})
+	if err != nil {
+		return err
+	}
+	p.promovido = true
+	return os.RemoveAll(p.staging)
+}
+
+// Descartar elimina los archivos que siguen en staging, no hace nada si ya se promovieron
+func (p *ArchivosPendientes) Descartar() {
+	if p == nil || p.promovido {
+		return
+	}
+	if err := os.RemoveAll(p.staging); err != nil {
+		Debug("Error al descartar archivos en staging:", err)
+	}
+}
 
+// guardarArchivos guarda los archivos subidos en destinoRaiz en una subcarpeta según su prefijo
+// de que linea pertenecen, a si misma todos  los archivos que tengan el prefijo "linea_X" se guardan en una subcarpeta.
+// Las rutas retornadas son las definitivas según el id de la solicitud
+func guardarArchivos(archivos []*multipart.FileHeader, destinoRaiz string, carpetaID string) ([]string, error) {
 	if err := os.MkdirAll(destinoRaiz, os.ModePerm); err != nil {
 		return nil, err
 	}